@@ -0,0 +1,201 @@
+// Package actype maps ICAO aircraft type designators (A320, B77W, EC35…) to
+// their ICAO Doc 8643 description, wake turbulence category and a friendly
+// family name, and reconciles that with the ADS-B emitter category so the board
+// can tell helicopters and light aircraft apart from airliners.
+package actype
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Kinds an aircraft is classified as.
+const (
+	KindAirliner   = "airliner"
+	KindBusiness   = "business"
+	KindLight      = "light"
+	KindHelicopter = "helicopter"
+	KindMilitary   = "military"
+	KindGlider     = "glider" // gliders, balloons, ultralights
+	KindUAV        = "uav"
+	KindGround     = "ground" // surface vehicles
+	KindUnknown    = "unknown"
+)
+
+// Type describes one type designator.
+type Type struct {
+	Designator  string `json:"designator"`
+	Family      string `json:"family"`
+	Description string `json:"description"`   // e.g. L2J: landplane, 2 engines, jet
+	WTC         string `json:"wake_category"` // L, M, H or J
+	Kind        string `json:"kind"`
+}
+
+// Class returns the aircraft class from the description, e.g. "Landplane".
+func (t Type) Class() string {
+	if t.Description == "" {
+		return ""
+	}
+	switch t.Description[0] {
+	case 'L':
+		return "Landplane"
+	case 'S':
+		return "Seaplane"
+	case 'A':
+		return "Amphibian"
+	case 'H':
+		return "Helicopter"
+	case 'G':
+		return "Gyrocopter"
+	case 'T':
+		return "Tiltrotor"
+	}
+	return ""
+}
+
+// Engines returns the engine count from the description, 0 if unknown.
+func (t Type) Engines() int {
+	if len(t.Description) < 2 || t.Description[1] < '1' || t.Description[1] > '8' {
+		return 0
+	}
+	return int(t.Description[1] - '0')
+}
+
+// EngineType returns the engine type from the description, e.g. "Jet".
+func (t Type) EngineType() string {
+	if len(t.Description) < 3 {
+		return ""
+	}
+	switch t.Description[2] {
+	case 'J':
+		return "Jet"
+	case 'T':
+		return "Turboprop"
+	case 'P':
+		return "Piston"
+	case 'E':
+		return "Electric"
+	case 'R':
+		return "Rocket"
+	}
+	return ""
+}
+
+// DB is a set of type designators.
+type DB struct {
+	types map[string]Type
+}
+
+// Bundled returns the built-in table of types commonly seen around London.
+func Bundled() *DB {
+	db := &DB{types: make(map[string]Type, len(bundled))}
+	for _, t := range bundled {
+		db.types[t.Designator] = t
+	}
+	return db
+}
+
+// Load returns the bundled table overlaid with a CSV whose header includes
+// designator, description and wtc, and optionally family and kind. An empty
+// path returns the bundled table.
+func Load(path string) (*DB, error) {
+	db := Bundled()
+	if path == "" {
+		return db, nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = -1
+	header, err := r.Read()
+	if err != nil {
+		return nil, fmt.Errorf("%s: read header: %w", path, err)
+	}
+	col := make(map[string]int)
+	for i, h := range header {
+		col[strings.ToLower(strings.TrimSpace(h))] = i
+	}
+	if _, ok := col["designator"]; !ok {
+		return nil, fmt.Errorf("%s: no designator column", path)
+	}
+	for {
+		row, err := r.Read()
+		if err == io.EOF {
+			return db, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		get := func(name string) string {
+			i, ok := col[name]
+			if !ok || i >= len(row) {
+				return ""
+			}
+			return strings.TrimSpace(row[i])
+		}
+		t := Type{
+			Designator:  strings.ToUpper(get("designator")),
+			Family:      get("family"),
+			Description: strings.ToUpper(get("description")),
+			WTC:         strings.ToUpper(get("wtc")),
+			Kind:        strings.ToLower(get("kind")),
+		}
+		if t.Designator == "" {
+			continue
+		}
+		if old, ok := db.types[t.Designator]; ok {
+			t.Family = firstNonEmpty(t.Family, old.Family)
+			t.Kind = firstNonEmpty(t.Kind, old.Kind)
+		}
+		if t.Kind == "" {
+			t.Kind = kindFromDescription(t.Description, t.WTC)
+		}
+		db.types[t.Designator] = t
+	}
+}
+
+// Lookup returns the type for an ICAO designator (any case).
+func (db *DB) Lookup(designator string) (Type, bool) {
+	if db == nil {
+		return Type{}, false
+	}
+	t, ok := db.types[strings.ToUpper(strings.TrimSpace(designator))]
+	return t, ok
+}
+
+// Len returns the number of designators.
+func (db *DB) Len() int {
+	if db == nil {
+		return 0
+	}
+	return len(db.types)
+}
+
+// kindFromDescription guesses the kind of a type not in the bundled table.
+func kindFromDescription(desc, wtc string) string {
+	t := Type{Description: desc}
+	switch {
+	case t.Class() == "Helicopter" || t.Class() == "Gyrocopter":
+		return KindHelicopter
+	case wtc == "L" || t.EngineType() == "Piston":
+		return KindLight
+	case wtc == "M" || wtc == "H" || wtc == "J":
+		return KindAirliner
+	}
+	return KindUnknown
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,102 @@
+package actype
+
+// ADS-B emitter categories as OpenSky numbers them (state vector index 17).
+const (
+	CategoryNone           = 0
+	CategoryNoInfo         = 1
+	CategoryLight          = 2 // < 15,500 lbs
+	CategorySmall          = 3 // 15,500 to 75,000 lbs
+	CategoryLarge          = 4 // 75,000 to 300,000 lbs
+	CategoryHighVortex     = 5 // e.g. B757
+	CategoryHeavy          = 6 // > 300,000 lbs
+	CategoryHighPerf       = 7 // > 5g and 400 kts
+	CategoryRotorcraft     = 8
+	CategoryGlider         = 9
+	CategoryLighterThanAir = 10
+	CategoryParachutist    = 11
+	CategoryUltralight     = 12
+	CategoryUAV            = 14
+	CategorySpace          = 15
+	CategoryEmergencyVeh   = 16
+	CategoryServiceVeh     = 17
+)
+
+var categoryNames = map[int]string{
+	CategoryLight:          "Light",
+	CategorySmall:          "Small",
+	CategoryLarge:          "Large",
+	CategoryHighVortex:     "High vortex large",
+	CategoryHeavy:          "Heavy",
+	CategoryHighPerf:       "High performance",
+	CategoryRotorcraft:     "Rotorcraft",
+	CategoryGlider:         "Glider",
+	CategoryLighterThanAir: "Lighter than air",
+	CategoryParachutist:    "Parachutist",
+	CategoryUltralight:     "Ultralight",
+	CategoryUAV:            "UAV",
+	CategorySpace:          "Space vehicle",
+	CategoryEmergencyVeh:   "Emergency vehicle",
+	CategoryServiceVeh:     "Service vehicle",
+}
+
+// CategoryFromCode converts a DO-260 emitter category as receivers print it,
+// e.g. "A3", to OpenSky's number. Unknown codes give CategoryNone.
+func CategoryFromCode(code string) int {
+	if len(code) != 2 || code[1] < '0' || code[1] > '7' {
+		return CategoryNone
+	}
+	n := int(code[1] - '0')
+	switch code[0] {
+	case 'A':
+		if n == 0 {
+			return CategoryNoInfo
+		}
+		return CategoryLight + n - 1
+	case 'B':
+		if n == 0 {
+			return CategoryNoInfo
+		}
+		return CategoryGlider + n - 1
+	case 'C':
+		if n == 1 || n == 2 {
+			return CategoryEmergencyVeh + n - 1
+		}
+	}
+	return CategoryNone
+}
+
+// CategoryName returns a readable emitter category, or "" when none was sent.
+func CategoryName(category int) string {
+	return categoryNames[category]
+}
+
+// Classify reconciles the type table with the emitter category. A known type
+// wins, except that a rotorcraft category overrides a fixed-wing type (the
+// designator is usually wrong in that case). Without a type the category decides.
+func Classify(t *Type, category int) string {
+	if t != nil && t.Kind != "" && t.Kind != KindUnknown {
+		if category == CategoryRotorcraft && t.Kind != KindHelicopter {
+			return KindHelicopter
+		}
+		return t.Kind
+	}
+	switch category {
+	case CategoryRotorcraft:
+		return KindHelicopter
+	case CategoryLight:
+		return KindLight
+	case CategorySmall:
+		return KindBusiness
+	case CategoryLarge, CategoryHighVortex, CategoryHeavy:
+		return KindAirliner
+	case CategoryHighPerf:
+		return KindMilitary
+	case CategoryGlider, CategoryLighterThanAir, CategoryParachutist, CategoryUltralight:
+		return KindGlider
+	case CategoryUAV:
+		return KindUAV
+	case CategoryEmergencyVeh, CategoryServiceVeh:
+		return KindGround
+	}
+	return KindUnknown
+}
@@ -0,0 +1,188 @@
+package actype
+
+// bundled covers the types that make up nearly all traffic around London:
+// airliners, business jets, light aircraft, helicopters and the military
+// types that transit the area. Descriptions and wake categories follow ICAO
+// Doc 8643.
+var bundled = []Type{
+	// Airbus
+	{"A318", "Airbus A320 family", "L2J", "M", KindAirliner},
+	{"A319", "Airbus A320 family", "L2J", "M", KindAirliner},
+	{"A320", "Airbus A320 family", "L2J", "M", KindAirliner},
+	{"A321", "Airbus A320 family", "L2J", "M", KindAirliner},
+	{"A19N", "Airbus A320neo family", "L2J", "M", KindAirliner},
+	{"A20N", "Airbus A320neo family", "L2J", "M", KindAirliner},
+	{"A21N", "Airbus A320neo family", "L2J", "M", KindAirliner},
+	{"BCS1", "Airbus A220", "L2J", "M", KindAirliner},
+	{"BCS3", "Airbus A220", "L2J", "M", KindAirliner},
+	{"A306", "Airbus A300", "L2J", "H", KindAirliner},
+	{"A310", "Airbus A310", "L2J", "H", KindAirliner},
+	{"A332", "Airbus A330", "L2J", "H", KindAirliner},
+	{"A333", "Airbus A330", "L2J", "H", KindAirliner},
+	{"A338", "Airbus A330neo", "L2J", "H", KindAirliner},
+	{"A339", "Airbus A330neo", "L2J", "H", KindAirliner},
+	{"A343", "Airbus A340", "L4J", "H", KindAirliner},
+	{"A346", "Airbus A340", "L4J", "H", KindAirliner},
+	{"A359", "Airbus A350", "L2J", "H", KindAirliner},
+	{"A35K", "Airbus A350", "L2J", "H", KindAirliner},
+	{"A388", "Airbus A380", "L4J", "J", KindAirliner},
+	{"A337", "Airbus BelugaXL", "L2J", "H", KindAirliner},
+
+	// Boeing
+	{"B737", "Boeing 737", "L2J", "M", KindAirliner},
+	{"B738", "Boeing 737", "L2J", "M", KindAirliner},
+	{"B739", "Boeing 737", "L2J", "M", KindAirliner},
+	{"B37M", "Boeing 737 MAX", "L2J", "M", KindAirliner},
+	{"B38M", "Boeing 737 MAX", "L2J", "M", KindAirliner},
+	{"B39M", "Boeing 737 MAX", "L2J", "M", KindAirliner},
+	{"B3XM", "Boeing 737 MAX", "L2J", "M", KindAirliner},
+	{"B744", "Boeing 747", "L4J", "H", KindAirliner},
+	{"B748", "Boeing 747", "L4J", "H", KindAirliner},
+	{"B752", "Boeing 757", "L2J", "M", KindAirliner},
+	{"B753", "Boeing 757", "L2J", "M", KindAirliner},
+	{"B762", "Boeing 767", "L2J", "H", KindAirliner},
+	{"B763", "Boeing 767", "L2J", "H", KindAirliner},
+	{"B764", "Boeing 767", "L2J", "H", KindAirliner},
+	{"B772", "Boeing 777", "L2J", "H", KindAirliner},
+	{"B77L", "Boeing 777", "L2J", "H", KindAirliner},
+	{"B77W", "Boeing 777", "L2J", "H", KindAirliner},
+	{"B778", "Boeing 777X", "L2J", "H", KindAirliner},
+	{"B779", "Boeing 777X", "L2J", "H", KindAirliner},
+	{"B788", "Boeing 787", "L2J", "H", KindAirliner},
+	{"B789", "Boeing 787", "L2J", "H", KindAirliner},
+	{"B78X", "Boeing 787", "L2J", "H", KindAirliner},
+	{"MD11", "McDonnell Douglas MD-11", "L3J", "H", KindAirliner},
+
+	// Regional
+	{"E170", "Embraer E-Jet", "L2J", "M", KindAirliner},
+	{"E75L", "Embraer E-Jet", "L2J", "M", KindAirliner},
+	{"E75S", "Embraer E-Jet", "L2J", "M", KindAirliner},
+	{"E190", "Embraer E-Jet", "L2J", "M", KindAirliner},
+	{"E195", "Embraer E-Jet", "L2J", "M", KindAirliner},
+	{"E290", "Embraer E-Jet E2", "L2J", "M", KindAirliner},
+	{"E295", "Embraer E-Jet E2", "L2J", "M", KindAirliner},
+	{"E135", "Embraer ERJ", "L2J", "M", KindAirliner},
+	{"E145", "Embraer ERJ", "L2J", "M", KindAirliner},
+	{"CRJ2", "Bombardier CRJ", "L2J", "M", KindAirliner},
+	{"CRJ7", "Bombardier CRJ", "L2J", "M", KindAirliner},
+	{"CRJ9", "Bombardier CRJ", "L2J", "M", KindAirliner},
+	{"CRJX", "Bombardier CRJ", "L2J", "M", KindAirliner},
+	{"RJ85", "Avro RJ", "L4J", "M", KindAirliner},
+	{"RJ1H", "Avro RJ", "L4J", "M", KindAirliner},
+	{"F70", "Fokker 70/100", "L2J", "M", KindAirliner},
+	{"F100", "Fokker 70/100", "L2J", "M", KindAirliner},
+	{"DH8A", "De Havilland Dash 8", "L2T", "M", KindAirliner},
+	{"DH8C", "De Havilland Dash 8", "L2T", "M", KindAirliner},
+	{"DH8D", "De Havilland Dash 8", "L2T", "M", KindAirliner},
+	{"AT43", "ATR 42/72", "L2T", "M", KindAirliner},
+	{"AT45", "ATR 42/72", "L2T", "M", KindAirliner},
+	{"AT72", "ATR 42/72", "L2T", "M", KindAirliner},
+	{"AT75", "ATR 42/72", "L2T", "M", KindAirliner},
+	{"AT76", "ATR 42/72", "L2T", "M", KindAirliner},
+	{"SF34", "Saab 340", "L2T", "M", KindAirliner},
+	{"SB20", "Saab 2000", "L2T", "M", KindAirliner},
+	{"D328", "Dornier 328", "L2T", "M", KindAirliner},
+	{"J328", "Dornier 328", "L2J", "M", KindAirliner},
+
+	// Business
+	{"C510", "Cessna Citation Mustang", "L2J", "L", KindBusiness},
+	{"C525", "Cessna CitationJet", "L2J", "L", KindBusiness},
+	{"C25A", "Cessna CitationJet", "L2J", "L", KindBusiness},
+	{"C25B", "Cessna CitationJet", "L2J", "L", KindBusiness},
+	{"C25C", "Cessna CitationJet", "L2J", "L", KindBusiness},
+	{"C56X", "Cessna Citation Excel", "L2J", "M", KindBusiness},
+	{"C560", "Cessna Citation V", "L2J", "M", KindBusiness},
+	{"C680", "Cessna Citation Sovereign", "L2J", "M", KindBusiness},
+	{"C68A", "Cessna Citation Latitude", "L2J", "M", KindBusiness},
+	{"C700", "Cessna Citation Longitude", "L2J", "M", KindBusiness},
+	{"C750", "Cessna Citation X", "L2J", "M", KindBusiness},
+	{"E50P", "Embraer Phenom", "L2J", "L", KindBusiness},
+	{"E55P", "Embraer Phenom", "L2J", "M", KindBusiness},
+	{"E545", "Embraer Legacy/Praetor", "L2J", "M", KindBusiness},
+	{"E550", "Embraer Legacy/Praetor", "L2J", "M", KindBusiness},
+	{"E35L", "Embraer Legacy 600", "L2J", "M", KindBusiness},
+	{"CL30", "Bombardier Challenger", "L2J", "M", KindBusiness},
+	{"CL35", "Bombardier Challenger", "L2J", "M", KindBusiness},
+	{"CL60", "Bombardier Challenger", "L2J", "M", KindBusiness},
+	{"GLEX", "Bombardier Global", "L2J", "M", KindBusiness},
+	{"GL5T", "Bombardier Global", "L2J", "M", KindBusiness},
+	{"GL7T", "Bombardier Global", "L2J", "M", KindBusiness},
+	{"LJ45", "Learjet", "L2J", "M", KindBusiness},
+	{"LJ75", "Learjet", "L2J", "M", KindBusiness},
+	{"GLF4", "Gulfstream", "L2J", "M", KindBusiness},
+	{"GLF5", "Gulfstream", "L2J", "M", KindBusiness},
+	{"GLF6", "Gulfstream", "L2J", "M", KindBusiness},
+	{"G280", "Gulfstream", "L2J", "M", KindBusiness},
+	{"F2TH", "Dassault Falcon", "L2J", "M", KindBusiness},
+	{"F900", "Dassault Falcon", "L3J", "M", KindBusiness},
+	{"FA7X", "Dassault Falcon", "L3J", "M", KindBusiness},
+	{"FA8X", "Dassault Falcon", "L3J", "M", KindBusiness},
+	{"H25B", "Hawker 800", "L2J", "M", KindBusiness},
+	{"PC24", "Pilatus PC-24", "L2J", "M", KindBusiness},
+	{"PC12", "Pilatus PC-12", "L1T", "L", KindBusiness},
+	{"BE20", "Beechcraft King Air", "L2T", "L", KindBusiness},
+	{"B350", "Beechcraft King Air", "L2T", "L", KindBusiness},
+	{"BE9L", "Beechcraft King Air", "L2T", "L", KindBusiness},
+	{"TBM9", "Daher TBM", "L1T", "L", KindBusiness},
+
+	// Light
+	{"C152", "Cessna 150/152", "L1P", "L", KindLight},
+	{"C172", "Cessna 172", "L1P", "L", KindLight},
+	{"C182", "Cessna 182", "L1P", "L", KindLight},
+	{"C206", "Cessna 206", "L1P", "L", KindLight},
+	{"P28A", "Piper Cherokee", "L1P", "L", KindLight},
+	{"P28R", "Piper Cherokee", "L1P", "L", KindLight},
+	{"PA34", "Piper Seneca", "L2P", "L", KindLight},
+	{"SR20", "Cirrus SR20/SR22", "L1P", "L", KindLight},
+	{"SR22", "Cirrus SR20/SR22", "L1P", "L", KindLight},
+	{"SF50", "Cirrus Vision Jet", "L1J", "L", KindLight},
+	{"DA40", "Diamond DA40", "L1P", "L", KindLight},
+	{"DA42", "Diamond DA42", "L2P", "L", KindLight},
+	{"DA62", "Diamond DA62", "L2P", "L", KindLight},
+	{"BE36", "Beechcraft Bonanza", "L1P", "L", KindLight},
+	{"BE58", "Beechcraft Baron", "L2P", "L", KindLight},
+	{"DR40", "Robin DR400", "L1P", "L", KindLight},
+	{"TB20", "Socata Trinidad", "L1P", "L", KindLight},
+
+	// Helicopters
+	{"R22", "Robinson R22", "H1P", "L", KindHelicopter},
+	{"R44", "Robinson R44", "H1P", "L", KindHelicopter},
+	{"R66", "Robinson R66", "H1T", "L", KindHelicopter},
+	{"B06", "Bell 206", "H1T", "L", KindHelicopter},
+	{"B429", "Bell 429", "H2T", "L", KindHelicopter},
+	{"AS50", "Airbus Helicopters H125", "H1T", "L", KindHelicopter},
+	{"EC30", "Airbus Helicopters H130", "H1T", "L", KindHelicopter},
+	{"EC35", "Airbus Helicopters H135", "H2T", "L", KindHelicopter},
+	{"EC45", "Airbus Helicopters H145", "H2T", "L", KindHelicopter},
+	{"EC55", "Airbus Helicopters H155", "H2T", "L", KindHelicopter},
+	{"EC75", "Airbus Helicopters H175", "H2T", "M", KindHelicopter},
+	{"H160", "Airbus Helicopters H160", "H2T", "L", KindHelicopter},
+	{"AS65", "Airbus Helicopters Dauphin", "H2T", "L", KindHelicopter},
+	{"A109", "Leonardo AW109", "H2T", "L", KindHelicopter},
+	{"A139", "Leonardo AW139", "H2T", "M", KindHelicopter},
+	{"A169", "Leonardo AW169", "H2T", "L", KindHelicopter},
+	{"A189", "Leonardo AW189", "H2T", "M", KindHelicopter},
+	{"S76", "Sikorsky S-76", "H2T", "L", KindHelicopter},
+	{"S92", "Sikorsky S-92", "H2T", "M", KindHelicopter},
+
+	// Military
+	{"EH10", "Leonardo Merlin", "H3T", "M", KindMilitary},
+	{"H47", "Boeing Chinook", "H2T", "M", KindMilitary},
+	{"H60", "Sikorsky Black Hawk", "H2T", "M", KindMilitary},
+	{"LYNX", "Westland Lynx/Wildcat", "H2T", "L", KindMilitary},
+	{"C130", "Lockheed Hercules", "L4T", "M", KindMilitary},
+	{"C30J", "Lockheed Hercules", "L4T", "M", KindMilitary},
+	{"A400", "Airbus A400M", "L4T", "H", KindMilitary},
+	{"C17", "Boeing C-17 Globemaster", "L4J", "H", KindMilitary},
+	{"K35R", "Boeing KC-135", "L4J", "H", KindMilitary},
+	{"E3TF", "Boeing E-3 Sentry", "L4J", "H", KindMilitary},
+	{"P8", "Boeing P-8 Poseidon", "L2J", "M", KindMilitary},
+	{"RC35", "Boeing RC-135", "L4J", "H", KindMilitary},
+	{"B52", "Boeing B-52", "L8J", "H", KindMilitary},
+	{"EUFI", "Eurofighter Typhoon", "L2J", "M", KindMilitary},
+	{"F35", "Lockheed F-35", "L1J", "M", KindMilitary},
+	{"HAWK", "BAE Hawk", "L1J", "M", KindMilitary},
+	{"PC21", "Pilatus PC-21", "L1T", "L", KindMilitary},
+	{"TEX2", "Beechcraft Texan II", "L1T", "L", KindMilitary},
+	{"V22", "Bell Boeing Osprey", "T2T", "M", KindMilitary},
+}
@@ -0,0 +1,252 @@
+// Package adsbdb is a client for the adsbdb.com aircraft and flight route API.
+//
+// adsbdb does not expose a "live aircraft by bounding box" endpoint; it focuses on
+// aircraft metadata, flight routes and identifier conversions, so callers source
+// Mode S codes and callsigns elsewhere (e.g. OpenSky) and enrich them here.
+//
+// Every endpoint wraps its payload in {"response": ...}. Lookups that fail return a
+// *StatusError that unwraps to one of the sentinel errors below where one applies:
+//
+//	a, err := db.Aircraft(ctx, "4CA123")
+//	if errors.Is(err, adsbdb.ErrUnknownAircraft) { ... }
+package adsbdb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// DefaultBaseURL is the public adsbdb API.
+const DefaultBaseURL = "https://api.adsbdb.com"
+
+// Sentinel errors for errors.Is. adsbdb reports the first three as 404s with a
+// "unknown ..." message, and rate limiting as 429.
+var (
+	ErrUnknownAircraft = errors.New("unknown aircraft")
+	ErrUnknownCallsign = errors.New("unknown callsign")
+	ErrUnknownAirline  = errors.New("unknown airline")
+	ErrRateLimited     = errors.New("rate limited")
+)
+
+// Client queries an adsbdb-compatible server.
+type Client struct {
+	BaseURL string
+	HTTP    *http.Client
+}
+
+// NewClient returns a client for baseURL (DefaultBaseURL if empty) using hc.
+func NewClient(baseURL string, hc *http.Client) *Client {
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+	return &Client{BaseURL: strings.TrimRight(baseURL, "/"), HTTP: hc}
+}
+
+// StatusError is returned for any non-200 response. Err is the matching sentinel
+// error, if any.
+type StatusError struct {
+	StatusCode int
+	Msg        string
+	Err        error
+}
+
+func (e *StatusError) Error() string {
+	return e.Msg
+}
+
+func (e *StatusError) Unwrap() error {
+	return e.Err
+}
+
+// HTTPStatus exposes the status code to logging.StatusCode.
+func (e *StatusError) HTTPStatus() int {
+	return e.StatusCode
+}
+
+// Aircraft is an aircraft record from /v0/aircraft.
+type Aircraft struct {
+	Type                          string  `json:"type"`
+	ICAOType                      string  `json:"icao_type"`
+	Manufacturer                  string  `json:"manufacturer"`
+	ModeS                         string  `json:"mode_s"`
+	Registration                  string  `json:"registration"`
+	RegisteredOwnerCountryISOName string  `json:"registered_owner_country_iso_name"`
+	RegisteredOwnerCountryName    string  `json:"registered_owner_country_name"`
+	RegisteredOwnerOperatorFlag   *string `json:"registered_owner_operator_flag_code"`
+	RegisteredOwner               string  `json:"registered_owner"`
+	URLPhoto                      *string `json:"url_photo"`
+	URLPhotoThumbnail             *string `json:"url_photo_thumbnail"`
+}
+
+// Airport is one end (or the midpoint) of a FlightRoute.
+type Airport struct {
+	CountryISOName string  `json:"country_iso_name"`
+	CountryName    string  `json:"country_name"`
+	Elevation      float64 `json:"elevation"` // feet
+	IATACode       string  `json:"iata_code"`
+	ICAOCode       string  `json:"icao_code"`
+	Latitude       float64 `json:"latitude"`
+	Longitude      float64 `json:"longitude"`
+	Municipality   string  `json:"municipality"`
+	Name           string  `json:"name"`
+}
+
+// Airline is an operator from /v0/airline or the airline block of a FlightRoute.
+// IATA and Callsign are empty when adsbdb reports null.
+type Airline struct {
+	Name       string `json:"name"`
+	ICAO       string `json:"icao"`
+	IATA       string `json:"iata"`
+	Country    string `json:"country"`
+	CountryISO string `json:"country_iso"`
+	Callsign   string `json:"callsign"` // radiotelephony callsign, e.g. "SPEEDBIRD"
+}
+
+// FlightRoute is the scheduled route flown under a callsign.
+type FlightRoute struct {
+	Callsign     string   `json:"callsign"`
+	CallsignICAO *string  `json:"callsign_icao"`
+	CallsignIATA *string  `json:"callsign_iata"`
+	Airline      *Airline `json:"airline"`
+	Origin       Airport  `json:"origin"`
+	Midpoint     *Airport `json:"midpoint"` // only for multi-leg routes
+	Destination  Airport  `json:"destination"`
+}
+
+// UnknownResponse is returned for 404 cases
+type UnknownResponse struct {
+	Response string `json:"response"`
+}
+
+// Aircraft queries adsbdb for a single Mode S or registration string.
+func (c *Client) Aircraft(ctx context.Context, id string) (*Aircraft, error) {
+	// Using major version v0 from current release examples.
+	var payload struct {
+		Aircraft Aircraft `json:"aircraft"`
+	}
+	if err := c.get(ctx, "/v0/aircraft/"+url.PathEscape(id), ErrUnknownAircraft, &payload); err != nil {
+		return nil, err
+	}
+	// Basic validation
+	if payload.Aircraft.ModeS == "" && payload.Aircraft.Registration == "" {
+		return nil, errors.New("empty aircraft payload")
+	}
+	return &payload.Aircraft, nil
+}
+
+// FlightRoute queries adsbdb for flight route info using aircraft Mode S + callsign.
+// It fails with ErrUnknownAircraft or ErrUnknownCallsign depending on which half
+// adsbdb does not know.
+func (c *Client) FlightRoute(ctx context.Context, modeS, callsign string) (*FlightRoute, error) {
+	if callsign == "" {
+		return nil, fmt.Errorf("no callsign available for route lookup")
+	}
+
+	// The aircraft endpoint with a callsign query parameter returns both aircraft and flightroute data.
+	var payload struct {
+		FlightRoute FlightRoute `json:"flightroute"`
+	}
+	path := "/v0/aircraft/" + url.PathEscape(modeS) + "?callsign=" + url.QueryEscape(callsign)
+	if err := c.get(ctx, path, ErrUnknownCallsign, &payload); err != nil {
+		return nil, err
+	}
+	return &payload.FlightRoute, nil
+}
+
+// Callsign queries the route flown under callsign without needing the aircraft.
+func (c *Client) Callsign(ctx context.Context, callsign string) (*FlightRoute, error) {
+	var payload struct {
+		FlightRoute FlightRoute `json:"flightroute"`
+	}
+	if err := c.get(ctx, "/v0/callsign/"+url.PathEscape(callsign), ErrUnknownCallsign, &payload); err != nil {
+		return nil, err
+	}
+	return &payload.FlightRoute, nil
+}
+
+// Airline looks up operators by ICAO (3-letter) or IATA (2-letter) code. adsbdb
+// returns a list because IATA codes are not unique.
+func (c *Client) Airline(ctx context.Context, code string) ([]Airline, error) {
+	var airlines []Airline
+	if err := c.get(ctx, "/v0/airline/"+url.PathEscape(code), ErrUnknownAirline, &airlines); err != nil {
+		return nil, err
+	}
+	if len(airlines) == 0 {
+		return nil, &StatusError{StatusCode: http.StatusNotFound, Msg: "unknown airline: " + code, Err: ErrUnknownAirline}
+	}
+	return airlines, nil
+}
+
+// ModeS converts a US N-number registration (e.g. "N123AB") to its Mode S hex code.
+func (c *Client) ModeS(ctx context.Context, nNumber string) (string, error) {
+	var modeS string
+	if err := c.get(ctx, "/v0/mode-s/"+url.PathEscape(nNumber), ErrUnknownAircraft, &modeS); err != nil {
+		return "", err
+	}
+	return modeS, nil
+}
+
+// NNumber converts a US Mode S hex code to its N-number registration.
+func (c *Client) NNumber(ctx context.Context, modeS string) (string, error) {
+	var nNumber string
+	if err := c.get(ctx, "/v0/n-number/"+url.PathEscape(modeS), ErrUnknownAircraft, &nNumber); err != nil {
+		return "", err
+	}
+	return nNumber, nil
+}
+
+// get fetches path and decodes the "response" field into out. A 404 becomes a
+// StatusError wrapping the sentinel named by adsbdb's message, or notFound when
+// the message is not recognised.
+func (c *Client) get(ctx context.Context, path string, notFound error, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.BaseURL+path, nil)
+	if err != nil {
+		return err
+	}
+	res, err := c.HTTP.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	switch res.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		var unknown UnknownResponse
+		_ = json.NewDecoder(res.Body).Decode(&unknown) // best-effort
+		return &StatusError{StatusCode: res.StatusCode,
+			Msg: fmt.Sprintf("adsbdb %s: %s", path, unknown.Response), Err: unknownError(unknown.Response, notFound)}
+	case http.StatusTooManyRequests:
+		return &StatusError{StatusCode: res.StatusCode,
+			Msg: fmt.Sprintf("adsbdb %s: rate limited", path), Err: ErrRateLimited}
+	default:
+		return &StatusError{StatusCode: res.StatusCode,
+			Msg: fmt.Sprintf("adsbdb %s: unexpected status %d", path, res.StatusCode)}
+	}
+
+	envelope := struct {
+		Response any `json:"response"`
+	}{Response: out}
+	return json.NewDecoder(res.Body).Decode(&envelope)
+}
+
+// unknownError maps adsbdb's 404 message to a sentinel error.
+func unknownError(msg string, fallback error) error {
+	switch {
+	case strings.Contains(msg, "aircraft"):
+		return ErrUnknownAircraft
+	case strings.Contains(msg, "callsign"):
+		return ErrUnknownCallsign
+	case strings.Contains(msg, "airline"):
+		return ErrUnknownAirline
+	}
+	return fallback
+}
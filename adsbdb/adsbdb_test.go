@@ -0,0 +1,144 @@
+package adsbdb_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"AirTraffic-Monitor/adsbdb"
+	"AirTraffic-Monitor/fakeupstream"
+)
+
+func newFake(t *testing.T) (*fakeupstream.Server, *adsbdb.Client) {
+	t.Helper()
+	fake := fakeupstream.New()
+	t.Cleanup(fake.Close)
+	fake.AddAircraft(fakeupstream.Aircraft{ModeS: "4CA123", Registration: "EI-ABC", Type: "737-8AS",
+		ICAOType: "B738", Manufacturer: "Boeing", Owner: "Ryanair", OwnerCountry: "Ireland"})
+	fake.AddAirline(fakeupstream.Airline{Name: "Ryanair", ICAO: "RYR", IATA: "FR", Country: "Ireland",
+		CountryISO: "IE", Callsign: "RYANAIR"})
+	fake.AddRoute(fakeupstream.Route{Callsign: "RYR1AB",
+		Origin:      fakeupstream.Airport{ICAO: "EIDW", IATA: "DUB", Name: "Dublin Airport", Latitude: 53.42, Longitude: -6.27},
+		Destination: fakeupstream.Airport{ICAO: "EGSS", IATA: "STN", Name: "London Stansted Airport", Latitude: 51.88, Longitude: 0.23},
+	})
+	fake.AddNNumber("N123AB", "A061D9")
+	return fake, adsbdb.NewClient(fake.ADSBDB.URL, nil)
+}
+
+func TestAircraft(t *testing.T) {
+	_, db := newFake(t)
+	a, err := db.Aircraft(context.Background(), "4ca123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.ModeS != "4CA123" || a.Registration != "EI-ABC" || a.ICAOType != "B738" || a.RegisteredOwner != "Ryanair" {
+		t.Errorf("Aircraft() = %+v", a)
+	}
+	if a.RegisteredOwnerOperatorFlag != nil || a.URLPhoto != nil {
+		t.Errorf("null fields decoded as %v, %v", a.RegisteredOwnerOperatorFlag, a.URLPhoto)
+	}
+}
+
+func TestFlightRouteAndCallsign(t *testing.T) {
+	_, db := newFake(t)
+	ctx := context.Background()
+
+	r, err := db.FlightRoute(ctx, "4CA123", "RYR1AB")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Origin.ICAOCode != "EIDW" || r.Destination.ICAOCode != "EGSS" || r.Destination.Latitude != 51.88 {
+		t.Errorf("FlightRoute() = %+v", r)
+	}
+	if r.Airline == nil || r.Airline.Name != "Ryanair" || r.Airline.Callsign != "RYANAIR" {
+		t.Errorf("FlightRoute() airline = %+v", r.Airline)
+	}
+
+	r, err = db.Callsign(ctx, "RYR1AB")
+	if err != nil || r.Origin.IATACode != "DUB" {
+		t.Errorf("Callsign() = %+v, %v", r, err)
+	}
+
+	if _, err := db.FlightRoute(ctx, "4CA123", ""); err == nil {
+		t.Error("FlightRoute() without a callsign succeeded")
+	}
+}
+
+func TestAirlineAndConversions(t *testing.T) {
+	_, db := newFake(t)
+	ctx := context.Background()
+
+	for _, code := range []string{"RYR", "FR"} {
+		airlines, err := db.Airline(ctx, code)
+		if err != nil || len(airlines) != 1 || airlines[0].ICAO != "RYR" || airlines[0].IATA != "FR" {
+			t.Errorf("Airline(%q) = %+v, %v", code, airlines, err)
+		}
+	}
+	if modeS, err := db.ModeS(ctx, "N123AB"); err != nil || modeS != "A061D9" {
+		t.Errorf("ModeS() = %q, %v", modeS, err)
+	}
+	if n, err := db.NNumber(ctx, "A061D9"); err != nil || n != "N123AB" {
+		t.Errorf("NNumber() = %q, %v", n, err)
+	}
+}
+
+func TestUnknownSentinels(t *testing.T) {
+	_, db := newFake(t)
+	ctx := context.Background()
+	tests := []struct {
+		name string
+		call func() error
+		want error
+	}{
+		{"aircraft", func() error { _, err := db.Aircraft(ctx, "000000"); return err }, adsbdb.ErrUnknownAircraft},
+		{"route aircraft", func() error { _, err := db.FlightRoute(ctx, "000000", "RYR1AB"); return err }, adsbdb.ErrUnknownAircraft},
+		{"route callsign", func() error { _, err := db.FlightRoute(ctx, "4CA123", "XXX999"); return err }, adsbdb.ErrUnknownCallsign},
+		{"callsign", func() error { _, err := db.Callsign(ctx, "XXX999"); return err }, adsbdb.ErrUnknownCallsign},
+		{"airline", func() error { _, err := db.Airline(ctx, "ZZZ"); return err }, adsbdb.ErrUnknownAirline},
+		{"mode-s", func() error { _, err := db.ModeS(ctx, "N999ZZ"); return err }, adsbdb.ErrUnknownAircraft},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.call()
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("error = %v, want %v", err, tt.want)
+			}
+			var se *adsbdb.StatusError
+			if !errors.As(err, &se) || se.HTTPStatus() != http.StatusNotFound {
+				t.Errorf("error = %#v, want a 404 StatusError", err)
+			}
+		})
+	}
+}
+
+func TestErrorStatus(t *testing.T) {
+	tests := []struct {
+		status   int
+		sentinel error
+	}{
+		{http.StatusTooManyRequests, adsbdb.ErrRateLimited},
+		{http.StatusInternalServerError, nil},
+		{http.StatusBadGateway, nil},
+	}
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.status), func(t *testing.T) {
+			fake, db := newFake(t)
+			fake.FailPath("/v0/", tt.status)
+
+			_, err := db.Aircraft(context.Background(), "4CA123")
+			var se *adsbdb.StatusError
+			if !errors.As(err, &se) || se.StatusCode != tt.status {
+				t.Fatalf("error = %v, want StatusError %d", err, tt.status)
+			}
+			if tt.sentinel != nil && !errors.Is(err, tt.sentinel) {
+				t.Errorf("error = %v, want %v", err, tt.sentinel)
+			}
+			for _, s := range []error{adsbdb.ErrUnknownAircraft, adsbdb.ErrUnknownCallsign, adsbdb.ErrUnknownAirline} {
+				if errors.Is(err, s) {
+					t.Errorf("status %d reported as %v", tt.status, s)
+				}
+			}
+		})
+	}
+}
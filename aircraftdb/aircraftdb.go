@@ -0,0 +1,238 @@
+// Package aircraftdb is a local aircraft registry keyed by ICAO24, imported from
+// OpenSky's aircraftDatabase.csv or a BaseStation-style CSV export, so airframes
+// can be identified without adsbdb.
+//
+// The on-disk file is a sorted index followed by the records:
+//
+//	magic   "ATDB0001"
+//	count   uint32
+//	index   count × {icao24 uint32, offset uint32}, sorted by icao24
+//	records count × fields, each a uvarint length and the bytes
+//
+// Lookups binary-search the index with ReadAt, so nothing is held in memory.
+package aircraftdb
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"AirTraffic-Monitor/adsbdb"
+	"AirTraffic-Monitor/icaoaddr"
+)
+
+const (
+	magic      = "ATDB0001"
+	headerSize = len(magic) + 4
+	entrySize  = 8
+	numFields  = 7
+)
+
+// Precedence values for choosing between the local database and adsbdb.
+const (
+	PreferADSBDB = "adsbdb"     // adsbdb first; local fills gaps and covers failures
+	PreferLocal  = "local"      // local first; adsbdb only for aircraft not in the file
+	LocalOnly    = "local_only" // never ask adsbdb for aircraft
+)
+
+// Record is one registry entry.
+type Record struct {
+	ICAO24       string
+	Registration string
+	Manufacturer string
+	Model        string // e.g. "A320 214"
+	ICAOType     string // e.g. "A320"
+	Owner        string
+	Operator     string
+	OperatorICAO string
+}
+
+// Aircraft converts the record to the adsbdb shape the enrichment step uses.
+// The operator stands in for the owner when the registry has no owner.
+func (r Record) Aircraft() adsbdb.Aircraft {
+	owner := r.Owner
+	if owner == "" {
+		owner = r.Operator
+	}
+	return adsbdb.Aircraft{
+		ModeS:           r.ICAO24,
+		Registration:    r.Registration,
+		Manufacturer:    r.Manufacturer,
+		Type:            r.Model,
+		ICAOType:        r.ICAOType,
+		RegisteredOwner: owner,
+	}
+}
+
+func (r Record) fields() [numFields]string {
+	return [numFields]string{r.Registration, r.Manufacturer, r.Model, r.ICAOType, r.Owner, r.Operator, r.OperatorICAO}
+}
+
+// DB is an open database file. It is safe for concurrent use.
+type DB struct {
+	path string
+
+	mu      sync.RWMutex
+	f       *os.File
+	count   int
+	size    int64
+	modTime time.Time
+}
+
+// Open opens the database at path.
+func Open(path string) (*DB, error) {
+	db := &DB{path: path}
+	if err := db.open(); err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
+func (db *DB) open() error {
+	f, err := os.Open(db.path)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	header := make([]byte, headerSize)
+	if _, err := f.ReadAt(header, 0); err != nil || string(header[:len(magic)]) != magic {
+		f.Close()
+		return fmt.Errorf("%s is not an aircraft database; rebuild it with refresh-aircraft-db", db.path)
+	}
+	count := int(binary.BigEndian.Uint32(header[len(magic):]))
+	if int64(headerSize+count*entrySize) > info.Size() {
+		f.Close()
+		return fmt.Errorf("%s is truncated", db.path)
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if db.f != nil {
+		db.f.Close()
+	}
+	db.f, db.count, db.size, db.modTime = f, count, info.Size(), info.ModTime()
+	return nil
+}
+
+// Len returns the number of records.
+func (db *DB) Len() int {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	return db.count
+}
+
+// Reload reopens the file if it has been replaced since it was opened, e.g. by a
+// refresh in another process. It reports whether it did.
+func (db *DB) Reload() (bool, error) {
+	info, err := os.Stat(db.path)
+	if err != nil {
+		return false, err
+	}
+	db.mu.RLock()
+	unchanged := info.ModTime().Equal(db.modTime) && info.Size() == db.size
+	db.mu.RUnlock()
+	if unchanged {
+		return false, nil
+	}
+	return true, db.open()
+}
+
+// Close closes the file.
+func (db *DB) Close() error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if db.f == nil {
+		return nil
+	}
+	err := db.f.Close()
+	db.f = nil
+	return err
+}
+
+// Lookup returns the record for icao24 (any case).
+func (db *DB) Lookup(icao24 string) (Record, bool, error) {
+	key, ok := icaoaddr.Parse(icao24)
+	if !ok {
+		return Record{}, false, nil
+	}
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	if db.f == nil {
+		return Record{}, false, errors.New("aircraft database is closed")
+	}
+
+	var readErr error
+	entry := func(i int) (uint32, int64) {
+		buf := make([]byte, entrySize)
+		if _, err := db.f.ReadAt(buf, int64(headerSize+i*entrySize)); err != nil {
+			readErr = err
+			return 0, 0
+		}
+		return binary.BigEndian.Uint32(buf), int64(binary.BigEndian.Uint32(buf[4:]))
+	}
+	i := sort.Search(db.count, func(i int) bool {
+		k, _ := entry(i)
+		return readErr != nil || k >= key
+	})
+	if readErr != nil {
+		return Record{}, false, readErr
+	}
+	if i == db.count {
+		return Record{}, false, nil
+	}
+	k, offset := entry(i)
+	if readErr != nil || k != key {
+		return Record{}, false, readErr
+	}
+
+	dataStart := int64(headerSize + db.count*entrySize)
+	end := db.size
+	if i+1 < db.count {
+		_, next := entry(i + 1)
+		end = dataStart + next
+	}
+	if readErr != nil {
+		return Record{}, false, readErr
+	}
+	buf := make([]byte, end-(dataStart+offset))
+	if _, err := db.f.ReadAt(buf, dataStart+offset); err != nil && err != io.EOF {
+		return Record{}, false, err
+	}
+	rec, err := decodeRecord(buf)
+	if err != nil {
+		return Record{}, false, fmt.Errorf("%s: record %06X: %w", db.path, key, err)
+	}
+	rec.ICAO24 = fmt.Sprintf("%06X", key)
+	return rec, true, nil
+}
+
+func decodeRecord(buf []byte) (Record, error) {
+	var f [numFields]string
+	for i := range f {
+		n, w := binary.Uvarint(buf)
+		if w <= 0 || uint64(len(buf)-w) < n {
+			return Record{}, errors.New("corrupt record")
+		}
+		f[i] = string(buf[w : w+int(n)])
+		buf = buf[w+int(n):]
+	}
+	return Record{Registration: f[0], Manufacturer: f[1], Model: f[2], ICAOType: f[3],
+		Owner: f[4], Operator: f[5], OperatorICAO: f[6]}, nil
+}
+
+func appendRecord(buf []byte, r Record) []byte {
+	for _, f := range r.fields() {
+		buf = binary.AppendUvarint(buf, uint64(len(f)))
+		buf = append(buf, f...)
+	}
+	return buf
+}
@@ -0,0 +1,214 @@
+package aircraftdb
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"AirTraffic-Monitor/icaoaddr"
+)
+
+// columns maps each Record field to the header names it may appear under,
+// checked in order and case-insensitively. OpenSky's file uses the first name;
+// BaseStation exports use the second. OpenSky also has a "modes" column, but it
+// is a capability flag, so icao24 must come first.
+var columns = map[string][]string{
+	"icao24":       {"icao24", "modes"},
+	"registration": {"registration"},
+	"manufacturer": {"manufacturername", "manufacturer"},
+	"model":        {"model", "type"},
+	"icaotype":     {"typecode", "icaotypecode"},
+	"owner":        {"owner", "registeredowners"},
+	"operator":     {"operator"},
+	"operatoricao": {"operatoricao", "operatorflagcode"},
+}
+
+// OpenSource opens a CSV source, which may be a local path or an http(s) URL
+// such as https://opensky-network.org/datasets/metadata/aircraftDatabase.csv.
+func OpenSource(ctx context.Context, source string, hc *http.Client) (io.ReadCloser, error) {
+	if !strings.HasPrefix(source, "http://") && !strings.HasPrefix(source, "https://") {
+		return os.Open(source)
+	}
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, source, nil)
+	if err != nil {
+		return nil, err
+	}
+	res, err := hc.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode != http.StatusOK {
+		res.Body.Close()
+		return nil, fmt.Errorf("download %s: unexpected status %d", source, res.StatusCode)
+	}
+	return res.Body, nil
+}
+
+// Import reads a registry CSV from src and atomically replaces the database at
+// path. Rows without a valid ICAO24 are skipped; for duplicates the last row wins.
+// It returns the number of records written.
+func Import(path string, src io.Reader) (int, error) {
+	records, err := readCSV(src)
+	if err != nil {
+		return 0, err
+	}
+	keys := make([]uint32, 0, len(records))
+	for k := range records {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+
+	index := make([]byte, 0, headerSize+len(keys)*entrySize)
+	index = append(index, magic...)
+	index = binary.BigEndian.AppendUint32(index, uint32(len(keys)))
+	var data []byte
+	for _, k := range keys {
+		index = binary.BigEndian.AppendUint32(index, k)
+		index = binary.BigEndian.AppendUint32(index, uint32(len(data)))
+		data = appendRecord(data, records[k])
+	}
+	if uint64(len(data)) > 1<<32-1 {
+		return 0, fmt.Errorf("aircraft database too large (%d bytes)", len(data))
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return 0, err
+	}
+	w := bufio.NewWriter(tmp)
+	w.Write(index)
+	w.Write(data)
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return 0, err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return 0, err
+	}
+	return len(keys), os.Rename(tmp.Name(), path)
+}
+
+// readCSV parses either dialect. Newer OpenSky dumps quote every field with
+// single quotes ('icao24','registration',…), which encoding/csv cannot read,
+// so those lines are split by hand.
+func readCSV(src io.Reader) (map[uint32]Record, error) {
+	br := bufio.NewReaderSize(src, 1<<16)
+	if bom, _ := br.Peek(3); string(bom) == "\ufeff" {
+		br.Discard(3)
+	}
+	first, err := br.Peek(1)
+	if err != nil {
+		return nil, fmt.Errorf("empty aircraft CSV: %w", err)
+	}
+
+	var next func() ([]string, error)
+	if first[0] == '\'' {
+		sc := bufio.NewScanner(br)
+		sc.Buffer(make([]byte, 1<<16), 1<<20)
+		next = func() ([]string, error) {
+			if !sc.Scan() {
+				if err := sc.Err(); err != nil {
+					return nil, err
+				}
+				return nil, io.EOF
+			}
+			return splitQuoted(sc.Text(), '\''), nil
+		}
+	} else {
+		r := csv.NewReader(br)
+		r.FieldsPerRecord = -1
+		r.LazyQuotes = true
+		r.ReuseRecord = true
+		next = r.Read
+	}
+
+	header, err := next()
+	if err != nil {
+		return nil, fmt.Errorf("read aircraft CSV header: %w", err)
+	}
+	col := make(map[string]int)
+	for name, aliases := range columns {
+		col[name] = -1
+	alias:
+		for _, alias := range aliases {
+			for i, h := range header {
+				if strings.EqualFold(strings.TrimSpace(h), alias) {
+					col[name] = i
+					break alias
+				}
+			}
+		}
+	}
+	if col["icao24"] < 0 {
+		return nil, fmt.Errorf("aircraft CSV has no icao24 or ModeS column")
+	}
+
+	records := make(map[uint32]Record)
+	for {
+		row, err := next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		get := func(name string) string {
+			i := col[name]
+			if i < 0 || i >= len(row) {
+				return ""
+			}
+			return strings.TrimSpace(row[i])
+		}
+		key, ok := icaoaddr.Parse(get("icao24"))
+		if !ok {
+			continue
+		}
+		records[key] = Record{
+			Registration: strings.ToUpper(get("registration")),
+			Manufacturer: get("manufacturer"),
+			Model:        get("model"),
+			ICAOType:     strings.ToUpper(get("icaotype")),
+			Owner:        get("owner"),
+			Operator:     get("operator"),
+			OperatorICAO: strings.ToUpper(get("operatoricao")),
+		}
+	}
+	return records, nil
+}
+
+// splitQuoted splits a comma-separated line whose fields may be wrapped in
+// quote, with a doubled quote standing for a literal one.
+func splitQuoted(line string, quote byte) []string {
+	var fields []string
+	var b strings.Builder
+	inQuotes := false
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case c == quote && inQuotes && i+1 < len(line) && line[i+1] == quote:
+			b.WriteByte(quote)
+			i++
+		case c == quote:
+			inQuotes = !inQuotes
+		case c == ',' && !inQuotes:
+			fields = append(fields, b.String())
+			b.Reset()
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return append(fields, b.String())
+}
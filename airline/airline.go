@@ -0,0 +1,114 @@
+// Package airline resolves the operating airline from an ICAO callsign prefix
+// (BAW, EZY, RYR…). The registered owner adsbdb reports is often a lessor, so
+// the callsign is the better guide to who is actually flying the aircraft.
+package airline
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+
+	"AirTraffic-Monitor/adsbdb"
+)
+
+var offline map[string]adsbdb.Airline
+
+func init() {
+	offline = make(map[string]adsbdb.Airline, len(offlineTable))
+	for _, a := range offlineTable {
+		offline[a.ICAO] = a
+	}
+}
+
+// Prefix returns the three-letter operator designator of an ICAO callsign.
+// It reports false for callsigns that are registrations (GABCD, N123AB) rather
+// than operator + flight number.
+func Prefix(callsign string) (string, bool) {
+	callsign = strings.ToUpper(strings.TrimSpace(callsign))
+	if len(callsign) < 4 {
+		return "", false
+	}
+	for _, c := range callsign[:3] {
+		if c < 'A' || c > 'Z' {
+			return "", false
+		}
+	}
+	if c := callsign[3]; c < '0' || c > '9' {
+		return "", false
+	}
+	return callsign[:3], true
+}
+
+// Offline returns the bundled table entry for an ICAO airline designator.
+func Offline(icao string) (adsbdb.Airline, bool) {
+	a, ok := offline[strings.ToUpper(icao)]
+	return a, ok
+}
+
+// Resolver looks airlines up in adsbdb, falling back to the offline table.
+// Answers are cached for the life of the process since airline data rarely changes.
+type Resolver struct {
+	db *adsbdb.Client // nil means offline table only
+
+	mu    sync.Mutex
+	cache map[string]*adsbdb.Airline // nil value: known not to exist
+}
+
+// NewResolver returns a resolver using db, which may be nil.
+func NewResolver(db *adsbdb.Client) *Resolver {
+	return &Resolver{db: db, cache: make(map[string]*adsbdb.Airline)}
+}
+
+// Resolve returns the airline operating callsign. err reports an adsbdb failure
+// other than "unknown airline"; the result then comes from the offline table and
+// is not cached, so adsbdb is asked again next time.
+func (r *Resolver) Resolve(ctx context.Context, callsign string) (a adsbdb.Airline, ok bool, err error) {
+	prefix, ok := Prefix(callsign)
+	if !ok {
+		return adsbdb.Airline{}, false, nil
+	}
+
+	r.mu.Lock()
+	cached, hit := r.cache[prefix]
+	r.mu.Unlock()
+	if hit {
+		if cached == nil {
+			return adsbdb.Airline{}, false, nil
+		}
+		return *cached, true, nil
+	}
+
+	if r.db != nil {
+		var airlines []adsbdb.Airline
+		airlines, err = r.db.Airline(ctx, prefix)
+		if err == nil {
+			// IATA lookups can return several operators; ours is the one with the exact ICAO code.
+			for i := range airlines {
+				if strings.EqualFold(airlines[i].ICAO, prefix) {
+					r.store(prefix, &airlines[i])
+					return airlines[i], true, nil
+				}
+			}
+		}
+		if errors.Is(err, adsbdb.ErrUnknownAirline) {
+			err = nil
+		}
+	}
+
+	a, ok = Offline(prefix)
+	if err == nil {
+		if ok {
+			r.store(prefix, &a)
+		} else {
+			r.store(prefix, nil)
+		}
+	}
+	return a, ok, err
+}
+
+func (r *Resolver) store(prefix string, a *adsbdb.Airline) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cache[prefix] = a
+}
@@ -0,0 +1,97 @@
+package airline
+
+import "AirTraffic-Monitor/adsbdb"
+
+// offlineTable covers the operators most often seen around London. It is the
+// fallback when adsbdb is unreachable or does not know a prefix, not a
+// complete register. Callsign is the radiotelephony callsign.
+var offlineTable = []adsbdb.Airline{
+	// United Kingdom and Ireland
+	{ICAO: "BAW", IATA: "BA", Name: "British Airways", Callsign: "SPEEDBIRD", Country: "United Kingdom", CountryISO: "GB"},
+	{ICAO: "SHT", IATA: "BA", Name: "British Airways", Callsign: "SHUTTLE", Country: "United Kingdom", CountryISO: "GB"},
+	{ICAO: "CFE", IATA: "BA", Name: "BA CityFlyer", Callsign: "FLYER", Country: "United Kingdom", CountryISO: "GB"},
+	{ICAO: "EFW", IATA: "BA", Name: "BA Euroflyer", Callsign: "GRIFFIN", Country: "United Kingdom", CountryISO: "GB"},
+	{ICAO: "EZY", IATA: "U2", Name: "easyJet", Callsign: "EASY", Country: "United Kingdom", CountryISO: "GB"},
+	{ICAO: "EJU", IATA: "EC", Name: "easyJet Europe", Callsign: "ALPINE", Country: "Austria", CountryISO: "AT"},
+	{ICAO: "EZS", IATA: "DS", Name: "easyJet Switzerland", Callsign: "TOPSWISS", Country: "Switzerland", CountryISO: "CH"},
+	{ICAO: "VIR", IATA: "VS", Name: "Virgin Atlantic", Callsign: "VIRGIN", Country: "United Kingdom", CountryISO: "GB"},
+	{ICAO: "TOM", IATA: "BY", Name: "TUI Airways", Callsign: "TOMSON", Country: "United Kingdom", CountryISO: "GB"},
+	{ICAO: "EXS", IATA: "LS", Name: "Jet2", Callsign: "CHANNEX", Country: "United Kingdom", CountryISO: "GB"},
+	{ICAO: "LOG", IATA: "LM", Name: "Loganair", Callsign: "LOGAN", Country: "United Kingdom", CountryISO: "GB"},
+	{ICAO: "AUR", IATA: "GR", Name: "Aurigny", Callsign: "AYLINE", Country: "Guernsey", CountryISO: "GG"},
+	{ICAO: "WUK", IATA: "W9", Name: "Wizz Air UK", Callsign: "WIZZ GO", Country: "United Kingdom", CountryISO: "GB"},
+	{ICAO: "RUK", IATA: "RK", Name: "Ryanair UK", Callsign: "BLUEJET", Country: "United Kingdom", CountryISO: "GB"},
+	{ICAO: "DHK", IATA: "D0", Name: "DHL Air UK", Callsign: "WORLD EXPRESS", Country: "United Kingdom", CountryISO: "GB"},
+	{ICAO: "RRR", Name: "Royal Air Force", Callsign: "ASCOT", Country: "United Kingdom", CountryISO: "GB"},
+	{ICAO: "RYR", IATA: "FR", Name: "Ryanair", Callsign: "RYANAIR", Country: "Ireland", CountryISO: "IE"},
+	{ICAO: "EIN", IATA: "EI", Name: "Aer Lingus", Callsign: "SHAMROCK", Country: "Ireland", CountryISO: "IE"},
+	{ICAO: "EUK", IATA: "EI", Name: "Aer Lingus UK", Callsign: "GREEN FLAG", Country: "United Kingdom", CountryISO: "GB"},
+
+	// Europe
+	{ICAO: "WZZ", IATA: "W6", Name: "Wizz Air", Callsign: "WIZZ AIR", Country: "Hungary", CountryISO: "HU"},
+	{ICAO: "DLH", IATA: "LH", Name: "Lufthansa", Callsign: "LUFTHANSA", Country: "Germany", CountryISO: "DE"},
+	{ICAO: "EWG", IATA: "EW", Name: "Eurowings", Callsign: "EUROWINGS", Country: "Germany", CountryISO: "DE"},
+	{ICAO: "BCS", IATA: "QY", Name: "European Air Transport", Callsign: "EUROTRANS", Country: "Germany", CountryISO: "DE"},
+	{ICAO: "AFR", IATA: "AF", Name: "Air France", Callsign: "AIRFRANS", Country: "France", CountryISO: "FR"},
+	{ICAO: "TVF", IATA: "TO", Name: "Transavia France", Callsign: "FRANCE SOLEIL", Country: "France", CountryISO: "FR"},
+	{ICAO: "KLM", IATA: "KL", Name: "KLM", Callsign: "KLM", Country: "Netherlands", CountryISO: "NL"},
+	{ICAO: "TRA", IATA: "HV", Name: "Transavia", Callsign: "TRANSAVIA", Country: "Netherlands", CountryISO: "NL"},
+	{ICAO: "IBE", IATA: "IB", Name: "Iberia", Callsign: "IBERIA", Country: "Spain", CountryISO: "ES"},
+	{ICAO: "VLG", IATA: "VY", Name: "Vueling", Callsign: "VUELING", Country: "Spain", CountryISO: "ES"},
+	{ICAO: "SWR", IATA: "LX", Name: "Swiss", Callsign: "SWISS", Country: "Switzerland", CountryISO: "CH"},
+	{ICAO: "AUA", IATA: "OS", Name: "Austrian Airlines", Callsign: "AUSTRIAN", Country: "Austria", CountryISO: "AT"},
+	{ICAO: "BEL", IATA: "SN", Name: "Brussels Airlines", Callsign: "BEE-LINE", Country: "Belgium", CountryISO: "BE"},
+	{ICAO: "TAY", IATA: "3V", Name: "ASL Airlines Belgium", Callsign: "QUALITY", Country: "Belgium", CountryISO: "BE"},
+	{ICAO: "CLX", IATA: "CV", Name: "Cargolux", Callsign: "CARGOLUX", Country: "Luxembourg", CountryISO: "LU"},
+	{ICAO: "SAS", IATA: "SK", Name: "SAS", Callsign: "SCANDINAVIAN", Country: "Sweden", CountryISO: "SE"},
+	{ICAO: "NAX", IATA: "DY", Name: "Norwegian", Callsign: "NORSHUTTLE", Country: "Norway", CountryISO: "NO"},
+	{ICAO: "NSZ", IATA: "D8", Name: "Norwegian Air Sweden", Callsign: "REDNOSE", Country: "Sweden", CountryISO: "SE"},
+	{ICAO: "FIN", IATA: "AY", Name: "Finnair", Callsign: "FINNAIR", Country: "Finland", CountryISO: "FI"},
+	{ICAO: "ICE", IATA: "FI", Name: "Icelandair", Callsign: "ICEAIR", Country: "Iceland", CountryISO: "IS"},
+	{ICAO: "TAP", IATA: "TP", Name: "TAP Air Portugal", Callsign: "AIR PORTUGAL", Country: "Portugal", CountryISO: "PT"},
+	{ICAO: "ITY", IATA: "AZ", Name: "ITA Airways", Callsign: "ITARROW", Country: "Italy", CountryISO: "IT"},
+	{ICAO: "LOT", IATA: "LO", Name: "LOT Polish Airlines", Callsign: "POLLOT", Country: "Poland", CountryISO: "PL"},
+	{ICAO: "BTI", IATA: "BT", Name: "airBaltic", Callsign: "AIRBALTIC", Country: "Latvia", CountryISO: "LV"},
+	{ICAO: "AEE", IATA: "A3", Name: "Aegean Airlines", Callsign: "AEGEAN", Country: "Greece", CountryISO: "GR"},
+	{ICAO: "CTN", IATA: "OU", Name: "Croatia Airlines", Callsign: "CROATIA", Country: "Croatia", CountryISO: "HR"},
+	{ICAO: "ROT", IATA: "RO", Name: "TAROM", Callsign: "TAROM", Country: "Romania", CountryISO: "RO"},
+	{ICAO: "THY", IATA: "TK", Name: "Turkish Airlines", Callsign: "TURKISH", Country: "Turkey", CountryISO: "TR"},
+	{ICAO: "PGT", IATA: "PC", Name: "Pegasus Airlines", Callsign: "SUNTURK", Country: "Turkey", CountryISO: "TR"},
+	{ICAO: "SXS", IATA: "XQ", Name: "SunExpress", Callsign: "SUNEXPRESS", Country: "Turkey", CountryISO: "TR"},
+	{ICAO: "NJE", Name: "NetJets Europe", Callsign: "FRACTION", Country: "Portugal", CountryISO: "PT"},
+	{ICAO: "VJT", Name: "VistaJet", Callsign: "VISTA MALTA", Country: "Malta", CountryISO: "MT"},
+
+	// Long haul
+	{ICAO: "AAL", IATA: "AA", Name: "American Airlines", Callsign: "AMERICAN", Country: "United States", CountryISO: "US"},
+	{ICAO: "UAL", IATA: "UA", Name: "United Airlines", Callsign: "UNITED", Country: "United States", CountryISO: "US"},
+	{ICAO: "DAL", IATA: "DL", Name: "Delta Air Lines", Callsign: "DELTA", Country: "United States", CountryISO: "US"},
+	{ICAO: "FDX", IATA: "FX", Name: "FedEx", Callsign: "FEDEX", Country: "United States", CountryISO: "US"},
+	{ICAO: "UPS", IATA: "5X", Name: "UPS Airlines", Callsign: "UPS", Country: "United States", CountryISO: "US"},
+	{ICAO: "EJA", Name: "NetJets", Callsign: "EXECJET", Country: "United States", CountryISO: "US"},
+	{ICAO: "ACA", IATA: "AC", Name: "Air Canada", Callsign: "AIR CANADA", Country: "Canada", CountryISO: "CA"},
+	{ICAO: "UAE", IATA: "EK", Name: "Emirates", Callsign: "EMIRATES", Country: "United Arab Emirates", CountryISO: "AE"},
+	{ICAO: "ETD", IATA: "EY", Name: "Etihad Airways", Callsign: "ETIHAD", Country: "United Arab Emirates", CountryISO: "AE"},
+	{ICAO: "QTR", IATA: "QR", Name: "Qatar Airways", Callsign: "QATARI", Country: "Qatar", CountryISO: "QA"},
+	{ICAO: "GFA", IATA: "GF", Name: "Gulf Air", Callsign: "GULF AIR", Country: "Bahrain", CountryISO: "BH"},
+	{ICAO: "KAC", IATA: "KU", Name: "Kuwait Airways", Callsign: "KUWAITI", Country: "Kuwait", CountryISO: "KW"},
+	{ICAO: "OMA", IATA: "WY", Name: "Oman Air", Callsign: "OMAN AIR", Country: "Oman", CountryISO: "OM"},
+	{ICAO: "SVA", IATA: "SV", Name: "Saudia", Callsign: "SAUDIA", Country: "Saudi Arabia", CountryISO: "SA"},
+	{ICAO: "ELY", IATA: "LY", Name: "El Al", Callsign: "ELAL", Country: "Israel", CountryISO: "IL"},
+	{ICAO: "MSR", IATA: "MS", Name: "EgyptAir", Callsign: "EGYPTAIR", Country: "Egypt", CountryISO: "EG"},
+	{ICAO: "RAM", IATA: "AT", Name: "Royal Air Maroc", Callsign: "ROYALAIR MAROC", Country: "Morocco", CountryISO: "MA"},
+	{ICAO: "ETH", IATA: "ET", Name: "Ethiopian Airlines", Callsign: "ETHIOPIAN", Country: "Ethiopia", CountryISO: "ET"},
+	{ICAO: "KQA", IATA: "KQ", Name: "Kenya Airways", Callsign: "KENYA", Country: "Kenya", CountryISO: "KE"},
+	{ICAO: "SAA", IATA: "SA", Name: "South African Airways", Callsign: "SPRINGBOK", Country: "South Africa", CountryISO: "ZA"},
+	{ICAO: "AIC", IATA: "AI", Name: "Air India", Callsign: "AIRINDIA", Country: "India", CountryISO: "IN"},
+	{ICAO: "PIA", IATA: "PK", Name: "Pakistan International Airlines", Callsign: "PAKISTAN", Country: "Pakistan", CountryISO: "PK"},
+	{ICAO: "SIA", IATA: "SQ", Name: "Singapore Airlines", Callsign: "SINGAPORE", Country: "Singapore", CountryISO: "SG"},
+	{ICAO: "MAS", IATA: "MH", Name: "Malaysia Airlines", Callsign: "MALAYSIAN", Country: "Malaysia", CountryISO: "MY"},
+	{ICAO: "CPA", IATA: "CX", Name: "Cathay Pacific", Callsign: "CATHAY", Country: "Hong Kong", CountryISO: "HK"},
+	{ICAO: "CCA", IATA: "CA", Name: "Air China", Callsign: "AIR CHINA", Country: "China", CountryISO: "CN"},
+	{ICAO: "CES", IATA: "MU", Name: "China Eastern Airlines", Callsign: "CHINA EASTERN", Country: "China", CountryISO: "CN"},
+	{ICAO: "CSN", IATA: "CZ", Name: "China Southern Airlines", Callsign: "CHINA SOUTHERN", Country: "China", CountryISO: "CN"},
+	{ICAO: "KAL", IATA: "KE", Name: "Korean Air", Callsign: "KOREANAIR", Country: "South Korea", CountryISO: "KR"},
+	{ICAO: "JAL", IATA: "JL", Name: "Japan Airlines", Callsign: "JAPANAIR", Country: "Japan", CountryISO: "JP"},
+	{ICAO: "ANA", IATA: "NH", Name: "All Nippon Airways", Callsign: "ALL NIPPON", Country: "Japan", CountryISO: "JP"},
+	{ICAO: "QFA", IATA: "QF", Name: "Qantas", Callsign: "QANTAS", Country: "Australia", CountryISO: "AU"},
+}
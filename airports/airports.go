@@ -0,0 +1,86 @@
+// Package airports is an offline airport table in the OurAirports layout: city,
+// country, IATA code, elevation, coordinates and runways. A handful of London
+// airports are bundled; the full OurAirports CSVs can be loaded over them.
+package airports
+
+import (
+	"strings"
+
+	"AirTraffic-Monitor/geo"
+)
+
+// Airport is one airport and its runways.
+type Airport struct {
+	ICAO        string   `json:"icao"`
+	IATA        string   `json:"iata,omitempty"`
+	Name        string   `json:"name"`
+	Type        string   `json:"type,omitempty"` // OurAirports type, e.g. large_airport
+	City        string   `json:"city,omitempty"`
+	CountryISO  string   `json:"country_iso,omitempty"`
+	Country     string   `json:"country,omitempty"`
+	Lat         float64  `json:"lat"`
+	Lon         float64  `json:"lon"`
+	ElevationFt float64  `json:"elevation_ft"`
+	Runways     []Runway `json:"runways"`
+}
+
+// Position returns the airport reference point.
+func (a Airport) Position() geo.LatLon {
+	return geo.LatLon{Lat: a.Lat, Lon: a.Lon}
+}
+
+// Runway is a physical runway with its two ends, low-numbered end first.
+type Runway struct {
+	Ident    string       `json:"ident"` // e.g. "09L/27R"
+	LengthFt float64      `json:"length_ft"`
+	WidthFt  float64      `json:"width_ft,omitempty"`
+	Surface  string       `json:"surface,omitempty"`
+	Closed   bool         `json:"closed,omitempty"`
+	Ends     [2]RunwayEnd `json:"ends"`
+}
+
+// RunwayEnd is one threshold. HeadingTrue is the direction of landing or
+// take-off from this end, in degrees true.
+type RunwayEnd struct {
+	Ident       string  `json:"ident"`
+	Lat         float64 `json:"lat"`
+	Lon         float64 `json:"lon"`
+	ElevationFt float64 `json:"elevation_ft,omitempty"`
+	HeadingTrue float64 `json:"heading_true"`
+}
+
+// Position returns the threshold position.
+func (e RunwayEnd) Position() geo.LatLon {
+	return geo.LatLon{Lat: e.Lat, Lon: e.Lon}
+}
+
+// DB is a read-only set of airports keyed by ICAO code.
+type DB struct {
+	byICAO map[string]Airport
+}
+
+// Bundled returns the built-in London airports.
+func Bundled() *DB {
+	db := &DB{byICAO: make(map[string]Airport, len(bundled))}
+	for _, a := range bundled {
+		db.byICAO[a.ICAO] = a
+	}
+	return db
+}
+
+// Lookup returns the airport with the given ICAO code (any case).
+func (db *DB) Lookup(icao string) (Airport, bool) {
+	if db == nil {
+		return Airport{}, false
+	}
+	a, ok := db.byICAO[strings.ToUpper(strings.TrimSpace(icao))]
+	return a, ok
+}
+
+// Len returns the number of airports.
+func (db *DB) Len() int {
+	if db == nil {
+		return 0
+	}
+	return len(db.byICAO)
+}
@@ -0,0 +1,81 @@
+package airports
+
+// bundled covers the London airports so runways and cities are known without
+// importing OurAirports. Threshold positions are approximate (within ~100 m),
+// which is enough for runway attribution but not for navigation.
+var bundled = []Airport{
+	{
+		ICAO: "EGLL", IATA: "LHR", Name: "London Heathrow Airport", Type: "large_airport",
+		City: "London", CountryISO: "GB", Country: "United Kingdom",
+		Lat: 51.4706, Lon: -0.461941, ElevationFt: 83,
+		Runways: []Runway{
+			{Ident: "09L/27R", LengthFt: 12802, WidthFt: 164, Surface: "ASP", Ends: [2]RunwayEnd{
+				{Ident: "09L", Lat: 51.4775, Lon: -0.4850, ElevationFt: 79, HeadingTrue: 89.7},
+				{Ident: "27R", Lat: 51.4777, Lon: -0.4332, ElevationFt: 78, HeadingTrue: 269.7},
+			}},
+			{Ident: "09R/27L", LengthFt: 12008, WidthFt: 164, Surface: "ASP", Ends: [2]RunwayEnd{
+				{Ident: "09R", Lat: 51.4648, Lon: -0.4822, ElevationFt: 75, HeadingTrue: 89.7},
+				{Ident: "27L", Lat: 51.4650, Lon: -0.4340, ElevationFt: 77, HeadingTrue: 269.7},
+			}},
+		},
+	},
+	{
+		ICAO: "EGKK", IATA: "LGW", Name: "London Gatwick Airport", Type: "large_airport",
+		City: "London", CountryISO: "GB", Country: "United Kingdom",
+		Lat: 51.148102, Lon: -0.190278, ElevationFt: 202,
+		Runways: []Runway{
+			{Ident: "08R/26L", LengthFt: 10879, WidthFt: 148, Surface: "ASP", Ends: [2]RunwayEnd{
+				{Ident: "08R", Lat: 51.1448, Lon: -0.2095, ElevationFt: 196, HeadingTrue: 77.7},
+				{Ident: "26L", Lat: 51.1512, Lon: -0.1635, ElevationFt: 202, HeadingTrue: 257.7},
+			}},
+		},
+	},
+	{
+		ICAO: "EGSS", IATA: "STN", Name: "London Stansted Airport", Type: "large_airport",
+		City: "London", CountryISO: "GB", Country: "United Kingdom",
+		Lat: 51.884998, Lon: 0.235, ElevationFt: 348,
+		Runways: []Runway{
+			{Ident: "04/22", LengthFt: 10003, WidthFt: 151, Surface: "ASP", Ends: [2]RunwayEnd{
+				{Ident: "04", Lat: 51.8749, Lon: 0.2200, ElevationFt: 321, HeadingTrue: 42.4},
+				{Ident: "22", Lat: 51.8951, Lon: 0.2500, ElevationFt: 348, HeadingTrue: 222.4},
+			}},
+		},
+	},
+	{
+		ICAO: "EGGW", IATA: "LTN", Name: "London Luton Airport", Type: "large_airport",
+		City: "London", CountryISO: "GB", Country: "United Kingdom",
+		Lat: 51.874699, Lon: -0.368333, ElevationFt: 526,
+		Runways: []Runway{
+			{Ident: "07/25", LengthFt: 7087, WidthFt: 151, Surface: "ASP", Ends: [2]RunwayEnd{
+				{Ident: "07", Lat: 51.8717, Lon: -0.3832, ElevationFt: 526, HeadingTrue: 72.6},
+				{Ident: "25", Lat: 51.8777, Lon: -0.3534, ElevationFt: 508, HeadingTrue: 252.6},
+			}},
+		},
+	},
+	{
+		ICAO: "EGLC", IATA: "LCY", Name: "London City Airport", Type: "medium_airport",
+		City: "London", CountryISO: "GB", Country: "United Kingdom",
+		Lat: 51.505299, Lon: 0.055278, ElevationFt: 19,
+		Runways: []Runway{
+			{Ident: "09/27", LengthFt: 4948, WidthFt: 98, Surface: "CON", Ends: [2]RunwayEnd{
+				{Ident: "09", Lat: 51.5056, Lon: 0.0444, ElevationFt: 17, HeadingTrue: 92.9},
+				{Ident: "27", Lat: 51.5050, Lon: 0.0662, ElevationFt: 19, HeadingTrue: 272.9},
+			}},
+		},
+	},
+	{
+		ICAO: "EGWU", Name: "RAF Northolt", Type: "medium_airport",
+		City: "London", CountryISO: "GB", Country: "United Kingdom",
+		Lat: 51.553001, Lon: -0.418167, ElevationFt: 124,
+	},
+	{
+		ICAO: "EGKB", IATA: "BQH", Name: "London Biggin Hill Airport", Type: "medium_airport",
+		City: "London", CountryISO: "GB", Country: "United Kingdom",
+		Lat: 51.330799, Lon: 0.0325, ElevationFt: 598,
+	},
+	{
+		ICAO: "EGLF", IATA: "FAB", Name: "Farnborough Airport", Type: "medium_airport",
+		City: "Farnborough", CountryISO: "GB", Country: "United Kingdom",
+		Lat: 51.275799, Lon: -0.776333, ElevationFt: 238,
+	},
+}
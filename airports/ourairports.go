@@ -0,0 +1,163 @@
+package airports
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Load returns the bundled airports overlaid with OurAirports exports
+// (https://ourairports.com/data/). airportsCSV is airports.csv; runwaysCSV
+// (runways.csv) and countriesCSV (countries.csv, for country names) are
+// optional and may be empty. Airports without an ICAO-style code are skipped.
+func Load(airportsCSV, runwaysCSV, countriesCSV string) (*DB, error) {
+	db := Bundled()
+	if airportsCSV == "" {
+		return db, nil
+	}
+
+	countries := make(map[string]string)
+	if countriesCSV != "" {
+		err := readCSV(countriesCSV, func(row csvRow) {
+			countries[row.get("code")] = row.get("name")
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	identToICAO := make(map[string]string)
+	imported := make(map[string]Airport)
+	err := readCSV(airportsCSV, func(row csvRow) {
+		if row.get("type") == "closed" {
+			return
+		}
+		icao := strings.ToUpper(firstNonEmpty(row.get("icao_code"), row.get("gps_code"), row.get("ident")))
+		if len(icao) != 4 {
+			return
+		}
+		a := Airport{
+			ICAO:        icao,
+			IATA:        strings.ToUpper(row.get("iata_code")),
+			Name:        row.get("name"),
+			Type:        row.get("type"),
+			City:        row.get("municipality"),
+			CountryISO:  row.get("iso_country"),
+			Lat:         row.float("latitude_deg"),
+			Lon:         row.float("longitude_deg"),
+			ElevationFt: row.float("elevation_ft"),
+		}
+		a.Country = countries[a.CountryISO]
+		// A bundled airport keeps its country and city if the import lacks them.
+		if old, ok := db.byICAO[icao]; ok {
+			a.Country = firstNonEmpty(a.Country, old.Country)
+			a.City = firstNonEmpty(a.City, old.City)
+		}
+		identToICAO[row.get("ident")] = icao
+		imported[icao] = a
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if runwaysCSV != "" {
+		runways := make(map[string][]Runway)
+		err := readCSV(runwaysCSV, func(row csvRow) {
+			icao, ok := identToICAO[row.get("airport_ident")]
+			if !ok {
+				return
+			}
+			le, he := row.get("le_ident"), row.get("he_ident")
+			runways[icao] = append(runways[icao], Runway{
+				Ident:    strings.Trim(le+"/"+he, "/"),
+				LengthFt: row.float("length_ft"),
+				WidthFt:  row.float("width_ft"),
+				Surface:  row.get("surface"),
+				Closed:   row.get("closed") == "1",
+				Ends: [2]RunwayEnd{
+					{Ident: le, Lat: row.float("le_latitude_deg"), Lon: row.float("le_longitude_deg"),
+						ElevationFt: row.float("le_elevation_ft"), HeadingTrue: row.float("le_heading_degT")},
+					{Ident: he, Lat: row.float("he_latitude_deg"), Lon: row.float("he_longitude_deg"),
+						ElevationFt: row.float("he_elevation_ft"), HeadingTrue: row.float("he_heading_degT")},
+				},
+			})
+		})
+		if err != nil {
+			return nil, err
+		}
+		for icao, rw := range runways {
+			a := imported[icao]
+			a.Runways = rw
+			imported[icao] = a
+		}
+	}
+
+	for icao, a := range imported {
+		// Keep the bundled runways when runways.csv was not supplied.
+		if old, ok := db.byICAO[icao]; ok && len(a.Runways) == 0 {
+			a.Runways = old.Runways
+		}
+		db.byICAO[icao] = a
+	}
+	return db, nil
+}
+
+// csvRow is one data row addressed by header name.
+type csvRow struct {
+	cols   map[string]int
+	fields []string
+}
+
+func (r csvRow) get(name string) string {
+	i, ok := r.cols[name]
+	if !ok || i >= len(r.fields) {
+		return ""
+	}
+	return strings.TrimSpace(r.fields[i])
+}
+
+func (r csvRow) float(name string) float64 {
+	v, _ := strconv.ParseFloat(r.get(name), 64)
+	return v
+}
+
+// readCSV calls fn for every data row of the CSV file at path.
+func readCSV(path string, fn func(csvRow)) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = -1
+	header, err := r.Read()
+	if err != nil {
+		return fmt.Errorf("%s: read header: %w", path, err)
+	}
+	cols := make(map[string]int, len(header))
+	for i, h := range header {
+		cols[strings.TrimPrefix(strings.TrimSpace(h), "\ufeff")] = i
+	}
+	for {
+		fields, err := r.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		fn(csvRow{cols: cols, fields: fields})
+	}
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
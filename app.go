@@ -0,0 +1,258 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"AirTraffic-Monitor/actype"
+	"AirTraffic-Monitor/adsbdb"
+	"AirTraffic-Monitor/aircraftdb"
+	"AirTraffic-Monitor/airports"
+	"AirTraffic-Monitor/config"
+	"AirTraffic-Monitor/fusion"
+	"AirTraffic-Monitor/logging"
+	"AirTraffic-Monitor/monitor"
+	"AirTraffic-Monitor/noise"
+	"AirTraffic-Monitor/opensky"
+	"AirTraffic-Monitor/record"
+	"AirTraffic-Monitor/replay"
+	"AirTraffic-Monitor/sbs"
+	"AirTraffic-Monitor/tar1090"
+	"AirTraffic-Monitor/watchlist"
+)
+
+// app is the monitor and everything it loads from the config, shared by the
+// commands. serve adds the web server and MQTT on top.
+type app struct {
+	cfg    config.Config
+	logs   *logging.Loggers
+	logger *slog.Logger
+	client *http.Client
+	replay *replaySession
+
+	store    *monitor.Store
+	mon      *monitor.Monitor
+	wl       *watchlist.Watchlist
+	meter    *noise.Meter
+	airports *airports.DB
+	db       *aircraftdb.DB
+	recorder *record.Recorder
+}
+
+// newApp loads the tables, state files and upstream clients the config names
+// and builds the monitor, replaying rs instead of polling live when it is not
+// nil. On failure it logs why and returns the exit status.
+func newApp(ctx context.Context, cfg config.Config, logs *logging.Loggers, rs *replaySession) (*app, int) {
+	a := &app{
+		cfg:    cfg,
+		logs:   logs,
+		logger: logs.For(logging.ComponentMonitor).With("region", cfg.Region),
+		replay: rs,
+	}
+	logger := a.logger
+
+	timeout := 10 * time.Second
+	a.client = &http.Client{Timeout: timeout}
+	skyClient, adsbClient := a.client, a.client
+	if rs != nil && rs.lookups != nil {
+		adsbClient = &http.Client{Timeout: timeout, Transport: rs.lookups}
+	}
+	if cfg.Record.Dir != "" {
+		recLog := logs.For(logging.ComponentRecord)
+		recorder, err := record.New(cfg.Record.Dir, recLog)
+		if err != nil {
+			recLog.Error("failed to create archive", "dir", cfg.Record.Dir, "err", err)
+			return nil, 1
+		}
+		recorder.MaxFileBytes = cfg.Record.MaxFileMB << 20
+		recorder.MaxTotalBytes = cfg.Record.MaxTotalMB << 20
+		recorder.MaxAge = cfg.Record.MaxAge.Duration
+		recorder.Prune()
+		a.recorder = recorder
+		skyClient = &http.Client{Timeout: timeout, Transport: recorder.Transport(fusion.SourceOpenSky, skyClient.Transport)}
+		adsbClient = &http.Client{Timeout: timeout, Transport: recorder.Transport("adsbdb", adsbClient.Transport)}
+		recLog.Info("recording upstream responses", "dir", cfg.Record.Dir, "max_file_mb", cfg.Record.MaxFileMB,
+			"max_total_mb", cfg.Record.MaxTotalMB, "max_age", cfg.Record.MaxAge.Duration)
+	}
+
+	wl, err := watchlist.Load(cfg.WatchlistPath, cfg.SpottedPath)
+	if err != nil {
+		logs.For(logging.ComponentWatchlist).Error("failed to load watchlist", "err", err)
+		return nil, 1
+	}
+	a.wl = wl
+
+	airportDB, err := airports.Load(cfg.Airports.AirportsCSV, cfg.Airports.RunwaysCSV, cfg.Airports.CountriesCSV)
+	if err != nil {
+		logger.Error("failed to load airports", "err", err)
+		return nil, 1
+	}
+	if cfg.Airports.AirportsCSV != "" {
+		logger.Info("airports loaded", "path", cfg.Airports.AirportsCSV, "airports", airportDB.Len())
+	}
+	a.airports = airportDB
+
+	typeDB, err := actype.Load(cfg.AircraftTypes)
+	if err != nil {
+		logger.Error("failed to load aircraft types", "err", err)
+		return nil, 1
+	}
+	if cfg.AircraftTypes != "" {
+		logger.Info("aircraft types loaded", "path", cfg.AircraftTypes, "types", typeDB.Len())
+	}
+
+	for _, code := range cfg.LocalAirports {
+		ap, ok := airportDB.Lookup(code)
+		if !ok {
+			logger.Warn("local airport not in the airport table; it will be ignored", "icao", code)
+			continue
+		}
+		if !cfg.BBox.ContainsRadius(ap.Position(), monitor.AirportCoverageKm) {
+			logger.Warn("bbox does not cover the local airport; its takeoffs, landings and runway direction may be missed",
+				"icao", code, "radius_km", monitor.AirportCoverageKm)
+		}
+	}
+
+	for _, st := range cfg.Holds {
+		if !cfg.BBox.ContainsRadius(st.Position(), st.RadiusKm) {
+			logger.Warn("bbox does not cover the holding stack; aircraft holding there may be missed",
+				"stack", st.Ident, "radius_km", st.RadiusKm)
+		}
+	}
+
+	if len(cfg.Noise.Observers) > 0 {
+		noiseLog := logs.For(logging.ComponentNoise)
+		if err := noise.ResolvePostcodes(ctx, a.client, cfg.Noise.PostcodesURL, cfg.Noise.Observers); err != nil {
+			noiseLog.Error("failed to resolve observer postcodes", "err", err)
+			return nil, 1
+		}
+		if a.meter, err = noise.Load(cfg.Noise.Path, cfg.Noise.Observers, cfg.Noise.ThresholdsDB); err != nil {
+			noiseLog.Error("failed to load noise totals", "path", cfg.Noise.Path, "err", err)
+			return nil, 1
+		}
+		noiseLog.Info("estimating noise", "observers", len(cfg.Noise.Observers), "path", cfg.Noise.Path)
+	}
+
+	sky := opensky.NewClient(cfg.OpenSkyURL, skyClient)
+	if rs != nil {
+		sky = nil
+	}
+	a.store = monitor.NewStore()
+	mon := monitor.New(monitor.Config{
+		Region:             cfg.Region,
+		BBox:               cfg.BBox,
+		Home:               cfg.Home,
+		EnrichWorkers:      cfg.EnrichWorkers,
+		LocalAirports:      cfg.LocalAirports,
+		Holds:              cfg.Holds,
+		AircraftPrecedence: cfg.AircraftDB.Precedence,
+		SourcePriority:     cfg.SourcePriority,
+	}, sky, adsbdb.NewClient(cfg.ADSBDBURL, adsbClient), a.store, logs)
+	mon.Watchlist = wl
+	mon.Airports = airportDB
+	mon.AircraftTypes = typeDB
+	mon.Noise = a.meter
+	if rs != nil {
+		mon.Clock = rs.clock.Now
+		mon.Feeds = rs.feeds
+	}
+	a.mon = mon
+
+	if cfg.AircraftDB.Path != "" {
+		dbLog := logs.For(logging.ComponentAircraftDB)
+		db, err := aircraftdb.Open(cfg.AircraftDB.Path)
+		if err != nil && cfg.AircraftDB.Precedence == aircraftdb.LocalOnly {
+			dbLog.Error("failed to open aircraft database", "path", cfg.AircraftDB.Path, "err", err)
+			return nil, 1
+		}
+		if err != nil {
+			dbLog.Warn("aircraft database unavailable, using adsbdb only", "path", cfg.AircraftDB.Path, "err", err)
+		} else {
+			a.db = db
+			mon.AircraftDB = db
+			dbLog.Info("aircraft database opened", "path", cfg.AircraftDB.Path, "records", db.Len(),
+				"precedence", cfg.AircraftDB.Precedence)
+		}
+	}
+	return a, 0
+}
+
+// startFeeds connects the local receivers the config names, unless replaying.
+// It reports whether an SBS feed was started, which needs a while to hear
+// aircraft.
+func (a *app) startFeeds(ctx context.Context) bool {
+	if a.replay != nil {
+		return false
+	}
+	if a.cfg.Tar1090.URL != "" {
+		a.mon.Feeds = append(a.mon.Feeds, fusion.Feed{Name: fusion.SourceTar1090, Source: tar1090.NewClient(a.cfg.Tar1090.URL, a.client)})
+		a.logs.For(logging.ComponentTar1090).Info("merging receiver aircraft", "url", a.cfg.Tar1090.URL)
+	}
+	if a.cfg.SBS.Address == "" {
+		return false
+	}
+	feed := sbs.NewFeed(a.cfg.SBS.Address)
+	go feed.Run(ctx, a.logs.For(logging.ComponentSBS))
+	a.mon.Feeds = append(a.mon.Feeds, fusion.Feed{Name: fusion.SourceSBS, Source: feed})
+	return true
+}
+
+// cycle runs a single monitoring cycle, first listening to a local SBS feed
+// for warmup so that it has aircraft to report.
+func (a *app) cycle(ctx context.Context, warmup time.Duration) (monitor.Cycle, error) {
+	if a.startFeeds(ctx) && warmup > 0 {
+		a.logger.Info("listening to the sbs feed before the cycle", "warmup", warmup)
+		select {
+		case <-ctx.Done():
+			return monitor.Cycle{}, ctx.Err()
+		case <-time.After(warmup):
+		}
+	}
+	return a.mon.RunCycle(ctx)
+}
+
+// close flushes the watchlist, spotted log and noise totals to disk and closes
+// the archive and registry. It returns 1 if any of it failed.
+func (a *app) close() int {
+	status := 0
+	if err := a.wl.Flush(); err != nil {
+		a.logs.For(logging.ComponentWatchlist).Error("failed to flush watchlist", "err", err)
+		status = 1
+	}
+	if a.meter != nil {
+		if err := a.meter.Flush(); err != nil {
+			a.logs.For(logging.ComponentNoise).Error("failed to flush noise totals", "err", err)
+			status = 1
+		}
+	}
+	return max(status, a.closeReadOnly())
+}
+
+// closeReadOnly closes the archive and the aircraft database but leaves the
+// watchlist, spotted log and noise totals alone. The one-shot commands use it:
+// a serve may own those files, and a pass still in progress when they exit is
+// not a finished overflight.
+func (a *app) closeReadOnly() int {
+	status := 0
+	if a.recorder != nil {
+		if err := a.recorder.Close(); err != nil {
+			a.logs.For(logging.ComponentRecord).Error("failed to close archive", "err", err)
+			status = 1
+		}
+	}
+	if a.db != nil {
+		a.db.Close()
+	}
+	return status
+}
+
+// replaySession is an open -replay: the simulated clock, the recordings and,
+// when a recording archive holds them, the adsbdb responses to answer lookups.
+type replaySession struct {
+	clock      *replay.Clock
+	recordings []replay.Recording
+	feeds      []fusion.Feed
+	lookups    *replay.Responses
+}
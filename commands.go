@@ -0,0 +1,250 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"AirTraffic-Monitor/aircraftdb"
+	"AirTraffic-Monitor/logging"
+	"AirTraffic-Monitor/noise"
+	"AirTraffic-Monitor/watchlist"
+)
+
+// sbsWarmup is how long once and export listen to a local SBS feed before
+// their cycle, by default.
+const sbsWarmup = 10 * time.Second
+
+// once runs a single cycle and prints it. It exits 1 when no position source
+// answered, so cron can tell an outage from an empty sky.
+func once(args []string) int {
+	fs := newFlagSet("once", "", "Runs a single monitoring cycle and prints its aircraft and events. Exits 1 if it fails.")
+	configPath, format := commonFlags(fs)
+	warmup := fs.Duration("warmup", sbsWarmup, "how long to listen to sbs.address before the cycle")
+	if _, status, ok := parseArgs(fs, args, 0, 0); !ok {
+		return status
+	}
+	if err := checkFormat(*format); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	cfg, logs, ok := loadConfig(*configPath)
+	if !ok {
+		return 2
+	}
+	// A single cycle must not end up in the spotted log or noise totals a serve keeps.
+	cfg.SpottedPath, cfg.Noise.Path = "", ""
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	a, status := newApp(ctx, cfg, logs, nil)
+	if a == nil {
+		return status
+	}
+	cycle, err := a.cycle(ctx, *warmup)
+	status = a.closeReadOnly()
+	if err != nil {
+		a.logger.Error("aircraft check failed", "err", err)
+		return 1
+	}
+	if err := writeCycle(os.Stdout, *format, cfg.Region, cycle, true); err != nil {
+		a.logger.Error("failed to write output", "err", err)
+		return 1
+	}
+	return status
+}
+
+// lookup enriches one aircraft and prints it. It exits 1 when nothing is
+// known about it or the lookup failed.
+func lookup(args []string) int {
+	fs := newFlagSet("lookup", "<icao24|registration>",
+		"Looks an aircraft up in adsbdb and the local aircraft database, as the board would show it.")
+	configPath, format := commonFlags(fs)
+	callsign := fs.String("callsign", "", "also look up the route and airline flown under this callsign")
+	operands, status, ok := parseArgs(fs, args, 1, 1)
+	if !ok {
+		return status
+	}
+	if err := checkFormat(*format); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	cfg, logs, ok := loadConfig(*configPath)
+	if !ok {
+		return 2
+	}
+	// A lookup estimates no noise, so there are no observer postcodes to resolve.
+	cfg.Noise.Observers = nil
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	a, status := newApp(ctx, cfg, logs, nil)
+	if a == nil {
+		return status
+	}
+	info, err := a.mon.Lookup(ctx, operands[0], *callsign)
+	status = a.closeReadOnly()
+	if err != nil {
+		logs.For(logging.ComponentADSBDB).Error("lookup failed", "id", operands[0], "callsign", *callsign, "err", err)
+		return 1
+	}
+	if err := writeRecord(os.Stdout, *format, aircraftColumns, info, info); err != nil {
+		a.logger.Error("failed to write output", "err", err)
+		return 1
+	}
+	return status
+}
+
+// Datasets export can print.
+const (
+	exportAircraft    = "aircraft"
+	exportSpotted     = "spotted"
+	exportNoise       = "noise"
+	exportOverflights = "overflights"
+)
+
+// export prints the aircraft of a fresh cycle, or history kept on disk: the
+// spotted log, daily noise totals or recent overflights.
+func export(args []string) int {
+	fs := newFlagSet("export", "[aircraft|spotted|noise|overflights]",
+		"Prints the current aircraft, after one cycle, or history kept on disk: the watchlist's spotted log,\n"+
+			"the daily noise totals or the recent overflights. The default is aircraft.")
+	configPath, format := commonFlags(fs)
+	warmup := fs.Duration("warmup", sbsWarmup, "aircraft: how long to listen to sbs.address before the cycle")
+	since := fs.String("since", "", "spotted, noise, overflights: first day to include, YYYY-MM-DD")
+	until := fs.String("until", "", "spotted, noise, overflights: last day to include, YYYY-MM-DD")
+	observer := fs.String("observer", "", "noise, overflights: the observer to include (default: all)")
+	operands, status, ok := parseArgs(fs, args, 0, 1)
+	if !ok {
+		return status
+	}
+	dataset := exportAircraft
+	if len(operands) == 1 {
+		dataset = operands[0]
+	}
+	switch dataset {
+	case exportAircraft, exportSpotted, exportNoise, exportOverflights:
+	default:
+		fmt.Fprintf(os.Stderr, "export: unknown dataset %q; want aircraft, spotted, noise or overflights\n", dataset)
+		return 2
+	}
+	if err := checkFormat(*format); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	for _, d := range []string{*since, *until} {
+		if _, err := time.Parse(time.DateOnly, d); d != "" && err != nil {
+			fmt.Fprintf(os.Stderr, "export: %q is not a YYYY-MM-DD date\n", d)
+			return 2
+		}
+	}
+	cfg, logs, ok := loadConfig(*configPath)
+	if !ok {
+		return 2
+	}
+	logger := logs.For(logging.ComponentMonitor).With("region", cfg.Region)
+	inRange := func(first, last time.Time) bool {
+		return (*since == "" || last.Local().Format(time.DateOnly) >= *since) &&
+			(*until == "" || first.Local().Format(time.DateOnly) <= *until)
+	}
+
+	var err error
+	switch dataset {
+	case exportAircraft:
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		cfg.SpottedPath, cfg.Noise.Path = "", ""
+		var a *app
+		if a, status = newApp(ctx, cfg, logs, nil); a == nil {
+			return status
+		}
+		cycle, cerr := a.cycle(ctx, *warmup)
+		if status = a.closeReadOnly(); cerr != nil {
+			logger.Error("aircraft check failed", "err", cerr)
+			return 1
+		}
+		err = write(os.Stdout, *format, nonNil(cycle.Aircraft), tabulate(aircraftColumns, cycle.Aircraft, *format), true)
+
+	case exportSpotted:
+		wl, lerr := watchlist.Load(cfg.WatchlistPath, cfg.SpottedPath)
+		if lerr != nil {
+			logs.For(logging.ComponentWatchlist).Error("failed to load watchlist", "err", lerr)
+			return 1
+		}
+		sightings := []watchlist.Sighting{}
+		for _, s := range wl.Spotted() {
+			if inRange(s.FirstSeen, s.LastSeen) {
+				sightings = append(sightings, s)
+			}
+		}
+		err = write(os.Stdout, *format, sightings, tabulate(sightingColumns, sightings, *format), true)
+
+	case exportNoise, exportOverflights:
+		meter, lerr := noise.Load(cfg.Noise.Path, cfg.Noise.Observers, cfg.Noise.ThresholdsDB)
+		if lerr != nil {
+			logs.For(logging.ComponentNoise).Error("failed to load noise totals", "path", cfg.Noise.Path, "err", lerr)
+			return 1
+		}
+		if dataset == exportNoise {
+			days := meter.Days(*observer, *since, *until)
+			err = write(os.Stdout, *format, days, tabulate(noiseDayColumns(cfg.Noise.ThresholdsDB), days, *format), true)
+			break
+		}
+		overflights := []noise.Overflight{}
+		for _, o := range meter.Overflights(*observer, "") {
+			if inRange(o.Time, o.Time) {
+				overflights = append(overflights, o)
+			}
+		}
+		err = write(os.Stdout, *format, overflights, tabulate(overflightColumns, overflights, *format), true)
+	}
+	if err != nil {
+		logger.Error("failed to write output", "err", err)
+		return 1
+	}
+	return status
+}
+
+// refreshAircraftDB reimports the registry CSV into the indexed lookup file. A
+// running serve picks the new file up on its next cycle, so this can run from
+// cron next to it.
+func refreshAircraftDB(args []string) int {
+	fs := newFlagSet("refresh-aircraft-db", "",
+		"Reimports aircraft_db.source (a CSV file or URL) into the indexed aircraft_db.path.")
+	configPath := fs.String("config", "", "path to a JSON config file (optional)")
+	if _, status, ok := parseArgs(fs, args, 0, 0); !ok {
+		return status
+	}
+	cfg, logs, ok := loadConfig(*configPath)
+	if !ok {
+		return 2
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	logger := logs.For(logging.ComponentAircraftDB)
+	if cfg.AircraftDB.Path == "" || cfg.AircraftDB.Source == "" {
+		logger.Error("aircraft_db.path and aircraft_db.source must both be set to refresh")
+		return 2
+	}
+	// Registry dumps are hundreds of megabytes, so allow far longer than the API timeout.
+	src, err := aircraftdb.OpenSource(ctx, cfg.AircraftDB.Source, &http.Client{Timeout: 30 * time.Minute})
+	if err != nil {
+		logger.Error("failed to open aircraft database source", "source", cfg.AircraftDB.Source, "err", err)
+		return 1
+	}
+	defer src.Close()
+	started := time.Now()
+	n, err := aircraftdb.Import(cfg.AircraftDB.Path, src)
+	if err != nil {
+		logger.Error("aircraft database import failed", "source", cfg.AircraftDB.Source, "err", err)
+		return 1
+	}
+	logger.Info("aircraft database refreshed", "source", cfg.AircraftDB.Source, "path", cfg.AircraftDB.Path, "records", n,
+		"took", time.Since(started).Round(time.Millisecond))
+	return 0
+}
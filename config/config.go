@@ -0,0 +1,304 @@
+// Package config defines the JSON configuration file and its defaults.
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"AirTraffic-Monitor/fusion"
+	"AirTraffic-Monitor/geo"
+	"AirTraffic-Monitor/holding"
+	"AirTraffic-Monitor/noise"
+	"AirTraffic-Monitor/record"
+)
+
+// Config holds the settings that can be overridden from a JSON file passed with -config.
+// Anything left out keeps the built-in default.
+type Config struct {
+	Region         string              `json:"region"`
+	BBox           geo.BBox            `json:"bbox"` // area polled from OpenSky
+	Home           geo.LatLon          `json:"home"` // reference point for distances
+	OpenSkyURL     string              `json:"opensky_url"`
+	SBS            SBSConfig           `json:"sbs"`
+	Tar1090        Tar1090Config       `json:"tar1090"`
+	SourcePriority []string            `json:"source_priority"` // preferred position source first when reports are equally fresh
+	ADSBDBURL      string              `json:"adsbdb_url"`
+	WatchlistPath  string              `json:"watchlist_path"`
+	SpottedPath    string              `json:"spotted_path"`
+	AircraftDB     AircraftDBConfig    `json:"aircraft_db"`
+	Airports       AirportsConfig      `json:"airports"`
+	LocalAirports  []string            `json:"local_airports"`     // ICAO codes the arrivals and departures boards cover
+	Holds          []holding.Stack     `json:"holds"`              // holding stacks to watch; [] disables
+	AircraftTypes  string              `json:"aircraft_types_csv"` // designator table overlaid on the bundled one
+	Noise          NoiseConfig         `json:"noise"`
+	Record         RecordConfig        `json:"record"`
+	History        HistoryConfig       `json:"history"`
+	MQTT           MQTTConfig          `json:"mqtt"`
+	HomeAssistant  HomeAssistantConfig `json:"home_assistant"`
+	Log            LogConfig           `json:"log"`
+
+	EnrichWorkers   int      `json:"enrich_workers"`   // concurrent adsbdb lookups
+	ShutdownTimeout Duration `json:"shutdown_timeout"` // per shutdown phase
+	PredictMaxAge   Duration `json:"predict_max_age"`  // how far past a position report the API extrapolates
+}
+
+// SBSConfig points at a BaseStation (port 30003) feed from a local receiver.
+type SBSConfig struct {
+	Address string `json:"address"` // host:port; empty disables the feed
+}
+
+// Tar1090Config points at a local receiver's tar1090 or readsb web interface.
+type Tar1090Config struct {
+	URL string `json:"url"` // e.g. http://adsb.local/tar1090; empty disables it
+}
+
+// AircraftDBConfig points at the local aircraft registry used alongside adsbdb.
+type AircraftDBConfig struct {
+	Path       string `json:"path"`       // indexed lookup file; empty disables the local registry
+	Source     string `json:"source"`     // CSV file or URL imported by refresh-aircraft-db
+	Precedence string `json:"precedence"` // "adsbdb", "local" or "local_only"
+}
+
+// AirportsConfig points at OurAirports CSV exports loaded over the bundled London airports.
+type AirportsConfig struct {
+	AirportsCSV  string `json:"airports_csv"`  // airports.csv; empty uses only the bundled table
+	RunwaysCSV   string `json:"runways_csv"`   // runways.csv, optional
+	CountriesCSV string `json:"countries_csv"` // countries.csv, optional, for country names
+}
+
+// NoiseConfig places the observers noise is estimated for.
+type NoiseConfig struct {
+	Observers    []noise.Observer `json:"observers"`     // empty disables noise estimation
+	Path         string           `json:"path"`          // daily totals and recent overflights; empty keeps them in memory
+	ThresholdsDB []float64        `json:"thresholds_db"` // levels counted per day, e.g. 65 for N65
+	PostcodesURL string           `json:"postcodes_url"` // postcodes.io-compatible API for observers given by postcode
+}
+
+// RecordConfig sets up the archive of raw OpenSky and adsbdb responses.
+type RecordConfig struct {
+	Dir        string   `json:"dir"`          // empty disables recording
+	MaxFileMB  int64    `json:"max_file_mb"`  // start a new file once one reaches this size
+	MaxTotalMB int64    `json:"max_total_mb"` // delete the oldest files beyond this total; 0 is unlimited
+	MaxAge     Duration `json:"max_age"`      // delete files older than this; "0s" keeps them
+}
+
+// HistoryConfig sets up the history of each cycle's positions, kept for replay.
+type HistoryConfig struct {
+	Dir    string   `json:"dir"`     // empty disables the history
+	MaxAge Duration `json:"max_age"` // delete daily files older than this; "0s" keeps them
+}
+
+// HomeAssistantConfig controls MQTT discovery for Home Assistant. It needs MQTT enabled.
+type HomeAssistantConfig struct {
+	Enabled          bool   `json:"enabled"`
+	DiscoveryPrefix  string `json:"discovery_prefix"`
+	WatchlistSensors bool   `json:"watchlist_sensors"` // one presence sensor per watchlist entry
+}
+
+// MQTTConfig describes the broker connection and topic layout for the MQTT publisher.
+type MQTTConfig struct {
+	Enabled     bool     `json:"enabled"`
+	Broker      string   `json:"broker"` // tcp://host:1883, ssl://host:8883 or tls://host:8883
+	ClientID    string   `json:"client_id"`
+	Username    string   `json:"username"`
+	Password    string   `json:"password"`
+	TopicPrefix string   `json:"topic_prefix"`
+	QoS         byte     `json:"qos"`
+	KeepAlive   Duration `json:"keep_alive"`
+	TLS         struct {
+		CAFile             string `json:"ca_file"`
+		CertFile           string `json:"cert_file"`
+		KeyFile            string `json:"key_file"`
+		InsecureSkipVerify bool   `json:"insecure_skip_verify"`
+	} `json:"tls"`
+}
+
+// LogConfig selects the diagnostic log format and levels, plus the optional console table.
+type LogConfig struct {
+	Format       string            `json:"format"`     // "text" or "json"
+	Level        string            `json:"level"`      // debug, info, warn, error
+	Components   map[string]string `json:"components"` // per-component overrides, e.g. {"adsbdb": "debug"}
+	ConsoleTable bool              `json:"console_table"`
+}
+
+// Duration is a time.Duration that reads and writes as a string such as "30s".
+type Duration struct {
+	time.Duration
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	d.Duration = v
+	return nil
+}
+
+// Default watches North London. The box reaches far enough around the local
+// airports and the London stacks for their movements and holds to be seen.
+func Default() Config {
+	cfg := Config{
+		Region:         "north-london",
+		BBox:           geo.BBox{LaMin: 50.95, LoMin: -0.90, LaMax: 52.22, LoMax: 0.92},
+		Home:           geo.LatLon{Lat: 51.65, Lon: -0.15}, // North London
+		OpenSkyURL:     "https://opensky-network.org",
+		ADSBDBURL:      "https://api.adsbdb.com",
+		WatchlistPath:  "watchlist.json",
+		SpottedPath:    "spotted.json",
+		LocalAirports:  []string{"EGLL", "EGLC", "EGGW", "EGSS", "EGKK"},
+		Holds:          holding.LondonStacks(),
+		SourcePriority: append([]string(nil), fusion.DefaultPriority...),
+
+		EnrichWorkers:   4,
+		ShutdownTimeout: Duration{15 * time.Second},
+		PredictMaxAge:   Duration{5 * time.Minute},
+	}
+	cfg.AircraftDB.Precedence = "adsbdb"
+	cfg.MQTT.Broker = "tcp://localhost:1883"
+	cfg.MQTT.ClientID = "airtraffic-monitor"
+	cfg.MQTT.TopicPrefix = "airtraffic"
+	cfg.MQTT.KeepAlive = Duration{60 * time.Second}
+	cfg.HomeAssistant.DiscoveryPrefix = "homeassistant"
+	cfg.Noise.Path = "noise.json"
+	cfg.Noise.ThresholdsDB = append([]float64(nil), noise.DefaultThresholds...)
+	cfg.Noise.PostcodesURL = noise.DefaultPostcodesURL
+	cfg.Record.MaxFileMB = record.DefaultMaxFileBytes >> 20
+	cfg.Record.MaxTotalMB = record.DefaultMaxTotalBytes >> 20
+	cfg.History.MaxAge = Duration{30 * 24 * time.Hour}
+	cfg.Log = LogConfig{Format: "text", Level: "info", ConsoleTable: true}
+	return cfg
+}
+
+// Load reads path over the defaults; an empty path returns the defaults.
+func Load(path string) (Config, error) {
+	cfg := Default()
+	if path == "" {
+		return cfg, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cfg, err
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("parse %s: %w", path, err)
+	}
+	return cfg, cfg.Validate()
+}
+
+// Validate reports settings that cannot work together or would be silently ignored.
+func (cfg Config) Validate() error {
+	if cfg.EnrichWorkers < 1 {
+		return fmt.Errorf("enrich_workers must be at least 1, got %d", cfg.EnrichWorkers)
+	}
+	if cfg.PredictMaxAge.Duration < 0 {
+		return fmt.Errorf("predict_max_age must not be negative, got %s", cfg.PredictMaxAge)
+	}
+	if cfg.BBox.LaMin >= cfg.BBox.LaMax || cfg.BBox.LoMin >= cfg.BBox.LoMax {
+		return fmt.Errorf("bbox must have lamin < lamax and lomin < lomax")
+	}
+	switch cfg.AircraftDB.Precedence {
+	case "adsbdb", "local":
+	case "local_only":
+		if cfg.AircraftDB.Path == "" {
+			return fmt.Errorf("aircraft_db.precedence local_only needs aircraft_db.path")
+		}
+	default:
+		return fmt.Errorf("aircraft_db.precedence must be adsbdb, local or local_only, got %q", cfg.AircraftDB.Precedence)
+	}
+	if cfg.Airports.AirportsCSV == "" && (cfg.Airports.RunwaysCSV != "" || cfg.Airports.CountriesCSV != "") {
+		return fmt.Errorf("airports.runways_csv and airports.countries_csv need airports.airports_csv")
+	}
+	if err := validateSources(cfg.SourcePriority); err != nil {
+		return err
+	}
+	for _, code := range cfg.LocalAirports {
+		if len(code) != 4 {
+			return fmt.Errorf("local_airports must be four-letter ICAO codes, got %q", code)
+		}
+	}
+	for _, st := range cfg.Holds {
+		if err := st.Validate(); err != nil {
+			return err
+		}
+	}
+	seen := make(map[string]bool)
+	for _, o := range cfg.Noise.Observers {
+		if err := o.Validate(); err != nil {
+			return err
+		}
+		if seen[o.Name] {
+			return fmt.Errorf("noise observer %q is listed twice", o.Name)
+		}
+		seen[o.Name] = true
+	}
+	if cfg.Record.MaxFileMB < 1 {
+		return fmt.Errorf("record.max_file_mb must be at least 1, got %d", cfg.Record.MaxFileMB)
+	}
+	if cfg.Record.MaxTotalMB < 0 || cfg.Record.MaxAge.Duration < 0 {
+		return fmt.Errorf("record.max_total_mb and record.max_age must not be negative")
+	}
+	if cfg.History.MaxAge.Duration < 0 {
+		return fmt.Errorf("history.max_age must not be negative")
+	}
+	if cfg.MQTT.QoS > 1 {
+		return fmt.Errorf("mqtt.qos must be 0 or 1, got %d", cfg.MQTT.QoS)
+	}
+	if err := validateLog(cfg.Log); err != nil {
+		return err
+	}
+	if cfg.HomeAssistant.Enabled && !cfg.MQTT.Enabled {
+		return fmt.Errorf("home_assistant requires mqtt.enabled")
+	}
+	return nil
+}
+
+func validateSources(priority []string) error {
+	seen := make(map[string]bool)
+	for _, name := range priority {
+		switch name {
+		case fusion.SourceOpenSky, fusion.SourceSBS, fusion.SourceTar1090:
+		default:
+			return fmt.Errorf("source_priority entries must be opensky, sbs or tar1090, got %q", name)
+		}
+		if seen[name] {
+			return fmt.Errorf("source %q is listed twice in source_priority", name)
+		}
+		seen[name] = true
+	}
+	return nil
+}
+
+func validateLog(cfg LogConfig) error {
+	switch strings.ToLower(cfg.Format) {
+	case "", "text", "json":
+	default:
+		return fmt.Errorf("log.format must be text or json, got %q", cfg.Format)
+	}
+	levels := map[string]string{"log.level": cfg.Level}
+	for name, lvl := range cfg.Components {
+		levels["log.components."+name] = lvl
+	}
+	for key, lvl := range levels {
+		if lvl == "" {
+			continue
+		}
+		var l slog.Level
+		if err := l.UnmarshalText([]byte(lvl)); err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+	}
+	return nil
+}
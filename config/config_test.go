@@ -0,0 +1,38 @@
+package config_test
+
+import (
+	"testing"
+
+	"AirTraffic-Monitor/airports"
+	"AirTraffic-Monitor/config"
+	"AirTraffic-Monitor/monitor"
+)
+
+// The default box must reach far enough around every local airport for
+// takeoffs, landings, go-arounds and the runway direction to be detected.
+func TestDefaultBBoxCoversLocalAirports(t *testing.T) {
+	cfg := config.Default()
+	db := airports.Bundled()
+	for _, code := range cfg.LocalAirports {
+		ap, ok := db.Lookup(code)
+		if !ok {
+			t.Errorf("%s is not in the bundled airports", code)
+			continue
+		}
+		if !cfg.BBox.ContainsRadius(ap.Position(), monitor.AirportCoverageKm) {
+			t.Errorf("default bbox %+v does not cover %s (%.3f, %.3f) within %d km",
+				cfg.BBox, code, ap.Lat, ap.Lon, monitor.AirportCoverageKm)
+		}
+	}
+}
+
+// Every default holding stack must lie wholly inside the default box.
+func TestDefaultBBoxCoversHoldingStacks(t *testing.T) {
+	cfg := config.Default()
+	for _, st := range cfg.Holds {
+		if !cfg.BBox.ContainsRadius(st.Position(), st.RadiusKm) {
+			t.Errorf("default bbox %+v does not cover stack %s (%.3f, %.3f) within %.0f km",
+				cfg.BBox, st.Ident, st.Lat, st.Lon, st.RadiusKm)
+		}
+	}
+}
@@ -0,0 +1,361 @@
+// Package fakeupstream runs httptest servers that speak just enough of the
+// OpenSky and adsbdb APIs to exercise the whole pipeline offline:
+//
+//	fake := fakeupstream.New()
+//	defer fake.Close()
+//	fake.SetStates(opensky.State{ICAO24: "4CA123", Callsign: "RYR1AB"})
+//	fake.AddAircraft(fakeupstream.Aircraft{ModeS: "4CA123", Registration: "EI-ABC"})
+//	sky := opensky.NewClient(fake.OpenSky.URL, nil)
+//	db := adsbdb.NewClient(fake.ADSBDB.URL, nil)
+//
+// Payloads mirror the real wire format, so the clients are tested as well.
+package fakeupstream
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+
+	"AirTraffic-Monitor/opensky"
+)
+
+// Aircraft is an adsbdb aircraft record served by the fake.
+type Aircraft struct {
+	ModeS        string
+	Registration string
+	Type         string
+	ICAOType     string
+	Manufacturer string
+	Owner        string
+	OwnerCountry string
+}
+
+// Airport is one end of a Route.
+type Airport struct {
+	ICAO         string
+	IATA         string
+	Name         string
+	Municipality string
+	CountryISO   string
+	CountryName  string
+	Latitude     float64
+	Longitude    float64
+	Elevation    float64
+}
+
+// Airline is an adsbdb airline served by /v0/airline and attached to routes
+// whose callsign starts with its ICAO code.
+type Airline struct {
+	Name       string
+	ICAO       string
+	IATA       string
+	Country    string
+	CountryISO string
+	Callsign   string
+}
+
+// Route is an adsbdb flight route served by the fake, keyed by callsign.
+type Route struct {
+	Callsign    string
+	Origin      Airport
+	Destination Airport
+}
+
+// Server holds the two fake upstreams and the data they serve.
+type Server struct {
+	OpenSky *httptest.Server
+	ADSBDB  *httptest.Server
+
+	mu       sync.Mutex
+	states   []opensky.State
+	aircraft map[string]Aircraft
+	routes   map[string]Route
+	airlines map[string]Airline // by ICAO code
+	nNumbers map[string]string  // N-number -> Mode S
+	failures map[string]int     // path prefix -> status to return instead
+	requests map[string]int     // path -> count
+}
+
+// New starts both fake servers with no data.
+func New() *Server {
+	s := &Server{
+		aircraft: make(map[string]Aircraft),
+		routes:   make(map[string]Route),
+		airlines: make(map[string]Airline),
+		nNumbers: make(map[string]string),
+		failures: make(map[string]int),
+		requests: make(map[string]int),
+	}
+	s.OpenSky = httptest.NewServer(http.HandlerFunc(s.serveOpenSky))
+	s.ADSBDB = httptest.NewServer(http.HandlerFunc(s.serveADSBDB))
+	return s
+}
+
+// Close shuts both servers down.
+func (s *Server) Close() {
+	s.OpenSky.Close()
+	s.ADSBDB.Close()
+}
+
+// SetStates replaces the state vectors returned by /api/states/all.
+func (s *Server) SetStates(states ...opensky.State) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.states = append([]opensky.State(nil), states...)
+}
+
+// AddAircraft makes an aircraft known to /v0/aircraft/{mode_s}.
+func (s *Server) AddAircraft(a Aircraft) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.aircraft[strings.ToUpper(a.ModeS)] = a
+}
+
+// AddRoute makes a callsign route known to /v0/aircraft/{mode_s}?callsign=
+// and /v0/callsign/{callsign}.
+func (s *Server) AddRoute(r Route) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.routes[strings.ToUpper(r.Callsign)] = r
+}
+
+// AddAirline makes an airline known to /v0/airline/{code} and to the routes
+// flown under its ICAO prefix.
+func (s *Server) AddAirline(a Airline) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.airlines[strings.ToUpper(a.ICAO)] = a
+}
+
+// AddNNumber pairs a US registration with its Mode S code for the
+// /v0/mode-s and /v0/n-number conversions.
+func (s *Server) AddNNumber(nNumber, modeS string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nNumbers[strings.ToUpper(nNumber)] = strings.ToUpper(modeS)
+}
+
+// FailPath makes every request whose path starts with prefix return status,
+// e.g. FailPath("/api/states", 429) to simulate OpenSky rate limiting.
+// A status of 0 removes the failure.
+func (s *Server) FailPath(prefix string, status int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if status == 0 {
+		delete(s.failures, prefix)
+		return
+	}
+	s.failures[prefix] = status
+}
+
+// Requests returns how many requests each path has received.
+func (s *Server) Requests() map[string]int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make(map[string]int, len(s.requests))
+	for k, v := range s.requests {
+		out[k] = v
+	}
+	return out
+}
+
+// record counts the request and reports an injected failure status, if any.
+func (s *Server) record(r *http.Request) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests[r.URL.Path]++
+	for prefix, status := range s.failures {
+		if strings.HasPrefix(r.URL.Path, prefix) {
+			return status
+		}
+	}
+	return 0
+}
+
+func (s *Server) serveOpenSky(w http.ResponseWriter, r *http.Request) {
+	if status := s.record(r); status != 0 {
+		http.Error(w, http.StatusText(status), status)
+		return
+	}
+	if r.URL.Path != "/api/states/all" {
+		http.NotFound(w, r)
+		return
+	}
+	s.mu.Lock()
+	rows := make([][]interface{}, 0, len(s.states))
+	for _, st := range s.states {
+		rows = append(rows, opensky.StateRow(st))
+	}
+	s.mu.Unlock()
+	writeJSON(w, http.StatusOK, map[string]interface{}{"time": 0, "states": rows})
+}
+
+func (s *Server) serveADSBDB(w http.ResponseWriter, r *http.Request) {
+	if status := s.record(r); status != 0 {
+		writeJSON(w, status, map[string]string{"response": http.StatusText(status)})
+		return
+	}
+	path := r.URL.Path
+	switch {
+	case strings.HasPrefix(path, "/v0/aircraft/"):
+		s.serveAircraft(w, r, strings.TrimPrefix(path, "/v0/aircraft/"))
+	case strings.HasPrefix(path, "/v0/callsign/"):
+		s.serveCallsign(w, strings.TrimPrefix(path, "/v0/callsign/"))
+	case strings.HasPrefix(path, "/v0/airline/"):
+		s.serveAirline(w, strings.TrimPrefix(path, "/v0/airline/"))
+	case strings.HasPrefix(path, "/v0/mode-s/"):
+		s.serveConversion(w, strings.TrimPrefix(path, "/v0/mode-s/"), true)
+	case strings.HasPrefix(path, "/v0/n-number/"):
+		s.serveConversion(w, strings.TrimPrefix(path, "/v0/n-number/"), false)
+	default:
+		writeJSON(w, http.StatusNotFound, map[string]string{"response": "unknown endpoint"})
+	}
+}
+
+func (s *Server) serveAircraft(w http.ResponseWriter, r *http.Request, id string) {
+	s.mu.Lock()
+	a, known := s.aircraft[strings.ToUpper(id)]
+	callsign := strings.ToUpper(r.URL.Query().Get("callsign"))
+	route, hasRoute := s.routes[callsign]
+	routeJSON := s.routeJSON(route)
+	s.mu.Unlock()
+
+	if !known {
+		writeJSON(w, http.StatusNotFound, map[string]string{"response": "unknown aircraft"})
+		return
+	}
+	response := map[string]interface{}{"aircraft": aircraftJSON(a)}
+	if callsign != "" {
+		if !hasRoute {
+			writeJSON(w, http.StatusNotFound, map[string]string{"response": "unknown callsign"})
+			return
+		}
+		response["flightroute"] = routeJSON
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"response": response})
+}
+
+func (s *Server) serveCallsign(w http.ResponseWriter, callsign string) {
+	s.mu.Lock()
+	route, ok := s.routes[strings.ToUpper(callsign)]
+	routeJSON := s.routeJSON(route)
+	s.mu.Unlock()
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]string{"response": "unknown callsign"})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"response": map[string]interface{}{"flightroute": routeJSON},
+	})
+}
+
+// serveAirline matches either the ICAO or the IATA code, like adsbdb.
+func (s *Server) serveAirline(w http.ResponseWriter, code string) {
+	code = strings.ToUpper(code)
+	s.mu.Lock()
+	var matches []map[string]interface{}
+	for _, a := range s.airlines {
+		if strings.EqualFold(a.ICAO, code) || strings.EqualFold(a.IATA, code) {
+			matches = append(matches, airlineJSON(a))
+		}
+	}
+	s.mu.Unlock()
+	if len(matches) == 0 {
+		writeJSON(w, http.StatusNotFound, map[string]string{"response": "unknown airline"})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"response": matches})
+}
+
+// serveConversion answers /v0/mode-s/{n-number} (toModeS) and /v0/n-number/{mode-s}.
+func (s *Server) serveConversion(w http.ResponseWriter, id string, toModeS bool) {
+	id = strings.ToUpper(id)
+	s.mu.Lock()
+	var result string
+	for nNumber, modeS := range s.nNumbers {
+		if toModeS && nNumber == id {
+			result = modeS
+		} else if !toModeS && modeS == id {
+			result = nNumber
+		}
+	}
+	s.mu.Unlock()
+	if result == "" {
+		writeJSON(w, http.StatusNotFound, map[string]string{"response": "unknown aircraft"})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"response": result})
+}
+
+func aircraftJSON(a Aircraft) map[string]interface{} {
+	return map[string]interface{}{
+		"type":                                a.Type,
+		"icao_type":                           a.ICAOType,
+		"manufacturer":                        a.Manufacturer,
+		"mode_s":                              strings.ToUpper(a.ModeS),
+		"registration":                        a.Registration,
+		"registered_owner_country_iso_name":   "",
+		"registered_owner_country_name":       a.OwnerCountry,
+		"registered_owner_operator_flag_code": nil,
+		"registered_owner":                    a.Owner,
+		"url_photo":                           nil,
+		"url_photo_thumbnail":                 nil,
+	}
+}
+
+// routeJSON must be called with s.mu held.
+func (s *Server) routeJSON(r Route) map[string]interface{} {
+	out := map[string]interface{}{
+		"callsign":      r.Callsign,
+		"callsign_icao": r.Callsign,
+		"callsign_iata": nil,
+		"airline":       nil,
+		"origin":        airportJSON(r.Origin),
+		"destination":   airportJSON(r.Destination),
+	}
+	if len(r.Callsign) > 3 {
+		if a, ok := s.airlines[strings.ToUpper(r.Callsign[:3])]; ok {
+			out["airline"] = airlineJSON(a)
+		}
+	}
+	return out
+}
+
+func airlineJSON(a Airline) map[string]interface{} {
+	null := func(v string) interface{} {
+		if v == "" {
+			return nil
+		}
+		return v
+	}
+	return map[string]interface{}{
+		"name":        a.Name,
+		"icao":        a.ICAO,
+		"iata":        null(a.IATA),
+		"country":     a.Country,
+		"country_iso": a.CountryISO,
+		"callsign":    null(a.Callsign),
+	}
+}
+
+func airportJSON(a Airport) map[string]interface{} {
+	return map[string]interface{}{
+		"country_iso_name": a.CountryISO,
+		"country_name":     a.CountryName,
+		"elevation":        a.Elevation,
+		"iata_code":        a.IATA,
+		"icao_code":        a.ICAO,
+		"latitude":         a.Latitude,
+		"longitude":        a.Longitude,
+		"municipality":     a.Municipality,
+		"name":             a.Name,
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
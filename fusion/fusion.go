@@ -0,0 +1,363 @@
+// Package fusion merges state vectors for the same aircraft from several
+// position sources (OpenSky, a local SBS feed, a tar1090 receiver) into one
+// per ICAO24. Each group of fields is taken from the source that reported it
+// most recently, with ties going to the source listed first, and the source
+// that supplied each group is recorded. Per-source coverage statistics are
+// kept across cycles.
+package fusion
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"AirTraffic-Monitor/geo"
+	"AirTraffic-Monitor/opensky"
+)
+
+// Source names.
+const (
+	SourceOpenSky = "opensky"
+	SourceSBS     = "sbs"
+	SourceTar1090 = "tar1090"
+)
+
+// DefaultPriority prefers the local receivers, which are fresher than OpenSky.
+var DefaultPriority = []string{SourceSBS, SourceTar1090, SourceOpenSky}
+
+// Field groups tracked for provenance.
+const (
+	FieldPosition     = "position"
+	FieldAltitude     = "altitude"
+	FieldGeoAltitude  = "geo_altitude"
+	FieldVelocity     = "velocity" // ground speed and track
+	FieldVerticalRate = "vertical_rate"
+	FieldCallsign     = "callsign"
+	FieldSquawk       = "squawk"
+	FieldCategory     = "category"
+)
+
+// Reports within tieWindow of each other count as equally fresh.
+const tieWindow = 2 * time.Second
+
+// Source is anything that returns the aircraft inside a box.
+type Source interface {
+	States(ctx context.Context, bbox geo.BBox) ([]opensky.State, error)
+}
+
+// Fix is one earlier position of an aircraft.
+type Fix struct {
+	At       time.Time
+	Position geo.LatLon
+	AltM     *float64
+	Track    *float64
+}
+
+// TrailSource is a Source that also keeps each aircraft's recent positions,
+// as a local receiver hearing every message can.
+type TrailSource interface {
+	Trail(icao24 string) []Fix // oldest first
+}
+
+// Feed is a named source.
+type Feed struct {
+	Name   string
+	Source Source
+}
+
+// Aircraft is one merged aircraft.
+type Aircraft struct {
+	opensky.State
+	Sources    []string                 // every source that reported it, in priority order
+	Provenance map[string]string        // field group to the source it came from
+	Reports    map[string]opensky.State // each source's report as received
+}
+
+// SourceStats describes one source's contribution.
+type SourceStats struct {
+	Source      string         `json:"source"`
+	Priority    int            `json:"priority"` // 1 is preferred
+	OK          bool           `json:"ok"`       // the last fetch succeeded
+	Error       string         `json:"error,omitempty"`
+	LatencyMs   int64          `json:"latency_ms"`
+	LastSuccess *time.Time     `json:"last_success,omitempty"`
+	Aircraft    int            `json:"aircraft"`  // reported in the last cycle
+	Positions   int            `json:"positions"` // of which with a position
+	Exclusive   int            `json:"exclusive"` // seen by no other source
+	Coverage    float64        `json:"coverage"`  // share of all merged aircraft, 0-1
+	Fields      map[string]int `json:"fields"`    // field groups this source supplied, by group
+	Cycles      int            `json:"cycles"`
+	Failures    int            `json:"failures"`
+}
+
+// Fuser fetches from every feed and merges the results. It keeps the running
+// statistics and is safe for concurrent use.
+type Fuser struct {
+	mu    sync.Mutex
+	stats map[string]*SourceStats
+	order []string
+}
+
+// New returns a fuser with no history.
+func New() *Fuser {
+	return &Fuser{stats: make(map[string]*SourceStats)}
+}
+
+// batch is one source's answer.
+type batch struct {
+	feed    Feed
+	states  []opensky.State
+	err     error
+	latency time.Duration
+	at      time.Time
+}
+
+// Fetch asks every feed concurrently, in priority order, and merges the
+// results. now stands in for the time of reports that carry none. It fails
+// only when every feed failed, returning the errors by source.
+func (f *Fuser) Fetch(ctx context.Context, feeds []Feed, bbox geo.BBox, now time.Time) ([]Aircraft, map[string]error, error) {
+	batches := make([]batch, len(feeds))
+	var wg sync.WaitGroup
+	for i, feed := range feeds {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			started := time.Now()
+			states, err := feed.Source.States(ctx, bbox)
+			batches[i] = batch{feed: feed, states: states, err: err, latency: time.Since(started), at: now}
+		}()
+	}
+	wg.Wait()
+
+	failed := make(map[string]error)
+	var errs []error
+	for _, b := range batches {
+		if b.err != nil {
+			failed[b.feed.Name] = b.err
+			errs = append(errs, b.err)
+		}
+	}
+	merged := merge(batches)
+	f.record(batches, merged)
+	if len(failed) == len(feeds) {
+		return nil, failed, errors.Join(errs...)
+	}
+	return merged, failed, nil
+}
+
+// merge combines the batches, which are in priority order.
+func merge(batches []batch) []Aircraft {
+	type report struct {
+		source string
+		state  opensky.State
+		at     time.Time // fetch time, for sources that send no timestamps
+	}
+	byICAO := make(map[string][]report)
+	var order []string
+	for _, b := range batches {
+		if b.err != nil {
+			continue
+		}
+		seen := make(map[string]bool)
+		for _, st := range b.states {
+			icao := strings.ToUpper(st.ICAO24)
+			if icao == "" || seen[icao] {
+				continue
+			}
+			seen[icao] = true
+			if _, ok := byICAO[icao]; !ok {
+				order = append(order, icao)
+			}
+			byICAO[icao] = append(byICAO[icao], report{source: b.feed.Name, state: st, at: b.at})
+		}
+	}
+
+	out := make([]Aircraft, 0, len(order))
+	for _, icao := range order {
+		reports := byICAO[icao]
+		a := Aircraft{Provenance: make(map[string]string), Reports: make(map[string]opensky.State, len(reports))}
+		for _, r := range reports {
+			a.Sources = append(a.Sources, r.source)
+			a.Reports[r.source] = r.state
+		}
+		contact := func(r report) time.Time {
+			if r.state.LastContact != 0 {
+				return time.Unix(r.state.LastContact, 0)
+			}
+			return r.at
+		}
+		positioned := func(r report) time.Time {
+			if r.state.TimePosition != 0 {
+				return time.Unix(r.state.TimePosition, 0)
+			}
+			return contact(r)
+		}
+		// pick returns the freshest report that has the field, preferring
+		// higher priority within tieWindow.
+		pick := func(group string, has func(opensky.State) bool, when func(report) time.Time) (report, bool) {
+			var best report
+			found := false
+			for _, r := range reports {
+				if !has(r.state) {
+					continue
+				}
+				if !found || when(r).Sub(when(best)) > tieWindow {
+					best, found = r, true
+				}
+			}
+			if found {
+				a.Provenance[group] = best.source
+			}
+			return best, found
+		}
+
+		// The freshest contact supplies the identity and anything not tracked separately.
+		base, _ := pick("", func(opensky.State) bool { return true }, contact)
+		delete(a.Provenance, "")
+		a.State = base.state
+		a.ICAO24 = icao
+		a.Latitude, a.Longitude, a.TimePosition = nil, nil, 0
+		a.BaroAltitude, a.GeoAltitude, a.Velocity, a.TrueTrack, a.VerticalRate = nil, nil, nil, nil, nil
+		a.Callsign, a.Squawk, a.Category = "", "", 0
+		for _, r := range reports {
+			a.LastContact = max(a.LastContact, contact(r).Unix())
+			if a.OriginCountry == "" {
+				a.OriginCountry = r.state.OriginCountry
+			}
+		}
+
+		if r, ok := pick(FieldPosition, func(s opensky.State) bool { return s.Latitude != nil && s.Longitude != nil }, positioned); ok {
+			a.Latitude, a.Longitude, a.TimePosition = r.state.Latitude, r.state.Longitude, positioned(r).Unix()
+		}
+		if r, ok := pick(FieldAltitude, func(s opensky.State) bool { return s.BaroAltitude != nil || s.OnGround }, contact); ok {
+			a.BaroAltitude, a.OnGround = r.state.BaroAltitude, r.state.OnGround
+		}
+		if r, ok := pick(FieldGeoAltitude, func(s opensky.State) bool { return s.GeoAltitude != nil }, contact); ok {
+			a.GeoAltitude = r.state.GeoAltitude
+		}
+		if r, ok := pick(FieldVelocity, func(s opensky.State) bool { return s.Velocity != nil && s.TrueTrack != nil }, contact); ok {
+			a.Velocity, a.TrueTrack = r.state.Velocity, r.state.TrueTrack
+		}
+		if r, ok := pick(FieldVerticalRate, func(s opensky.State) bool { return s.VerticalRate != nil }, contact); ok {
+			a.VerticalRate = r.state.VerticalRate
+		}
+		if r, ok := pick(FieldCallsign, func(s opensky.State) bool { return s.Callsign != "" }, contact); ok {
+			a.Callsign = r.state.Callsign
+		}
+		if r, ok := pick(FieldSquawk, func(s opensky.State) bool { return s.Squawk != "" }, contact); ok {
+			a.Squawk = r.state.Squawk
+		}
+		if r, ok := pick(FieldCategory, func(s opensky.State) bool { return s.Category != 0 }, contact); ok {
+			a.Category = r.state.Category
+		}
+		out = append(out, a)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ICAO24 < out[j].ICAO24 })
+	return out
+}
+
+// record updates the statistics after a fetch.
+func (f *Fuser) record(batches []batch, merged []Aircraft) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.order = f.order[:0]
+	for rank, b := range batches {
+		name := b.feed.Name
+		f.order = append(f.order, name)
+		st := f.stats[name]
+		if st == nil {
+			st = &SourceStats{Source: name}
+			f.stats[name] = st
+		}
+		st.Priority = rank + 1
+		st.Cycles++
+		st.LatencyMs = b.latency.Milliseconds()
+		st.OK, st.Error = b.err == nil, ""
+		st.Aircraft, st.Positions, st.Exclusive, st.Coverage = 0, 0, 0, 0
+		st.Fields = make(map[string]int)
+		if b.err != nil {
+			st.Failures++
+			st.Error = b.err.Error()
+			continue
+		}
+		at := b.at
+		st.LastSuccess = &at
+		st.Aircraft = len(b.states)
+		for _, s := range b.states {
+			if s.Latitude != nil && s.Longitude != nil {
+				st.Positions++
+			}
+		}
+	}
+	for _, a := range merged {
+		if len(a.Sources) == 1 {
+			f.stats[a.Sources[0]].Exclusive++
+		}
+		for group, source := range a.Provenance {
+			f.stats[source].Fields[group]++
+		}
+	}
+	if len(merged) > 0 {
+		for _, name := range f.order {
+			st := f.stats[name]
+			seen := 0
+			for _, a := range merged {
+				for _, s := range a.Sources {
+					if s == name {
+						seen++
+						break
+					}
+				}
+			}
+			st.Coverage = float64(seen) / float64(len(merged))
+		}
+	}
+}
+
+// Stats returns a copy of each source's statistics, in priority order.
+func (f *Fuser) Stats() []SourceStats {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	out := make([]SourceStats, 0, len(f.order))
+	for _, name := range f.order {
+		st := *f.stats[name]
+		st.Fields = make(map[string]int, len(f.stats[name].Fields))
+		for k, v := range f.stats[name].Fields {
+			st.Fields[k] = v
+		}
+		out = append(out, st)
+	}
+	return out
+}
+
+// Trail returns the longest position history any of feeds keeps for icao24.
+func Trail(feeds []Feed, icao24 string) []Fix {
+	var best []Fix
+	for _, f := range feeds {
+		if ts, ok := f.Source.(TrailSource); ok {
+			if trail := ts.Trail(icao24); len(trail) > len(best) {
+				best = trail
+			}
+		}
+	}
+	return best
+}
+
+// Order sorts feeds by priority, a list of source names. Feeds not named keep
+// their relative order after the named ones.
+func Order(feeds []Feed, priority []string) []Feed {
+	rank := func(name string) int {
+		for i, p := range priority {
+			if p == name {
+				return i
+			}
+		}
+		return len(priority)
+	}
+	out := append([]Feed(nil), feeds...)
+	sort.SliceStable(out, func(i, j int) bool { return rank(out[i].Name) < rank(out[j].Name) })
+	return out
+}
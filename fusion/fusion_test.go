@@ -0,0 +1,188 @@
+package fusion
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"AirTraffic-Monitor/geo"
+	"AirTraffic-Monitor/opensky"
+)
+
+var now = time.Date(2026, 10, 18, 10, 0, 0, 0, time.UTC)
+
+func ptr(v float64) *float64 { return &v }
+
+// unix returns now shifted by seconds, as an OpenSky timestamp.
+func unix(seconds int) int64 { return now.Add(time.Duration(seconds) * time.Second).Unix() }
+
+// stub is a Source returning fixed states or an error.
+type stub struct {
+	states []opensky.State
+	err    error
+}
+
+func (s stub) States(context.Context, geo.BBox) ([]opensky.State, error) {
+	return s.states, s.err
+}
+
+func TestMerge(t *testing.T) {
+	tests := []struct {
+		name       string
+		batches    []batch
+		want       opensky.State
+		provenance map[string]string
+		sources    []string
+	}{
+		{
+			name: "fresher local position beats OpenSky, OpenSky fills the gaps",
+			batches: []batch{
+				{feed: Feed{Name: SourceSBS}, at: now, states: []opensky.State{{ICAO24: "4ca123",
+					LastContact: unix(0), TimePosition: unix(0), Latitude: ptr(51.51), Longitude: ptr(-0.11), BaroAltitude: ptr(3000)}}},
+				{feed: Feed{Name: SourceOpenSky}, at: now, states: []opensky.State{{ICAO24: "4CA123", Callsign: "RYR1AB",
+					OriginCountry: "Ireland", LastContact: unix(-20), TimePosition: unix(-20), Latitude: ptr(51.50), Longitude: ptr(-0.10),
+					BaroAltitude: ptr(2900), Velocity: ptr(150), TrueTrack: ptr(90), Squawk: "4521", Category: 3}}},
+			},
+			want: opensky.State{ICAO24: "4CA123", Callsign: "RYR1AB", OriginCountry: "Ireland", TimePosition: unix(0), LastContact: unix(0),
+				Latitude: ptr(51.51), Longitude: ptr(-0.11), BaroAltitude: ptr(3000), Velocity: ptr(150), TrueTrack: ptr(90),
+				Squawk: "4521", Category: 3},
+			provenance: map[string]string{FieldPosition: SourceSBS, FieldAltitude: SourceSBS, FieldVelocity: SourceOpenSky,
+				FieldCallsign: SourceOpenSky, FieldSquawk: SourceOpenSky, FieldCategory: SourceOpenSky},
+			sources: []string{SourceSBS, SourceOpenSky},
+		},
+		{
+			name: "within the tie window the higher priority source wins",
+			batches: []batch{
+				{feed: Feed{Name: SourceTar1090}, at: now, states: []opensky.State{{ICAO24: "400A0B", Callsign: "BAW12",
+					LastContact: unix(-1), Latitude: ptr(51.47), Longitude: ptr(-0.45)}}},
+				{feed: Feed{Name: SourceOpenSky}, at: now, states: []opensky.State{{ICAO24: "400a0b", Callsign: "BAW12X",
+					LastContact: unix(0), Latitude: ptr(51.48), Longitude: ptr(-0.46)}}},
+			},
+			want: opensky.State{ICAO24: "400A0B", Callsign: "BAW12", TimePosition: unix(-1), LastContact: unix(0),
+				Latitude: ptr(51.47), Longitude: ptr(-0.45)},
+			provenance: map[string]string{FieldPosition: SourceTar1090, FieldCallsign: SourceTar1090},
+			sources:    []string{SourceTar1090, SourceOpenSky},
+		},
+		{
+			name: "a stale higher priority report loses to a fresh one",
+			batches: []batch{
+				{feed: Feed{Name: SourceSBS}, at: now, states: []opensky.State{{ICAO24: "400A0B", Callsign: "BAW12",
+					LastContact: unix(-30), TimePosition: unix(-30), Latitude: ptr(51.47), Longitude: ptr(-0.45)}}},
+				{feed: Feed{Name: SourceOpenSky}, at: now, states: []opensky.State{{ICAO24: "400A0B",
+					LastContact: unix(0), TimePosition: unix(-5), Latitude: ptr(51.48), Longitude: ptr(-0.46), OnGround: true}}},
+			},
+			want: opensky.State{ICAO24: "400A0B", Callsign: "BAW12", TimePosition: unix(-5), LastContact: unix(0),
+				Latitude: ptr(51.48), Longitude: ptr(-0.46), OnGround: true},
+			provenance: map[string]string{FieldPosition: SourceOpenSky, FieldAltitude: SourceOpenSky, FieldCallsign: SourceSBS},
+			sources:    []string{SourceSBS, SourceOpenSky},
+		},
+		{
+			name: "no timestamps: the fetch time stands in and a failed source is skipped",
+			batches: []batch{
+				{feed: Feed{Name: SourceSBS}, err: errors.New("refused")},
+				{feed: Feed{Name: SourceTar1090}, at: now, states: []opensky.State{{ICAO24: "4CA123", Squawk: "7000",
+					Latitude: ptr(51.5), Longitude: ptr(-0.1)}}},
+			},
+			want: opensky.State{ICAO24: "4CA123", Squawk: "7000", TimePosition: now.Unix(), LastContact: now.Unix(),
+				Latitude: ptr(51.5), Longitude: ptr(-0.1)},
+			provenance: map[string]string{FieldPosition: SourceTar1090, FieldSquawk: SourceTar1090},
+			sources:    []string{SourceTar1090},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			merged := merge(tt.batches)
+			if len(merged) != 1 {
+				t.Fatalf("got %d aircraft, want 1", len(merged))
+			}
+			a := merged[0]
+			if !reflect.DeepEqual(a.State, tt.want) {
+				t.Errorf("state\n got %s\nwant %s", describe(a.State), describe(tt.want))
+			}
+			if !reflect.DeepEqual(a.Provenance, tt.provenance) {
+				t.Errorf("provenance = %v, want %v", a.Provenance, tt.provenance)
+			}
+			if !reflect.DeepEqual(a.Sources, tt.sources) {
+				t.Errorf("sources = %v, want %v", a.Sources, tt.sources)
+			}
+			if len(a.Reports) != len(tt.sources) {
+				t.Errorf("kept %d raw reports, want %d", len(a.Reports), len(tt.sources))
+			}
+		})
+	}
+}
+
+// describe prints a state with its pointers dereferenced.
+func describe(s opensky.State) string {
+	v := func(p *float64) any {
+		if p == nil {
+			return nil
+		}
+		return *p
+	}
+	return fmt.Sprint(s.ICAO24, s.Callsign, s.OriginCountry, s.TimePosition, s.LastContact, v(s.Latitude), v(s.Longitude),
+		v(s.BaroAltitude), s.OnGround, v(s.Velocity), v(s.TrueTrack), s.Squawk, s.Category)
+}
+
+func TestFetchAndStats(t *testing.T) {
+	sky := stub{states: []opensky.State{
+		{ICAO24: "4CA123", LastContact: unix(-10), Latitude: ptr(51.5), Longitude: ptr(-0.1)},
+		{ICAO24: "400A0B", LastContact: unix(-10)},
+	}}
+	sbs := stub{states: []opensky.State{{ICAO24: "4CA123", LastContact: unix(0), Latitude: ptr(51.51), Longitude: ptr(-0.11)}}}
+	down := stub{err: errors.New("connection refused")}
+	feeds := Order([]Feed{{Name: SourceOpenSky, Source: sky}, {Name: SourceTar1090, Source: down}, {Name: SourceSBS, Source: sbs}},
+		DefaultPriority)
+
+	f := New()
+	merged, failed, err := f.Fetch(context.Background(), feeds, geo.BBox{}, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(merged) != 2 || len(failed) != 1 || failed[SourceTar1090] == nil {
+		t.Fatalf("merged %d, failed %v", len(merged), failed)
+	}
+
+	stats := f.Stats()
+	if len(stats) != 3 || stats[0].Source != SourceSBS || stats[1].Source != SourceTar1090 || stats[2].Source != SourceOpenSky {
+		t.Fatalf("stats order = %+v", stats)
+	}
+	sbsStats, tarStats, skyStats := stats[0], stats[1], stats[2]
+	if !sbsStats.OK || sbsStats.Aircraft != 1 || sbsStats.Exclusive != 0 || sbsStats.Coverage != 0.5 || sbsStats.Fields[FieldPosition] != 1 {
+		t.Errorf("sbs stats = %+v", sbsStats)
+	}
+	if tarStats.OK || tarStats.Failures != 1 || tarStats.Error == "" {
+		t.Errorf("tar1090 stats = %+v", tarStats)
+	}
+	if skyStats.Aircraft != 2 || skyStats.Positions != 1 || skyStats.Exclusive != 1 || skyStats.Coverage != 1 {
+		t.Errorf("opensky stats = %+v", skyStats)
+	}
+
+	_, _, err = f.Fetch(context.Background(), []Feed{{Name: SourceTar1090, Source: down}}, geo.BBox{}, now)
+	if err == nil {
+		t.Error("Fetch succeeded with every source down")
+	}
+}
+
+// trailStub is a Source that keeps a trail.
+type trailStub struct {
+	stub
+	trail []Fix
+}
+
+func (s trailStub) Trail(string) []Fix { return s.trail }
+
+func TestTrail(t *testing.T) {
+	short := trailStub{trail: []Fix{{At: now}}}
+	long := trailStub{trail: []Fix{{At: now}, {At: now.Add(5 * time.Second)}}}
+	feeds := []Feed{{Name: SourceOpenSky, Source: stub{}}, {Name: SourceSBS, Source: short}, {Name: "other", Source: long}}
+	if got := Trail(feeds, "4CA123"); len(got) != 2 {
+		t.Errorf("Trail() returned %d fixes, want the longest (2)", len(got))
+	}
+	if got := Trail(feeds[:1], "4CA123"); got != nil {
+		t.Errorf("Trail() without a trail source = %v", got)
+	}
+}
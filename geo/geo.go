@@ -0,0 +1,96 @@
+// Package geo holds the small amount of spherical geometry the monitor needs.
+package geo
+
+import "math"
+
+const (
+	EarthRadiusKm = 6371.0
+	MetresToFeet  = 3.28084
+)
+
+// LatLon is a point in decimal degrees.
+type LatLon struct {
+	Lat float64 `json:"lat"`
+	Lon float64 `json:"lon"`
+}
+
+// BBox is a latitude/longitude bounding box in decimal degrees.
+type BBox struct {
+	LaMin float64 `json:"lamin"`
+	LoMin float64 `json:"lomin"`
+	LaMax float64 `json:"lamax"`
+	LoMax float64 `json:"lomax"`
+}
+
+// Contains reports whether p lies inside the box.
+func (b BBox) Contains(p LatLon) bool {
+	return p.Lat >= b.LaMin && p.Lat <= b.LaMax && p.Lon >= b.LoMin && p.Lon <= b.LoMax
+}
+
+// ContainsRadius reports whether the whole circle of radiusKm around c lies
+// inside the box, checked at its northern, eastern, southern and western edges.
+func (b BBox) ContainsRadius(c LatLon, radiusKm float64) bool {
+	for _, bearing := range []float64{0, 90, 180, 270} {
+		if !b.Contains(Destination(c, bearing, radiusKm)) {
+			return false
+		}
+	}
+	return true
+}
+
+// Centre returns the midpoint of the box.
+func (b BBox) Centre() LatLon {
+	return LatLon{Lat: (b.LaMin + b.LaMax) / 2, Lon: (b.LoMin + b.LoMax) / 2}
+}
+
+// HaversineKm returns the great-circle distance between two points.
+func HaversineKm(a, b LatLon) float64 {
+	lat1, lat2 := a.Lat*math.Pi/180, b.Lat*math.Pi/180
+	dLat := lat2 - lat1
+	dLon := (b.Lon - a.Lon) * math.Pi / 180
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * EarthRadiusKm * math.Asin(math.Sqrt(h))
+}
+
+// DistanceFrom returns the distance from home to an optional position, or nil if unknown.
+func DistanceFrom(home LatLon, lat, lon *float64) *float64 {
+	if lat == nil || lon == nil {
+		return nil
+	}
+	d := HaversineKm(home, LatLon{Lat: *lat, Lon: *lon})
+	return &d
+}
+
+// BearingDeg returns the initial great-circle bearing from a to b, 0-360° clockwise from north.
+func BearingDeg(a, b LatLon) float64 {
+	lat1, lat2 := a.Lat*math.Pi/180, b.Lat*math.Pi/180
+	dLon := (b.Lon - a.Lon) * math.Pi / 180
+	y := math.Sin(dLon) * math.Cos(lat2)
+	x := math.Cos(lat1)*math.Sin(lat2) - math.Sin(lat1)*math.Cos(lat2)*math.Cos(dLon)
+	return math.Mod(math.Atan2(y, x)*180/math.Pi+360, 360)
+}
+
+// CrossTrackKm returns how far p lies from the great circle through a and b.
+func CrossTrackKm(a, b, p LatLon) float64 {
+	d13 := HaversineKm(a, p) / EarthRadiusKm
+	t13, t12 := BearingDeg(a, p)*math.Pi/180, BearingDeg(a, b)*math.Pi/180
+	return math.Abs(math.Asin(math.Sin(d13)*math.Sin(t13-t12))) * EarthRadiusKm
+}
+
+// AngleDiff returns the absolute difference between two headings, 0-180°.
+func AngleDiff(a, b float64) float64 {
+	d := math.Mod(math.Abs(a-b), 360)
+	if d > 180 {
+		d = 360 - d
+	}
+	return d
+}
+
+// Destination returns the point km from p along the great circle starting on bearing deg.
+func Destination(p LatLon, bearingDeg, km float64) LatLon {
+	lat1, lon1 := p.Lat*math.Pi/180, p.Lon*math.Pi/180
+	brg, d := bearingDeg*math.Pi/180, km/EarthRadiusKm
+	lat2 := math.Asin(math.Sin(lat1)*math.Cos(d) + math.Cos(lat1)*math.Sin(d)*math.Cos(brg))
+	lon2 := lon1 + math.Atan2(math.Sin(brg)*math.Sin(d)*math.Cos(lat1), math.Cos(d)-math.Sin(lat1)*math.Sin(lat2))
+	return LatLon{Lat: lat2 * 180 / math.Pi, Lon: math.Mod(lon2*180/math.Pi+540, 360) - 180}
+}
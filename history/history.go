@@ -0,0 +1,135 @@
+// Package history keeps every cycle's aircraft positions on disk so that a day
+// can be replayed later (see package replay).
+//
+// Each cycle is appended as one OpenSky states/all response per line to a
+// daily file, named by UTC date:
+//
+//	<dir>/positions-2024-06-30.json
+//
+// A directory or file of these is an OpenSky archive to the replay package.
+// Positions are stored as reported, before track smoothing, so a replay runs
+// them through the track filter again; altitude, speed and track are stored as
+// smoothed. Files older than MaxAge are deleted when a new day's file is
+// started.
+package history
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"AirTraffic-Monitor/monitor"
+	"AirTraffic-Monitor/opensky"
+)
+
+// prefix and suffix frame the date in a history file name.
+const (
+	prefix = "positions-"
+	suffix = ".json"
+)
+
+// Store appends cycles to the history under Dir. It is a monitor.Publisher and
+// is safe for concurrent use.
+type Store struct {
+	Dir    string
+	MaxAge time.Duration // delete daily files older than this; 0 keeps them
+
+	mu  sync.Mutex
+	day string // the file last written, "2006-01-02"
+}
+
+// New returns a store writing under dir, creating it if needed.
+func New(dir string, maxAge time.Duration) (*Store, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &Store{Dir: dir, MaxAge: maxAge}, nil
+}
+
+// Publish appends the cycle's positions to the day's file.
+func (s *Store) Publish(ctx context.Context, c monitor.Cycle) error {
+	res := opensky.StatesResponse{Time: c.Time.Unix(), States: make([][]interface{}, 0, len(c.Aircraft))}
+	for _, a := range c.Aircraft {
+		res.States = append(res.States, opensky.StateRow(State(a, c.Time)))
+	}
+	line, err := json.Marshal(res)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	day := c.Time.UTC().Format(time.DateOnly)
+	if day != s.day {
+		s.day = day
+		if err := s.pruneLocked(c.Time); err != nil {
+			return err
+		}
+	}
+	f, err := os.OpenFile(filepath.Join(s.Dir, prefix+day+suffix), os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return fmt.Errorf("write history: %w", err)
+	}
+	return f.Close()
+}
+
+// pruneLocked deletes the daily files older than MaxAge at now.
+func (s *Store) pruneLocked(now time.Time) error {
+	if s.MaxAge <= 0 {
+		return nil
+	}
+	entries, err := os.ReadDir(s.Dir)
+	if err != nil {
+		return err
+	}
+	oldest := now.Add(-s.MaxAge).UTC().Format(time.DateOnly)
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, suffix) {
+			continue
+		}
+		// A day's file is kept while any of the day is within MaxAge.
+		if day := strings.TrimSuffix(strings.TrimPrefix(name, prefix), suffix); day < oldest {
+			if err := os.Remove(filepath.Join(s.Dir, name)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// State returns the state vector a cycle's aircraft was reported with at,
+// taking the position as reported rather than as smoothed.
+func State(a monitor.AircraftInfo, at time.Time) opensky.State {
+	st := opensky.State{
+		ICAO24:       a.ICAO24,
+		Callsign:     a.Callsign,
+		LastContact:  at.Unix(),
+		Latitude:     a.Latitude,
+		Longitude:    a.Longitude,
+		BaroAltitude: a.BaroAltitude,
+		OnGround:     a.OnGround,
+		Velocity:     a.Velocity,
+		TrueTrack:    a.Track,
+		VerticalRate: a.VerticalRate,
+		Squawk:       a.Squawk,
+		Category:     a.Category,
+	}
+	if a.RawPosition != nil {
+		lat, lon := a.RawPosition.Lat, a.RawPosition.Lon
+		st.Latitude, st.Longitude = &lat, &lon
+	}
+	if a.PositionTime != nil {
+		st.TimePosition = a.PositionTime.Unix()
+	}
+	return st
+}
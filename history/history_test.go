@@ -0,0 +1,98 @@
+package history
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"AirTraffic-Monitor/geo"
+	"AirTraffic-Monitor/monitor"
+	"AirTraffic-Monitor/replay"
+)
+
+func ptr(v float64) *float64 { return &v }
+
+func TestReplaysAsOpenSkyArchive(t *testing.T) {
+	dir := t.TempDir()
+	s, err := New(dir, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t0 := time.Date(2026, 10, 18, 23, 55, 0, 0, time.UTC)
+	measured := t0.Add(-4 * time.Second)
+	cycles := []monitor.Cycle{
+		{Time: t0, Aircraft: []monitor.AircraftInfo{{
+			ICAO24: "400A0B", Callsign: "BAW12", PositionTime: &measured,
+			Latitude: ptr(51.4701), Longitude: ptr(-0.4501), RawPosition: &geo.LatLon{Lat: 51.47, Lon: -0.45},
+			BaroAltitude: ptr(900), Velocity: ptr(80), Track: ptr(270), Squawk: "4521", Category: 3,
+		}}},
+		{Time: t0.Add(5 * time.Minute), Aircraft: []monitor.AircraftInfo{{ICAO24: "4CA123", OnGround: true}}},
+	}
+	for _, c := range cycles {
+		if err := s.Publish(context.Background(), c); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, day := range []string{"2026-10-18", "2026-10-19"} {
+		if _, err := os.Stat(filepath.Join(dir, "positions-"+day+".json")); err != nil {
+			t.Errorf("no file for %s: %v", day, err)
+		}
+	}
+
+	var now time.Time
+	a, err := replay.OpenSkyArchive(dir, func() time.Time { return now })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !a.Start().Equal(t0) {
+		t.Errorf("archive starts at %v, want %v", a.Start(), t0)
+	}
+
+	now = t0.Add(time.Minute)
+	states, err := a.States(context.Background(), geo.BBox{LaMin: 50, LoMin: -2, LaMax: 53, LoMax: 2})
+	if err != nil || len(states) != 1 {
+		t.Fatalf("got %d states, err %v", len(states), err)
+	}
+	st := states[0]
+	if st.ICAO24 != "400A0B" || st.Callsign != "BAW12" || *st.Latitude != 51.47 || *st.Longitude != -0.45 {
+		t.Errorf("state %s %q at %v,%v, want BAW12 at the reported 51.47,-0.45", st.ICAO24, st.Callsign, *st.Latitude, *st.Longitude)
+	}
+	if st.TimePosition != measured.Unix() || st.LastContact != t0.Unix() || *st.BaroAltitude != 900 ||
+		*st.Velocity != 80 || *st.TrueTrack != 270 || st.Squawk != "4521" || st.Category != 3 {
+		t.Errorf("state = %+v", st)
+	}
+
+	// The second cycle has no position, so it is outside any bbox.
+	now = t0.Add(6 * time.Minute)
+	if states, err := a.States(context.Background(), geo.BBox{LaMin: 50, LoMin: -2, LaMax: 53, LoMax: 2}); err != nil || len(states) != 0 {
+		t.Errorf("got %d states, err %v", len(states), err)
+	}
+}
+
+func TestPrunesOldDays(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"positions-2026-09-17.json", "positions-2026-09-18.json", "notes.json"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("{}\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s, err := New(dir, 30*24*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Publish(context.Background(), monitor.Cycle{Time: time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)}); err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range map[string]bool{
+		"positions-2026-09-17.json": false,
+		"positions-2026-09-18.json": true,
+		"positions-2026-10-18.json": true,
+		"notes.json":                true,
+	} {
+		if _, err := os.Stat(filepath.Join(dir, name)); (err == nil) != want {
+			t.Errorf("%s kept = %v, want %v", name, err == nil, want)
+		}
+	}
+}
@@ -0,0 +1,371 @@
+// Package holding detects aircraft orbiting published holding stacks. Each
+// cycle's positions are matched to the nearest stack fix. Where the track is
+// sampled densely enough to follow the turns, e.g. from a local receiver, an
+// aircraft is holding once it has flown a racetrack there: two reversals of
+// heading in the same direction. Otherwise an aircraft that stays in the stack
+// long enough is taken to be holding, with low confidence. The tracker counts
+// laps and time in the hold and reports stack occupancy by flight level.
+package holding
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"AirTraffic-Monitor/geo"
+)
+
+const (
+	// lapTime is one standard racetrack: two one-minute legs and two rate-one turns.
+	lapTime = 4 * time.Minute
+	// A racetrack lap is minReversals turns of at least reversalDeg, all the
+	// same way, each completed within reversalTime.
+	minReversals = 2
+	reversalDeg  = 150.0
+	reversalTime = 3 * time.Minute
+	// With samples further apart than this the turns cannot be followed; an
+	// aircraft in a stack for minDwell is then taken to be holding and laps are
+	// estimated from the time in the hold.
+	turnSampleGap = 90 * time.Second
+	minDwell      = 6 * time.Minute
+)
+
+// How sure the tracker is that an aircraft is holding.
+const (
+	ConfidenceHigh = "high" // a racetrack was seen in the track
+	ConfidenceLow  = "low"  // inferred from time in the stack
+)
+
+// Stack is a published holding fix.
+type Stack struct {
+	Name     string   `json:"name"`      // e.g. "Bovingdon"
+	Ident    string   `json:"ident"`     // e.g. "BNN"
+	Lat      float64  `json:"lat"`       // fix position
+	Lon      float64  `json:"lon"`       //
+	RadiusKm float64  `json:"radius_km"` // how far the racetrack extends from the fix
+	MinFt    float64  `json:"min_ft"`    // lowest holding level
+	MaxFt    float64  `json:"max_ft"`    // highest holding level
+	Airports []string `json:"airports"`  // airports the stack feeds
+}
+
+// LondonStacks returns the London TMA stacks. The Luton and Stansted fixes are
+// approximate; override them in the config for anything that matters.
+func LondonStacks() []Stack {
+	return []Stack{
+		{Name: "Bovingdon", Ident: "BNN", Lat: 51.7267, Lon: -0.5483, RadiusKm: 22, MinFt: 7000, MaxFt: 16000, Airports: []string{"EGLL"}},
+		{Name: "Lambourne", Ident: "LAM", Lat: 51.6462, Lon: 0.1517, RadiusKm: 22, MinFt: 7000, MaxFt: 16000, Airports: []string{"EGLL"}},
+		{Name: "Biggin", Ident: "BIG", Lat: 51.3308, Lon: 0.0350, RadiusKm: 22, MinFt: 7000, MaxFt: 16000, Airports: []string{"EGLL"}},
+		{Name: "Ockham", Ident: "OCK", Lat: 51.3050, Lon: -0.4467, RadiusKm: 22, MinFt: 7000, MaxFt: 16000, Airports: []string{"EGLL"}},
+		{Name: "Lorel", Ident: "LOREL", Lat: 52.0133, Lon: 0.0567, RadiusKm: 22, MinFt: 7000, MaxFt: 16000, Airports: []string{"EGSS", "EGGW"}},
+		{Name: "Abbot", Ident: "ABBOT", Lat: 52.0150, Lon: 0.5983, RadiusKm: 22, MinFt: 7000, MaxFt: 16000, Airports: []string{"EGSS"}},
+	}
+}
+
+// Validate reports a stack that cannot match anything.
+func (s Stack) Validate() error {
+	switch {
+	case s.Ident == "":
+		return fmt.Errorf("holding stack %q needs an ident", s.Name)
+	case s.RadiusKm <= 0:
+		return fmt.Errorf("holding stack %s: radius_km must be positive", s.Ident)
+	case s.MaxFt <= s.MinFt:
+		return fmt.Errorf("holding stack %s: max_ft must be above min_ft", s.Ident)
+	}
+	return nil
+}
+
+// Position returns the fix.
+func (s Stack) Position() geo.LatLon {
+	return geo.LatLon{Lat: s.Lat, Lon: s.Lon}
+}
+
+// Sample is one aircraft's state in a cycle.
+type Sample struct {
+	ICAO24   string
+	Callsign string
+	Position geo.LatLon
+	AltFt    float64
+	Track    *float64
+	OnGround bool
+
+	// Trail holds earlier positions since the last cycle, oldest first, when a
+	// source heard them; it lets the turns be followed between polls.
+	Trail []TrackPoint
+}
+
+// TrackPoint is one earlier position and track of an aircraft.
+type TrackPoint struct {
+	At       time.Time
+	Position geo.LatLon
+	Track    float64
+}
+
+// Hold is an aircraft currently holding.
+type Hold struct {
+	ICAO24     string    `json:"icao24"`
+	Callsign   string    `json:"callsign,omitempty"`
+	Stack      string    `json:"stack"` // ident
+	Level      string    `json:"level"` // e.g. "FL090"
+	AltFt      float64   `json:"alt_ft"`
+	Entered    time.Time `json:"entered"`
+	Minutes    float64   `json:"minutes"`
+	Laps       int       `json:"laps"`
+	Confidence string    `json:"confidence"` // high when the racetrack was seen, low when inferred
+}
+
+// Level is one occupied level of a stack.
+type Level struct {
+	Level    string   `json:"level"`
+	Aircraft []string `json:"aircraft"` // callsign, or ICAO24 without one
+}
+
+// StackStatus is one stack's occupancy.
+type StackStatus struct {
+	Stack
+	Count      int     `json:"count"`
+	Unsure     int     `json:"unsure"`      // holds with low confidence
+	MaxMinutes float64 `json:"max_minutes"` // longest current hold
+	Levels     []Level `json:"levels"`      // lowest first
+}
+
+// Report is the holding picture after a cycle.
+type Report struct {
+	Time   time.Time     `json:"time"`
+	Stacks []StackStatus `json:"stacks"`
+	Holds  []Hold        `json:"holds"`
+}
+
+// Holding returns the number of aircraft holding across all stacks.
+func (r Report) Holding() int {
+	return len(r.Holds)
+}
+
+// stay is an aircraft inside one stack's area.
+type stay struct {
+	stack     string
+	entered   time.Time
+	lastSeen  time.Time
+	lastTrack *float64
+	sparse    bool // some samples too far apart to follow the turns
+	holding   bool
+
+	// Heading reversals seen so far, all turning turnDir (+1 right, -1 left).
+	reversals int
+	turnDir   float64
+	turning   float64   // signed degrees turned towards the next reversal
+	turnStart time.Time // when that turn started
+}
+
+// Tracker follows aircraft through the stacks. It is not safe for concurrent use.
+type Tracker struct {
+	stacks []Stack
+	stays  map[string]*stay
+}
+
+// NewTracker returns a tracker for stacks.
+func NewTracker(stacks []Stack) *Tracker {
+	return &Tracker{stacks: stacks, stays: make(map[string]*stay)}
+}
+
+// Stacks returns the stacks the tracker watches.
+func (t *Tracker) Stacks() []Stack {
+	return t.stacks
+}
+
+// Update feeds one cycle's samples and returns the holding picture. Aircraft
+// missing from samples are forgotten.
+func (t *Tracker) Update(samples []Sample, now time.Time) Report {
+	stays := make(map[string]*stay, len(t.stays))
+	report := Report{Time: now}
+	for _, s := range samples {
+		st, ok := t.stackFor(s)
+		if !ok {
+			continue
+		}
+		trail := trailInStack(st, s.Trail, now)
+		prev := t.stays[s.ICAO24]
+		if prev == nil || prev.stack != st.Ident {
+			prev = &stay{stack: st.Ident, entered: now}
+			if len(trail) > 0 {
+				prev.entered = trail[0].At
+			}
+		}
+		for _, p := range trail {
+			if p.At.After(prev.lastSeen) {
+				prev.observe(p.At, &p.Track)
+			}
+		}
+		prev.observe(now, s.Track)
+
+		dwell := now.Sub(prev.entered)
+		if !prev.holding && (prev.reversals >= minReversals || (prev.sparse && dwell >= minDwell)) {
+			prev.holding = true
+		}
+		stays[s.ICAO24] = prev
+		if prev.holding {
+			report.Holds = append(report.Holds, Hold{
+				ICAO24:     s.ICAO24,
+				Callsign:   s.Callsign,
+				Stack:      st.Ident,
+				Level:      FlightLevel(s.AltFt),
+				AltFt:      s.AltFt,
+				Entered:    prev.entered,
+				Minutes:    dwell.Minutes(),
+				Laps:       prev.laps(dwell),
+				Confidence: prev.confidence(),
+			})
+		}
+	}
+	t.stays = stays
+
+	sort.Slice(report.Holds, func(i, j int) bool {
+		a, b := report.Holds[i], report.Holds[j]
+		if a.Stack != b.Stack {
+			return a.Stack < b.Stack
+		}
+		return a.AltFt < b.AltFt
+	})
+	report.Stacks = occupancy(t.stacks, report.Holds)
+	return report
+}
+
+// trailInStack returns the trail points before now since the aircraft last
+// entered st's area.
+func trailInStack(st Stack, trail []TrackPoint, now time.Time) []TrackPoint {
+	start := 0
+	for i, p := range trail {
+		if geo.HaversineKm(st.Position(), p.Position) > st.RadiusKm {
+			start = i + 1
+		}
+	}
+	end := start
+	for end < len(trail) && trail[end].At.Before(now) {
+		end++
+	}
+	return trail[start:end]
+}
+
+// observe follows the track to at, counting a reversal whenever the aircraft
+// has turned through reversalDeg within reversalTime. A turn the other way
+// starts the count again: vectors weave, a racetrack turns one way.
+func (s *stay) observe(at time.Time, track *float64) {
+	switch {
+	case s.lastSeen.IsZero():
+		s.turnStart = at
+	case at.Sub(s.lastSeen) > turnSampleGap:
+		s.sparse = true
+		s.turning, s.turnStart = 0, at
+	case s.lastTrack != nil && track != nil:
+		if at.Sub(s.turnStart) > reversalTime {
+			s.turning, s.turnStart = 0, s.lastSeen
+		}
+		s.turning += signedTurn(*s.lastTrack, *track)
+		if math.Abs(s.turning) >= reversalDeg {
+			dir := math.Copysign(1, s.turning)
+			if dir != s.turnDir {
+				s.reversals = 0
+			}
+			s.turnDir = dir
+			s.reversals++
+			s.turning, s.turnStart = s.turning-dir*180, at
+		}
+	}
+	s.lastSeen, s.lastTrack = at, track
+}
+
+// laps counts completed racetracks from the reversals when the samples were
+// dense enough to follow them, and otherwise estimates them from the time in
+// the hold.
+func (s *stay) laps(dwell time.Duration) int {
+	laps := s.reversals / minReversals
+	if s.sparse {
+		laps = max(laps, int(dwell/lapTime))
+	}
+	return laps
+}
+
+func (s *stay) confidence() string {
+	if s.reversals >= minReversals {
+		return ConfidenceHigh
+	}
+	return ConfidenceLow
+}
+
+// stackFor returns the nearest stack whose area and levels contain s.
+func (t *Tracker) stackFor(s Sample) (Stack, bool) {
+	var best Stack
+	dist, found := math.Inf(1), false
+	if s.OnGround {
+		return best, false
+	}
+	for _, st := range t.stacks {
+		if s.AltFt < st.MinFt-500 || s.AltFt > st.MaxFt+500 {
+			continue
+		}
+		if d := geo.HaversineKm(st.Position(), s.Position); d <= st.RadiusKm && d < dist {
+			best, dist, found = st, d, true
+		}
+	}
+	return best, found
+}
+
+// occupancy groups holds by stack and level.
+func occupancy(stacks []Stack, holds []Hold) []StackStatus {
+	out := make([]StackStatus, 0, len(stacks))
+	for _, st := range stacks {
+		status := StackStatus{Stack: st, Levels: []Level{}}
+		for _, h := range holds {
+			if h.Stack != st.Ident {
+				continue
+			}
+			status.Count++
+			if h.Confidence != ConfidenceHigh {
+				status.Unsure++
+			}
+			status.MaxMinutes = math.Max(status.MaxMinutes, h.Minutes)
+			name := h.Callsign
+			if name == "" {
+				name = h.ICAO24
+			}
+			if n := len(status.Levels); n > 0 && status.Levels[n-1].Level == h.Level {
+				status.Levels[n-1].Aircraft = append(status.Levels[n-1].Aircraft, name)
+			} else {
+				status.Levels = append(status.Levels, Level{Level: h.Level, Aircraft: []string{name}})
+			}
+		}
+		out = append(out, status)
+	}
+	return out
+}
+
+// FlightLevel rounds a pressure altitude to the nearest thousand feet, e.g. "FL090".
+func FlightLevel(altFt float64) string {
+	return fmt.Sprintf("FL%03d", int(math.Round(altFt/1000))*10)
+}
+
+// LevelRange describes a stack's occupied levels, e.g. "FL070-FL110", or "" when empty.
+func (s StackStatus) LevelRange() string {
+	switch len(s.Levels) {
+	case 0:
+		return ""
+	case 1:
+		return s.Levels[0].Level
+	}
+	return s.Levels[0].Level + "-" + s.Levels[len(s.Levels)-1].Level
+}
+
+// Feeds names the airports the stack serves, e.g. "EGSS/EGGW".
+func (s Stack) Feeds() string {
+	return strings.Join(s.Airports, "/")
+}
+
+// signedTurn returns the change from one track to the next in (-180, 180].
+func signedTurn(from, to float64) float64 {
+	d := math.Mod(to-from+540, 360) - 180
+	if d == -180 {
+		return 180
+	}
+	return d
+}
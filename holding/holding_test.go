@@ -0,0 +1,204 @@
+package holding
+
+import (
+	"testing"
+	"time"
+
+	"AirTraffic-Monitor/geo"
+)
+
+var t0 = time.Date(2026, 10, 18, 8, 0, 0, 0, time.UTC)
+
+// bnn is Bovingdon as in LondonStacks.
+var bnn = LondonStacks()[0]
+
+// racetrack returns a right-hand holding pattern at bnn sampled every step
+// from start for d: one-minute legs inbound on 110° and outbound on 290°,
+// joined by rate-one turns (3°/s).
+func racetrack(start time.Time, d, step time.Duration) []TrackPoint {
+	var out []TrackPoint
+	pos := geo.Destination(bnn.Position(), 290, 3)
+	for el := time.Duration(0); el <= d; el += step {
+		phase := el % lapTime
+		var track float64
+		switch {
+		case phase < time.Minute:
+			track = 110
+		case phase < 2*time.Minute:
+			track = 110 + 180*(phase-time.Minute).Seconds()/60
+		case phase < 3*time.Minute:
+			track = 290
+		default:
+			track = 290 + 180*(phase-3*time.Minute).Seconds()/60
+		}
+		out = append(out, TrackPoint{At: start.Add(el), Position: pos, Track: mod360(track)})
+	}
+	return out
+}
+
+// straight returns a track across bnn on a constant heading.
+func straight(start time.Time, d, step time.Duration, track float64) []TrackPoint {
+	var out []TrackPoint
+	for el := time.Duration(0); el <= d; el += step {
+		out = append(out, TrackPoint{At: start.Add(el), Position: bnn.Position(), Track: track})
+	}
+	return out
+}
+
+// weave returns vectors through the stack: alternate 160° turns left and right.
+func weave(start time.Time, d, step time.Duration) []TrackPoint {
+	var out []TrackPoint
+	for el := time.Duration(0); el <= d; el += step {
+		phase := el % (2 * time.Minute)
+		track := 90.0
+		if phase >= time.Minute {
+			track = 250
+		}
+		out = append(out, TrackPoint{At: start.Add(el), Position: bnn.Position(), Track: track})
+	}
+	return out
+}
+
+func mod360(v float64) float64 {
+	for v >= 360 {
+		v -= 360
+	}
+	return v
+}
+
+// run feeds points to a fresh tracker one cycle every poll, passing the points
+// in between as the trail when dense is set, and returns the last report.
+func run(points []TrackPoint, poll time.Duration, dense bool) Report {
+	tr := NewTracker([]Stack{bnn})
+	var report Report
+	var trail []TrackPoint
+	next := points[0].At
+	for _, p := range points {
+		if p.At.Before(next) {
+			if dense {
+				trail = append(trail, p)
+			}
+			continue
+		}
+		track := p.Track
+		report = tr.Update([]Sample{{ICAO24: "406A1B", Callsign: "BAW123", Position: p.Position, AltFt: 9000,
+			Track: &track, Trail: trail}}, p.At)
+		trail, next = nil, p.At.Add(poll)
+	}
+	return report
+}
+
+func TestTrackerUpdate(t *testing.T) {
+	tests := []struct {
+		name           string
+		points         []TrackPoint
+		poll           time.Duration
+		dense          bool
+		wantHolding    bool
+		wantLaps       int
+		wantConfidence string
+	}{
+		{
+			name:   "racetrack polled every 10s",
+			points: racetrack(t0, 9*time.Minute, 10*time.Second), poll: 10 * time.Second,
+			wantHolding: true, wantLaps: 2, wantConfidence: ConfidenceHigh,
+		},
+		{
+			name:   "racetrack polled every 5 minutes with a dense trail",
+			points: racetrack(t0, 10*time.Minute, 5*time.Second), poll: 5 * time.Minute, dense: true,
+			wantHolding: true, wantLaps: 2, wantConfidence: ConfidenceHigh,
+		},
+		{
+			name:   "one turn is not yet a hold",
+			points: racetrack(t0, 2*time.Minute+30*time.Second, 10*time.Second), poll: 10 * time.Second,
+		},
+		{
+			name:   "straight through slowly with dense samples",
+			points: straight(t0, 8*time.Minute, 10*time.Second, 45), poll: 10 * time.Second,
+		},
+		{
+			name:   "weaving vectors turn both ways",
+			points: weave(t0, 8*time.Minute, 10*time.Second), poll: 10 * time.Second,
+		},
+		{
+			name:   "sparse polls fall back to dwell time",
+			points: straight(t0, 10*time.Minute, 5*time.Minute, 45), poll: 5 * time.Minute,
+			wantHolding: true, wantLaps: 2, wantConfidence: ConfidenceLow,
+		},
+		{
+			name:   "sparse polls, not long enough",
+			points: straight(t0, 5*time.Minute, 5*time.Minute, 45), poll: 5 * time.Minute,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := run(tt.points, tt.poll, tt.dense)
+			if !tt.wantHolding {
+				if report.Holding() != 0 {
+					t.Fatalf("holding: %+v", report.Holds)
+				}
+				return
+			}
+			if report.Holding() != 1 {
+				t.Fatalf("got %d holds, want 1", report.Holding())
+			}
+			h := report.Holds[0]
+			if h.Stack != "BNN" || h.Level != "FL090" || h.Laps != tt.wantLaps || h.Confidence != tt.wantConfidence {
+				t.Errorf("hold = %+v, want BNN FL090 laps %d confidence %s", h, tt.wantLaps, tt.wantConfidence)
+			}
+			if !h.Entered.Equal(t0) {
+				t.Errorf("entered %v, want %v", h.Entered, t0)
+			}
+			st := report.Stacks[0]
+			unsure := 0
+			if tt.wantConfidence == ConfidenceLow {
+				unsure = 1
+			}
+			if st.Count != 1 || st.Unsure != unsure || st.LevelRange() != "FL090" {
+				t.Errorf("stack = %+v", st)
+			}
+		})
+	}
+}
+
+func TestTrackerStackMatching(t *testing.T) {
+	tr := NewTracker(LondonStacks())
+	lam, abbot := LondonStacks()[1], LondonStacks()[5]
+	samples := []Sample{
+		{ICAO24: "A", Position: bnn.Position(), AltFt: 9000},
+		{ICAO24: "B", Position: bnn.Position(), AltFt: 20000},                           // above the stack
+		{ICAO24: "C", Position: geo.Destination(abbot.Position(), 90, 30), AltFt: 9000}, // outside every radius
+		{ICAO24: "D", Position: lam.Position(), AltFt: 8000, OnGround: true},
+	}
+	tr.Update(samples, t0)
+	tr.Update(samples, t0.Add(5*time.Minute))
+	report := tr.Update(samples, t0.Add(10*time.Minute))
+	if report.Holding() != 1 || report.Holds[0].ICAO24 != "A" {
+		t.Fatalf("holds = %+v, want only A", report.Holds)
+	}
+	if len(report.Stacks) != len(LondonStacks()) {
+		t.Errorf("got %d stacks, want every stack listed", len(report.Stacks))
+	}
+
+	// An aircraft that leaves is forgotten and starts again on return.
+	tr.Update(nil, t0.Add(15*time.Minute))
+	if report := tr.Update(samples[:1], t0.Add(20*time.Minute)); report.Holding() != 0 {
+		t.Errorf("returning aircraft kept its old stay: %+v", report.Holds)
+	}
+}
+
+func TestSignedTurn(t *testing.T) {
+	tests := []struct{ from, to, want float64 }{
+		{10, 20, 10},
+		{20, 10, -10},
+		{350, 10, 20},
+		{10, 350, -20},
+		{0, 180, 180},
+		{90, 270, 180},
+	}
+	for _, tt := range tests {
+		if got := signedTurn(tt.from, tt.to); got != tt.want {
+			t.Errorf("signedTurn(%v, %v) = %v, want %v", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+// Package icaoaddr derives what the 24-bit ICAO aircraft address itself says
+// about an airframe: the state it is allocated to (ICAO Annex 10 Vol III) and
+// whether it falls in a block known to be used by military aircraft. It needs
+// no lookups, so it works for contacts adsbdb has never heard of.
+package icaoaddr
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type block struct {
+	start, end uint32
+	country    string
+}
+
+// Parse turns a six-digit hex address into its 24-bit value.
+func Parse(icao24 string) (uint32, bool) {
+	s := strings.TrimSpace(icao24)
+	if len(s) != 6 {
+		return 0, false
+	}
+	v, err := strconv.ParseUint(s, 16, 32)
+	if err != nil {
+		return 0, false
+	}
+	return uint32(v), true
+}
+
+// Country returns the state the address block is allocated to.
+func Country(icao24 string) (string, bool) {
+	b, ok := find(countryBlocks, icao24)
+	return b.country, ok
+}
+
+// LikelyMilitary reports whether the address lies in a curated military block.
+// The list is deliberately conservative: a false result does not mean civil.
+func LikelyMilitary(icao24 string) bool {
+	_, ok := find(militaryBlocks, icao24)
+	return ok
+}
+
+// find binary-searches blocks, which must be sorted and non-overlapping.
+func find(blocks []block, icao24 string) (block, bool) {
+	addr, ok := Parse(icao24)
+	if !ok {
+		return block{}, false
+	}
+	i := sort.Search(len(blocks), func(i int) bool { return blocks[i].end >= addr })
+	if i < len(blocks) && blocks[i].start <= addr {
+		return blocks[i], true
+	}
+	return block{}, false
+}
@@ -0,0 +1,51 @@
+package icaoaddr
+
+import "strings"
+
+// nationalityMarks maps registration prefixes (ICAO Annex 7) to the state whose
+// address block the aircraft should be in, using the names in countryBlocks.
+// British overseas territories and Crown dependencies register aircraft from
+// the United Kingdom's block, and Aruba from the Netherlands'.
+var nationalityMarks = map[string]string{
+	"N": "United States", "C-": "Canada", "XA-": "Mexico", "XB-": "Mexico", "XC-": "Mexico",
+	"G-": "United Kingdom", "M-": "United Kingdom", "2-": "United Kingdom", "VP-": "United Kingdom", "VQ-": "United Kingdom",
+	"EI-": "Ireland", "EJ-": "Ireland", "D-": "Germany", "F-": "France", "I-": "Italy", "EC-": "Spain",
+	"PH-": "Netherlands", "P4-": "Netherlands", "OO-": "Belgium", "LX-": "Luxembourg", "OE-": "Austria",
+	"HB-": "Switzerland", "OY-": "Denmark", "SE-": "Sweden", "LN-": "Norway", "OH-": "Finland", "TF-": "Iceland",
+	"CS-": "Portugal", "SX-": "Greece", "SP-": "Poland", "OK-": "Czechia", "OM-": "Slovakia", "HA-": "Hungary",
+	"YR-": "Romania", "LZ-": "Bulgaria", "9A-": "Croatia", "S5-": "Slovenia", "YU-": "Serbia", "4O-": "Montenegro",
+	"Z3-": "North Macedonia", "E7-": "Bosnia and Herzegovina", "ZA-": "Albania", "9H-": "Malta", "5B-": "Cyprus",
+	"T7-": "San Marino", "3A-": "Monaco", "ES-": "Estonia", "YL-": "Latvia", "LY-": "Lithuania", "UR-": "Ukraine",
+	"EW-": "Belarus", "ER-": "Moldova", "RA-": "Russia", "RF-": "Russia", "TC-": "Turkey", "4L-": "Georgia",
+	"EK-": "Armenia", "4K-": "Azerbaijan", "UP-": "Kazakhstan", "UK": "Uzbekistan", "EX-": "Kyrgyzstan",
+	"EY-": "Tajikistan", "EZ-": "Turkmenistan", "YA-": "Afghanistan", "4X-": "Israel", "JY-": "Jordan",
+	"OD-": "Lebanon", "YK-": "Syria", "YI-": "Iraq", "EP-": "Iran", "HZ-": "Saudi Arabia", "9K-": "Kuwait",
+	"A6-": "United Arab Emirates", "A7-": "Qatar", "A9C-": "Bahrain", "A4O-": "Oman", "7O-": "Yemen",
+	"AP-": "Pakistan", "VT-": "India", "4R-": "Sri Lanka", "S2-": "Bangladesh", "9N-": "Nepal", "8Q-": "Maldives",
+	"9V-": "Singapore", "9M-": "Malaysia", "HS-": "Thailand", "PK-": "Indonesia", "RP-": "Philippines",
+	"VN-": "Vietnam", "XU-": "Cambodia", "RDPL-": "Laos", "XY-": "Myanmar", "V8-": "Brunei", "JU-": "Mongolia",
+	"B-": "China", "JA": "Japan", "HL": "South Korea", "VH-": "Australia", "ZK-": "New Zealand",
+	"P2-": "Papua New Guinea", "DQ-": "Fiji", "SU-": "Egypt", "CN-": "Morocco", "7T-": "Algeria", "TS-": "Tunisia",
+	"5A-": "Libya", "ZS-": "South Africa", "5N-": "Nigeria", "5Y-": "Kenya", "ET-": "Ethiopia", "9G-": "Ghana",
+	"5H-": "Tanzania", "5X-": "Uganda", "9J-": "Zambia", "Z-": "Zimbabwe", "D2-": "Angola", "A2-": "Botswana",
+	"V5-": "Namibia", "3B-": "Mauritius", "S7-": "Seychelles", "PP-": "Brazil", "PR-": "Brazil", "PS-": "Brazil",
+	"PT-": "Brazil", "PU-": "Brazil", "LV-": "Argentina", "CC-": "Chile", "HK-": "Colombia", "OB-": "Peru",
+	"YV-": "Venezuela", "HC-": "Ecuador", "CX-": "Uruguay", "9Y-": "Trinidad and Tobago", "6Y-": "Jamaica",
+}
+
+// RegistrationCountry returns the state a registration's nationality mark
+// belongs to, e.g. "United Kingdom" for G-EUPT. It reports false for marks not
+// in the table.
+func RegistrationCountry(registration string) (string, bool) {
+	reg := strings.ToUpper(strings.TrimSpace(registration))
+	// Taiwan also uses B-, with five digits; Hong Kong and Macau use China's block.
+	if len(reg) == 7 && strings.HasPrefix(reg, "B-") && strings.Trim(reg[2:], "0123456789") == "" {
+		return "Taiwan", true
+	}
+	for n := min(len(reg), 5); n > 0; n-- {
+		if country, ok := nationalityMarks[reg[:n]]; ok {
+			return country, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,160 @@
+// Package logging builds the structured slog loggers shared by every component,
+// each with its own level, plus an HTTP access-log middleware.
+package logging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"AirTraffic-Monitor/config"
+)
+
+// Component names used for per-component log levels.
+const (
+	ComponentMonitor    = "monitor"
+	ComponentOpenSky    = "opensky"
+	ComponentADSBDB     = "adsbdb"
+	ComponentWeb        = "web"
+	ComponentMQTT       = "mqtt"
+	ComponentWatchlist  = "watchlist"
+	ComponentAircraftDB = "aircraftdb"
+	ComponentNoise      = "noise"
+	ComponentSBS        = "sbs"
+	ComponentTar1090    = "tar1090"
+	ComponentRecord     = "record"
+	ComponentHistory    = "history"
+)
+
+// levelHandler lets each component filter at its own level while sharing one output handler.
+type levelHandler struct {
+	level slog.Leveler
+	next  slog.Handler
+}
+
+func (h levelHandler) Enabled(_ context.Context, l slog.Level) bool {
+	return l >= h.level.Level()
+}
+
+func (h levelHandler) Handle(ctx context.Context, r slog.Record) error {
+	return h.next.Handle(ctx, r)
+}
+
+func (h levelHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return levelHandler{level: h.level, next: h.next.WithAttrs(attrs)}
+}
+
+func (h levelHandler) WithGroup(name string) slog.Handler {
+	return levelHandler{level: h.level, next: h.next.WithGroup(name)}
+}
+
+// Loggers hands out one logger per component, all writing through the same handler.
+type Loggers struct {
+	mu         sync.Mutex
+	base       slog.Handler
+	level      slog.Level
+	components map[string]slog.Level
+	cache      map[string]*slog.Logger
+}
+
+// New builds the shared handler. Unknown formats or levels fall back to text/info;
+// config.Validate reports them before we get here.
+func New(cfg config.LogConfig, w io.Writer) *Loggers {
+	l := &Loggers{
+		level:      parseLevel(cfg.Level, slog.LevelInfo),
+		components: make(map[string]slog.Level),
+		cache:      make(map[string]*slog.Logger),
+	}
+	// The base handler lets everything through; levelHandler does the filtering.
+	opts := &slog.HandlerOptions{Level: slog.Level(-8)}
+	if strings.EqualFold(cfg.Format, "json") {
+		l.base = slog.NewJSONHandler(w, opts)
+	} else {
+		l.base = slog.NewTextHandler(w, opts)
+	}
+	for name, lvl := range cfg.Components {
+		l.components[name] = parseLevel(lvl, l.level)
+	}
+	return l
+}
+
+// Discard returns loggers that write nothing, for library use without logging.
+func Discard() *Loggers {
+	return New(config.LogConfig{Level: "error"}, io.Discard)
+}
+
+func parseLevel(s string, fallback slog.Level) slog.Level {
+	var l slog.Level
+	if s == "" || l.UnmarshalText([]byte(s)) != nil {
+		return fallback
+	}
+	return l
+}
+
+// For returns the logger for a component, tagged with component=<name>.
+func (l *Loggers) For(component string) *slog.Logger {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if lg, ok := l.cache[component]; ok {
+		return lg
+	}
+	level, ok := l.components[component]
+	if !ok {
+		level = l.level
+	}
+	lg := slog.New(levelHandler{level: level, next: l.base}).With("component", component)
+	l.cache[component] = lg
+	return lg
+}
+
+// Default returns a logger for code outside any named component.
+func (l *Loggers) Default() *slog.Logger {
+	return slog.New(levelHandler{level: l.level, next: l.base})
+}
+
+// requestSeq numbers HTTP requests for the access log and X-Request-ID header.
+var requestSeq atomic.Uint64
+
+// Middleware wraps handlers with a debug-level access log; each request gets an
+// ID that is echoed back in X-Request-ID so clients can quote it.
+func Middleware(logger *slog.Logger, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get("X-Request-ID")
+		if id == "" {
+			id = fmt.Sprintf("req-%d", requestSeq.Add(1))
+		}
+		w.Header().Set("X-Request-ID", id)
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		start := time.Now()
+		next.ServeHTTP(rec, r)
+		logger.Debug("http request", "request_id", id, "method", r.Method,
+			"path", r.URL.Path, "status", rec.status, "duration", time.Since(start))
+	})
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(code int) {
+	r.status = code
+	r.ResponseWriter.WriteHeader(code)
+}
+
+// StatusCode extracts the upstream HTTP status from err, or 0 if there is none.
+// Client errors expose it through an HTTPStatus method.
+func StatusCode(err error) int {
+	var se interface{ HTTPStatus() int }
+	if errors.As(err, &se) {
+		return se.HTTPStatus()
+	}
+	return 0
+}
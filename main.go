@@ -1,760 +1,338 @@
+// Command AirTraffic-Monitor polls OpenSky for aircraft over North London,
+// enriches them via adsbdb and serves an airport-style board and JSON API. The
+// once, lookup and export commands give single-shot output for scripts.
 package main
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
+	"flag"
 	"fmt"
-	"html/template"
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
-	"sort"
+	"os/signal"
 	"strings"
-	"sync"
+	"syscall"
 	"time"
-)
-
-// NOTE: The adsbdb public API does not (currently) expose a direct "live aircraft by geo bounding box" endpoint.
-// It focuses on aircraft metadata, flight routes and conversions for identifiers. To approximate "planes over London"
-// with JUST this API we would need callsigns or mode-s codes sourced elsewhere (e.g. a feed like ADS-B Exchange,
-// OpenSky Network, etc.) and then enrich with adsbdb. For now, this program demonstrates querying a set of example
-// Mode S hex codes (you can replace with real-time hex codes captured from a receiver) and printing aircraft details.
-//
-// If in future adsbdb provides a geographic query endpoint, you can adapt by hitting that endpoint instead.
 
-// AircraftResponse models a successful aircraft lookup response from adsbdb
-type AircraftResponse struct {
-	Response struct {
-		Aircraft struct {
-			Type                          string `json:"type"`
-			ICAOType                      string `json:"icao_type"`
-			Manufacturer                  string `json:"manufacturer"`
-			ModeS                        string `json:"mode_s"`
-			Registration                  string `json:"registration"`
-			RegisteredOwnerCountryISOName string `json:"registered_owner_country_iso_name"`
-			RegisteredOwnerCountryName    string `json:"registered_owner_country_name"`
-			RegisteredOwnerOperatorFlag   *string `json:"registered_owner_operator_flag_code"`
-			RegisteredOwner               string  `json:"registered_owner"`
-			URLPhoto                      *string `json:"url_photo"`
-			URLPhotoThumbnail             *string `json:"url_photo_thumbnail"`
-		} `json:"aircraft"`
-	} `json:"response"`
-}
+	"AirTraffic-Monitor/config"
+	"AirTraffic-Monitor/fusion"
+	"AirTraffic-Monitor/history"
+	"AirTraffic-Monitor/logging"
+	"AirTraffic-Monitor/monitor"
+	"AirTraffic-Monitor/mqtt"
+	"AirTraffic-Monitor/replay"
+	"AirTraffic-Monitor/web"
+)
 
-// UnknownResponse is returned for 404 cases
-type UnknownResponse struct {
-	Response string `json:"response"`
-}
+const usage = `usage: AirTraffic-Monitor [command] [flags] [arguments]
 
-// FlightRouteResponse models a callsign lookup response from adsbdb
-type FlightRouteResponse struct {
-	Response struct {
-		FlightRoute struct {
-			Callsign     string `json:"callsign"`
-			CallsignICAO *string `json:"callsign_icao"`
-			CallsignIATA *string `json:"callsign_iata"`
-			Origin struct {
-				CountryISOName string  `json:"country_iso_name"`
-				CountryName    string  `json:"country_name"`
-				Elevation      float64 `json:"elevation"`
-				IATACode       string  `json:"iata_code"`
-				ICAOCode       string  `json:"icao_code"`
-				Latitude       float64 `json:"latitude"`
-				Longitude      float64 `json:"longitude"`
-				Municipality   string  `json:"municipality"`
-				Name           string  `json:"name"`
-			} `json:"origin"`
-			Destination struct {
-				CountryISOName string  `json:"country_iso_name"`
-				CountryName    string  `json:"country_name"`
-				Elevation      float64 `json:"elevation"`
-				IATACode       string  `json:"iata_code"`
-				ICAOCode       string  `json:"icao_code"`
-				Latitude       float64 `json:"latitude"`
-				Longitude      float64 `json:"longitude"`
-				Municipality   string  `json:"municipality"`
-				Name           string  `json:"name"`
-			} `json:"destination"`
-		} `json:"flightroute"`
-	} `json:"response"`
-}
+Commands:
+  serve                poll every 5 minutes and serve the board and API on :4545 (default)
+  once                 run a single cycle and print its aircraft and events
+  lookup               look up an aircraft by ICAO24 address or registration
+  export               print the current aircraft, or the spotted log or noise history
+  refresh-aircraft-db  reimport aircraft_db.source into the indexed aircraft_db.path
 
-// CombinedFlightInfo holds both aircraft and route data
-type CombinedFlightInfo struct {
-	Aircraft    *AircraftResponse
-	FlightRoute *FlightRouteResponse
-}
+Run "AirTraffic-Monitor <command> -h" for the command's flags.
+`
 
-// WebAircraftInfo holds display-ready aircraft information
-type WebAircraftInfo struct {
-	Registration string
-	Owner        string
-	Manufacturer string
-	Type         string
-	Origin       string
-	Destination  string
-	LastUpdated  string
+func main() {
+	os.Exit(run(os.Args[1:]))
 }
 
-// Global state for web server
-var (
-	currentAircraft []WebAircraftInfo
-	lastUpdate      string
-	aircraftMutex   sync.RWMutex
-)
-
-// fetchAircraft queries adsbdb for a single Mode S or registration string.
-func fetchAircraft(ctx context.Context, client *http.Client, id string) (*AircraftResponse, error) {
-	// Using major version v0 from current release examples.
-	url := fmt.Sprintf("https://api.adsbdb.com/v0/aircraft/%s", id)
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
-	if err != nil {
-		return nil, err
+// run dispatches to the command named by the first argument, serve when there
+// is none, and returns the exit status.
+func run(args []string) int {
+	cmd := "serve"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		cmd, args = args[0], args[1:]
 	}
-	res, err := client.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer res.Body.Close()
-
-	if res.StatusCode == http.StatusNotFound {
-		var unknown UnknownResponse
-		_ = json.NewDecoder(res.Body).Decode(&unknown) // best-effort
-		return nil, fmt.Errorf("unknown aircraft (%s): %s", id, unknown.Response)
-	}
-	if res.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected status %d for %s", res.StatusCode, id)
-	}
-	var aircraft AircraftResponse
-	if err := json.NewDecoder(res.Body).Decode(&aircraft); err != nil {
-		return nil, err
-	}
-	// Basic validation
-	if aircraft.Response.Aircraft.ModeS == "" && aircraft.Response.Aircraft.Registration == "" {
-		return nil, errors.New("empty aircraft payload")
+	switch cmd {
+	case "serve":
+		return serve(args)
+	case "once":
+		return once(args)
+	case "lookup":
+		return lookup(args)
+	case "export":
+		return export(args)
+	case "refresh-aircraft-db":
+		return refreshAircraftDB(args)
+	case "help":
+		fmt.Print(usage)
+		return 0
 	}
-	return &aircraft, nil
+	fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", cmd, usage)
+	return 2
 }
 
-// fetchFlightRoute queries adsbdb for flight route info using aircraft Mode S + callsign
-func fetchFlightRoute(ctx context.Context, client *http.Client, modeS, callsign string) (*FlightRouteResponse, error) {
-	if callsign == "" {
-		return nil, fmt.Errorf("no callsign available for route lookup")
-	}
-	
-	// Try aircraft endpoint with callsign query parameter first
-	url := fmt.Sprintf("https://api.adsbdb.com/v0/aircraft/%s?callsign=%s", modeS, callsign)
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
-	if err != nil {
-		return nil, err
-	}
-	res, err := client.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer res.Body.Close()
-
-	if res.StatusCode == http.StatusNotFound {
-		return nil, fmt.Errorf("flight route not found for callsign %s", callsign)
-	}
-	if res.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected status %d for callsign %s", res.StatusCode, callsign)
-	}
-
-	// This endpoint returns both aircraft and flightroute data
-	var combined struct {
-		Response struct {
-			Aircraft    AircraftResponse `json:"aircraft"`
-			FlightRoute struct {
-				Callsign     string `json:"callsign"`
-				CallsignICAO *string `json:"callsign_icao"`
-				CallsignIATA *string `json:"callsign_iata"`
-				Origin struct {
-					CountryISOName string  `json:"country_iso_name"`
-					CountryName    string  `json:"country_name"`
-					Elevation      float64 `json:"elevation"`
-					IATACode       string  `json:"iata_code"`
-					ICAOCode       string  `json:"icao_code"`
-					Latitude       float64 `json:"latitude"`
-					Longitude      float64 `json:"longitude"`
-					Municipality   string  `json:"municipality"`
-					Name           string  `json:"name"`
-				} `json:"origin"`
-				Destination struct {
-					CountryISOName string  `json:"country_iso_name"`
-					CountryName    string  `json:"country_name"`
-					Elevation      float64 `json:"elevation"`
-					IATACode       string  `json:"iata_code"`
-					ICAOCode       string  `json:"icao_code"`
-					Latitude       float64 `json:"latitude"`
-					Longitude      float64 `json:"longitude"`
-					Municipality   string  `json:"municipality"`
-					Name           string  `json:"name"`
-				} `json:"destination"`
-			} `json:"flightroute"`
-		} `json:"response"`
-	}
-	
-	if err := json.NewDecoder(res.Body).Decode(&combined); err != nil {
-		return nil, err
+// newFlagSet returns the flags of a command, whose usage line shows operands.
+func newFlagSet(name, operands, about string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: AirTraffic-Monitor %s [flags] %s\n\n%s\n\nFlags:\n", name, operands, about)
+		fs.PrintDefaults()
 	}
-	
-	return &FlightRouteResponse{Response: struct {
-		FlightRoute struct {
-			Callsign     string `json:"callsign"`
-			CallsignICAO *string `json:"callsign_icao"`
-			CallsignIATA *string `json:"callsign_iata"`
-			Origin struct {
-				CountryISOName string  `json:"country_iso_name"`
-				CountryName    string  `json:"country_name"`
-				Elevation      float64 `json:"elevation"`
-				IATACode       string  `json:"iata_code"`
-				ICAOCode       string  `json:"icao_code"`
-				Latitude       float64 `json:"latitude"`
-				Longitude      float64 `json:"longitude"`
-				Municipality   string  `json:"municipality"`
-				Name           string  `json:"name"`
-			} `json:"origin"`
-			Destination struct {
-				CountryISOName string  `json:"country_iso_name"`
-				CountryName    string  `json:"country_name"`
-				Elevation      float64 `json:"elevation"`
-				IATACode       string  `json:"iata_code"`
-				ICAOCode       string  `json:"icao_code"`
-				Latitude       float64 `json:"latitude"`
-				Longitude      float64 `json:"longitude"`
-				Municipality   string  `json:"municipality"`
-				Name           string  `json:"name"`
-			} `json:"destination"`
-		} `json:"flightroute"`
-	}{FlightRoute: combined.Response.FlightRoute}}, nil
-}
-
-// OpenSky states endpoint shape we'll use (public, anonymous) for a bounding box.
-// Reference: https://opensky-network.org/apidoc/rest.html#flights-in-a-bounding-box
-// We will call: https://opensky-network.org/api/states/all?lamin=51.30&lomin=-0.50&lamax=51.70&lomax=0.30
-// This returns JSON with field "states": [[icao24, callsign, origin_country, time_position, last_contact, longitude, latitude, baro_altitude, on_ground, velocity, true_track, vertical_rate, sensors, geo_altitude, squawk, spi, position_source, category]]
-
-type openSkyStates struct {
-	Time   int64           `json:"time"`
-	States [][]interface{} `json:"states"`
+	return fs
 }
 
-// AircraftState holds both ICAO24 and callsign from OpenSky
-type AircraftState struct {
-	ICAO24   string
-	Callsign string
+// commonFlags adds the flags every command takes.
+func commonFlags(fs *flag.FlagSet) (configPath, format *string) {
+	configPath = fs.String("config", "", "path to a JSON config file (optional)")
+	format = fs.String("format", formatTable, "output format: table, json or csv")
+	return configPath, format
 }
 
-// extractAircraftStates parses states array pulling both icao24 (index 0) and callsign (index 1) when present.
-func extractAircraftStates(data *openSkyStates) []AircraftState {
-	if data == nil || len(data.States) == 0 {
-		return nil
-	}
-	seen := make(map[string]struct{})
-	var states []AircraftState
-	
-	for _, row := range data.States {
-		if len(row) < 2 {
-			continue
+// parseArgs parses fs from args, allowing flags after the operands as well as
+// before, and checks there are between min and max operands. When it fails,
+// or -h was given, it returns false and the exit status.
+func parseArgs(fs *flag.FlagSet, args []string, min, max int) ([]string, int, bool) {
+	var operands []string
+	for {
+		if err := fs.Parse(args); errors.Is(err, flag.ErrHelp) {
+			return nil, 0, false
+		} else if err != nil {
+			return nil, 2, false
 		}
-		hex, _ := row[0].(string)
-		if hex == "" {
-			continue
+		if args = fs.Args(); len(args) == 0 {
+			break
 		}
-		// OpenSky returns lowercase; adsbdb expects uppercase for Mode S. Convert.
-		hex = strings.ToUpper(hex)
-		
-		// Avoid duplicates
-		if _, exists := seen[hex]; exists {
-			continue
-		}
-		seen[hex] = struct{}{}
-		
-		callsign, _ := row[1].(string)
-		callsign = strings.TrimSpace(callsign)
-		
-		states = append(states, AircraftState{
-			ICAO24:   hex,
-			Callsign: callsign,
-		})
+		operands, args = append(operands, args[0]), args[1:]
+	}
+	if len(operands) < min || len(operands) > max {
+		fmt.Fprintf(fs.Output(), "%s: wrong number of arguments: %q\n", fs.Name(), operands)
+		fs.Usage()
+		return nil, 2, false
 	}
-	
-	// Sort by ICAO24 for consistent output
-	sort.Slice(states, func(i, j int) bool {
-		return states[i].ICAO24 < states[j].ICAO24
-	})
-	
-	return states
+	return operands, 0, true
 }
 
-func fetchOpenSkyNorthLondon(ctx context.Context, client *http.Client) ([]AircraftState, error) {
-	// Much larger North London area: lat 51.50-51.80, lon -0.50 to 0.20 (covers all of North London and beyond)
-	url := "https://opensky-network.org/api/states/all?lamin=51.50&lomin=-0.50&lamax=51.80&lomax=0.20"
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+// loadConfig reads the config and sets up logging to stderr. It logs and
+// returns false when the config is invalid.
+func loadConfig(path string) (config.Config, *logging.Loggers, bool) {
+	cfg, err := config.Load(path)
 	if err != nil {
-		return nil, err
+		logging.New(config.Default().Log, os.Stderr).Default().Error("failed to load config", "err", err)
+		return cfg, nil, false
 	}
-	res, err := client.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer res.Body.Close()
-	if res.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("opensky unexpected status %d", res.StatusCode)
-	}
-	var payload openSkyStates
-	if err := json.NewDecoder(res.Body).Decode(&payload); err != nil {
-		return nil, err
-	}
-	return extractAircraftStates(&payload), nil
+	logs := logging.New(cfg.Log, os.Stderr)
+	slog.SetDefault(logs.Default())
+	return cfg, logs, true
 }
 
-func checkAircraftInArea(ctx context.Context, client *http.Client) {
-	timestamp := time.Now().Format("2006-01-02 15:04:05")
-	fmt.Printf("\n=== Aircraft Check at %s ===\n", timestamp)
-	
-	// Step 1: Get live aircraft with both ICAO24 and callsigns over North London area via OpenSky.
-	aircraftStates, err := fetchOpenSkyNorthLondon(ctx, client)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "failed to fetch OpenSky states: %v\n", err)
-		updateWebData([]WebAircraftInfo{}, timestamp+" (Error fetching data)")
-		return
+// serve starts the monitor and web server and blocks until SIGINT/SIGTERM or a fatal
+// error, then shuts down in order: stop polling and drain enrichment, stop the web
+// server, flush state to disk and disconnect from MQTT. It returns the exit status.
+func serve(args []string) int {
+	fs := newFlagSet("serve", "", "Polls every 5 minutes and serves the board and API on :4545 until interrupted.")
+	configPath := fs.String("config", "", "path to a JSON config file (optional)")
+	format := fs.String("format", "", "print each cycle to stdout as table, json or csv (default: the console table if log.console_table is set)")
+	replayPaths := fs.String("replay", "", "comma-separated OpenSky archives, SBS captures or history directories to replay instead of polling live")
+	replaySpeed := fs.Float64("replay-speed", 1, "how many times faster than real time to replay, e.g. 60 for an hour a minute")
+	replayFrom := fs.String("replay-from", "", "RFC 3339 time to start the replay at (default: the start of the recordings)")
+	if _, status, ok := parseArgs(fs, args, 0, 0); !ok {
+		return status
 	}
-	if len(aircraftStates) == 0 {
-		fmt.Println("No aircraft currently reported over North London area - OpenSky.")
-		updateWebData([]WebAircraftInfo{}, timestamp)
-		return
+	if *format != "" {
+		if err := checkFormat(*format); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
 	}
 
-	fmt.Printf("Found %d aircraft over North London area. Enriching via adsbdb...\n\n", len(aircraftStates))
+	cfg, logs, ok := loadConfig(*configPath)
+	if !ok {
+		return 2
+	}
+	logger := logs.For(logging.ComponentMonitor).With("region", cfg.Region)
 
-	var webAircraftList []WebAircraftInfo
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	// Step 2: Enrich each aircraft using adsbdb for both aircraft info and route info.
-	for _, state := range aircraftStates {
-		aircraft, aErr := fetchAircraft(ctx, client, state.ICAO24)
-		if aErr != nil {
-			fmt.Fprintf(os.Stderr, "%s -> adsbdb aircraft error: %v\n", state.ICAO24, aErr)
-			continue
+	var rs *replaySession
+	if *replayPaths != "" {
+		var err error
+		if rs, err = openReplay(strings.Split(*replayPaths, ","), *replaySpeed, *replayFrom); err != nil {
+			logger.Error("failed to open replay", "err", err)
+			return 2
 		}
+		// A replayed afternoon must not end up in the live spotted log or noise totals.
+		cfg.SpottedPath, cfg.Noise.Path = "", ""
+		logger.Info("replaying recordings", "paths", *replayPaths, "from", rs.clock.Now().Format(time.RFC3339), "speed", *replaySpeed,
+			"recorded_adsbdb", rs.lookups != nil)
+	}
 
-		a := aircraft.Response.Aircraft
-		
-		// Try to get route information if we have a callsign
-		var origin, destination string = "Unknown", "Unknown"
-		if state.Callsign != "" {
-			route, rErr := fetchFlightRoute(ctx, client, state.ICAO24, state.Callsign)
-			if rErr == nil && route != nil {
-				r := route.Response.FlightRoute
-				origin = fmt.Sprintf("%s (%s)", r.Origin.Name, r.Origin.ICAOCode)
-				destination = fmt.Sprintf("%s (%s)", r.Destination.Name, r.Destination.ICAOCode)
-			}
+	a, status := newApp(ctx, cfg, logs, rs)
+	if a == nil {
+		return status
+	}
+	mon := a.mon
+
+	// A replay is not added to the history it may be replaying.
+	if cfg.History.Dir != "" && rs == nil {
+		histLog := logs.For(logging.ComponentHistory)
+		store, err := history.New(cfg.History.Dir, cfg.History.MaxAge.Duration)
+		if err != nil {
+			histLog.Error("failed to create history", "dir", cfg.History.Dir, "err", err)
+			a.close()
+			return 1
 		}
-
-		// Output in requested format: Reg, Owner, Manufacturer, Type, Origin, Destination
-		fmt.Printf("Reg: %s | Owner: %s | Manufacturer: %s | Type: %s | Origin: %s | Destination: %s\n",
-			a.Registration, a.RegisteredOwner, a.Manufacturer, a.Type, origin, destination)
-
-		// Add to web data
-		webAircraftList = append(webAircraftList, WebAircraftInfo{
-			Registration: a.Registration,
-			Owner:        a.RegisteredOwner,
-			Manufacturer: a.Manufacturer,
-			Type:         a.Type,
-			Origin:       origin,
-			Destination:  destination,
-			LastUpdated:  timestamp,
-		})
+		mon.Publishers = append(mon.Publishers, store)
+		histLog.Info("keeping position history", "dir", cfg.History.Dir, "max_age", cfg.History.MaxAge.Duration)
 	}
 
-	// Update web data
-	updateWebData(webAircraftList, timestamp)
-
-	fmt.Println("\nData sources: OpenSky Network (live positions) + adsbdb (aircraft metadata + routes).")
-}
-
-// HTML template for the web page
-const htmlTemplate = `
-<!DOCTYPE html>
-<html>
-<head>
-    <title>Aircraft Over North London</title>
-    <meta http-equiv="refresh" content="60">
-    <style>
-        body { 
-            font-family: 'Courier New', monospace; 
-            margin: 0; 
-            padding: 20px;
-            background-color: #000000;
-            color: #FFFF00;
-        }
-        h1 { 
-            color: #FFFF00; 
-            text-align: center;
-            font-size: 2.5em;
-            margin-bottom: 10px;
-            text-transform: uppercase;
-            letter-spacing: 2px;
-        }
-        .header { 
-            background-color: #1a1a1a; 
-            padding: 15px; 
-            border: 2px solid #FFFF00;
-            margin-bottom: 20px;
-            text-align: center;
-        }
-        table { 
-            border-collapse: collapse; 
-            width: 100%; 
-            margin-top: 20px;
-            background-color: #000000;
-            border: 2px solid #FFFF00;
-        }
-        th, td { 
-            border: 1px solid #FFFF00; 
-            padding: 15px; 
-            text-align: left;
-            font-family: 'Courier New', monospace;
-            font-size: 0.9em;
-        }
-        th { 
-            background-color: #FFFF00; 
-            color: #000000;
-            font-weight: bold;
-            text-transform: uppercase;
-            letter-spacing: 1px;
-        }
-        td {
-            background-color: #000000;
-            color: #FFFFFF;
-        }
-        tr:nth-child(even) td { 
-            background-color: #1a1a1a;
-        }
-        tr:hover td {
-            background-color: #333333;
-        }
-        .no-aircraft { 
-            color: #FFFF00; 
-            font-style: italic; 
-            text-align: center; 
-            padding: 40px;
-            font-size: 1.2em;
-            background-color: #1a1a1a;
-            border: 2px solid #FFFF00;
-            margin: 20px 0;
-        }
-        .update-time { 
-            color: #FFFF00; 
-            font-size: 1em;
-            margin: 5px 0;
-        }
-        .footer {
-            margin-top: 30px; 
-            padding-top: 20px; 
-            border-top: 2px solid #FFFF00; 
-            color: #FFFF00; 
-            font-size: 0.9em;
-            text-align: center;
-        }
-        
-        /* Flip animation for airport board effect */
-        @keyframes flipIn {
-            0% {
-                transform: rotateX(-90deg);
-                opacity: 0;
-            }
-            50% {
-                transform: rotateX(-45deg);
-                opacity: 0.5;
-            }
-            100% {
-                transform: rotateX(0deg);
-                opacity: 1;
-            }
-        }
-        
-        @keyframes flipOut {
-            0% {
-                transform: rotateX(0deg);
-                opacity: 1;
-            }
-            50% {
-                transform: rotateX(45deg);
-                opacity: 0.5;
-            }
-            100% {
-                transform: rotateX(90deg);
-                opacity: 0;
-            }
-        }
-        
-        .flip-char {
-            display: inline-block;
-            animation: flipIn 0.8s ease-in-out;
-            transform-origin: center;
-        }
-        
-        .flip-update {
-            animation: flipOut 0.4s ease-in-out, flipIn 0.4s ease-in-out 0.4s;
-        }
-        
-        /* Stagger animation delays for wave effect */
-        .flip-char:nth-child(1) { animation-delay: 0.1s; }
-        .flip-char:nth-child(2) { animation-delay: 0.2s; }
-        .flip-char:nth-child(3) { animation-delay: 0.3s; }
-        .flip-char:nth-child(4) { animation-delay: 0.4s; }
-        .flip-char:nth-child(5) { animation-delay: 0.5s; }
-        .flip-char:nth-child(6) { animation-delay: 0.6s; }
-        .flip-char:nth-child(7) { animation-delay: 0.7s; }
-        .flip-char:nth-child(8) { animation-delay: 0.8s; }
-        .flip-char:nth-child(9) { animation-delay: 0.9s; }
-        .flip-char:nth-child(10) { animation-delay: 1.0s; }
-        .flip-char:nth-child(11) { animation-delay: 1.1s; }
-        .flip-char:nth-child(12) { animation-delay: 1.2s; }
-        .flip-char:nth-child(13) { animation-delay: 1.3s; }
-        .flip-char:nth-child(14) { animation-delay: 1.4s; }
-        .flip-char:nth-child(15) { animation-delay: 1.5s; }
-        
-        /* Table rows animate in sequence */
-        tbody tr {
-            animation: flipIn 1.2s ease-in-out;
-        }
-        
-        tbody tr:nth-child(1) { animation-delay: 0.2s; }
-        tbody tr:nth-child(2) { animation-delay: 0.4s; }
-        tbody tr:nth-child(3) { animation-delay: 0.6s; }
-        tbody tr:nth-child(4) { animation-delay: 0.8s; }
-        tbody tr:nth-child(5) { animation-delay: 1.0s; }
-        tbody tr:nth-child(6) { animation-delay: 1.2s; }
-        tbody tr:nth-child(7) { animation-delay: 1.4s; }
-        tbody tr:nth-child(8) { animation-delay: 1.6s; }
-        
-        /* Header flip animation */
-        h1 .flip-char {
-            animation-duration: 1.5s;
-            animation-delay: calc(0.1s * var(--char-index));
-        }
-    </style>
-</head>
-<body>
-    <h1>✈ DEPARTURES - NORTH LONDON ✈</h1>
-    
-    <div class="header">
-        <p><strong>Coverage Area:</strong> North London (Lat: 51.50-51.80, Lon: -0.50 to 0.20)</p>
-        <p class="update-time"><strong>Last Updated:</strong> {{.LastUpdate}}</p>
-        <p class="update-time"><strong>Total Aircraft:</strong> {{len .Aircraft}}</p>
-        <p><em>Page auto-refreshes every 60 seconds</em></p>
-    </div>
-
-    {{if .Aircraft}}
-    <table>
-        <thead>
-            <tr>
-                <th>Registration</th>
-                <th>Owner</th>
-                <th>Manufacturer</th>
-                <th>Aircraft Type</th>
-                <th>Origin</th>
-                <th>Destination</th>
-            </tr>
-        </thead>
-        <tbody>
-            {{range .Aircraft}}
-            <tr>
-                <td>{{.Registration}}</td>
-                <td>{{.Owner}}</td>
-                <td>{{.Manufacturer}}</td>
-                <td>{{.Type}}</td>
-                <td>{{.Origin}}</td>
-                <td>{{.Destination}}</td>
-            </tr>
-            {{end}}
-        </tbody>
-    </table>
-    {{else}}
-    <div class="no-aircraft">
-        <p>No aircraft currently detected over North London area.</p>
-        <p>Data will refresh automatically every 5 minutes.</p>
-    </div>
-    {{end}}
-
-    <div class="footer">
-        <p><strong>DATA SOURCES</strong></p>
-        <p>LIVE POSITIONS: OPENSKY NETWORK | AIRCRAFT DATA: ADSBDB.COM</p>
-        <p>MONITORING AREA: 51.50°N-51.80°N, 0.50°W-0.20°E</p>
-    </div>
-
-    <script>
-        function wrapTextInFlipChars(element) {
-            const text = element.textContent;
-            element.innerHTML = '';
-            
-            for (let i = 0; i < text.length; i++) {
-                const char = text[i];
-                const span = document.createElement('span');
-                span.className = 'flip-char';
-                span.style.setProperty('--char-index', i);
-                span.textContent = char === ' ' ? '\u00A0' : char; // Non-breaking space
-                element.appendChild(span);
-            }
-        }
-        
-        function animateTableUpdate() {
-            const rows = document.querySelectorAll('tbody tr');
-            rows.forEach((row, index) => {
-                row.style.animation = 'none';
-                row.offsetHeight; // Trigger reflow
-                row.style.animation = 'flipIn 1.2s ease-in-out ' + (index * 0.2) + 's';
-            });
-        }
-        
-        // Initialize flip animations when page loads
-        window.addEventListener('load', function() {
-            // Animate the main title
-            const title = document.querySelector('h1');
-            if (title) {
-                wrapTextInFlipChars(title);
-            }
-            
-            // Animate table headers
-            const headers = document.querySelectorAll('th');
-            headers.forEach(header => {
-                wrapTextInFlipChars(header);
-            });
-            
-            // Set up periodic flip animation for visual effect
-            setInterval(() => {
-                const randomRowNum = Math.floor(Math.random() * 5) + 1;
-                const randomRow = document.querySelector('tbody tr:nth-child(' + randomRowNum + ')');
-                if (randomRow) {
-                    const cells = randomRow.querySelectorAll('td');
-                    cells.forEach(cell => {
-                        cell.style.animation = 'flipUpdate 0.8s ease-in-out';
-                        setTimeout(() => {
-                            cell.style.animation = '';
-                        }, 800);
-                    });
-                }
-            }, 8000); // Random flip every 8 seconds
-            
-            // Add subtle continuous flip to title
-            setInterval(() => {
-                const titleChars = document.querySelectorAll('h1 .flip-char');
-                titleChars.forEach((char, index) => {
-                    setTimeout(() => {
-                        char.style.animation = 'flipIn 0.6s ease-in-out';
-                        setTimeout(() => {
-                            char.style.animation = '';
-                        }, 600);
-                    }, index * 100);
-                });
-            }, 15000); // Title flip every 15 seconds
-        });
-        
-        // Re-animate when page refreshes with new data
-        let lastUpdateTime = '{{.LastUpdate}}';
-        setInterval(() => {
-            // This would normally check for updates via AJAX, 
-            // but since we're using meta refresh, we'll just add visual flair
-            const updateTimeElement = document.querySelector('.update-time');
-            if (updateTimeElement) {
-                updateTimeElement.style.animation = 'flipUpdate 0.8s ease-in-out';
-                setTimeout(() => {
-                    updateTimeElement.style.animation = '';
-                }, 800);
-            }
-        }, 60000); // Visual update every minute
-    </script>
-</body>
-</html>
-`
-
-// Web handler for the main page
-func aircraftHandler(w http.ResponseWriter, r *http.Request) {
-    aircraftMutex.RLock()
-    data := struct {
-        Aircraft   []WebAircraftInfo
-        LastUpdate string
-    }{
-        Aircraft:   currentAircraft,
-        LastUpdate: lastUpdate,
-    }
-    aircraftMutex.RUnlock()
-
-    tmpl, err := template.New("aircraft").Parse(htmlTemplate)
-    if err != nil {
-        http.Error(w, "Template error", http.StatusInternalServerError)
-        return
-    }
-
-    w.Header().Set("Content-Type", "text/html")
-    if err := tmpl.Execute(w, data); err != nil {
-        http.Error(w, "Template execution error", http.StatusInternalServerError)
-    }
-}
+	a.startFeeds(ctx)
+	switch {
+	case *format != "":
+		mon.Publishers = append(mon.Publishers, &cyclePrinter{W: os.Stdout, Format: *format, Region: cfg.Region})
+	case cfg.Log.ConsoleTable:
+		mon.Console = monitor.ConsoleTable{W: os.Stdout}
+	}
 
-// JSON API endpoint
-func apiHandler(w http.ResponseWriter, r *http.Request) {
-    aircraftMutex.RLock()
-    data := struct {
-        Aircraft   []WebAircraftInfo `json:"aircraft"`
-        LastUpdate string            `json:"last_update"`
-        Count      int               `json:"count"`
-    }{
-        Aircraft:   currentAircraft,
-        LastUpdate: lastUpdate,
-        Count:      len(currentAircraft),
-    }
-    aircraftMutex.RUnlock()
+	var publisher *mqtt.Publisher
+	if cfg.MQTT.Enabled {
+		publisher = mqtt.NewPublisher(cfg.MQTT, cfg.Region)
+		if cfg.HomeAssistant.Enabled {
+			publisher.EnableHomeAssistant(cfg.HomeAssistant, a.wl)
+		}
+		mon.Publishers = append(mon.Publishers, publisher)
+		logs.For(logging.ComponentMQTT).Info("publishing to broker", "broker", cfg.MQTT.Broker,
+			"topic", cfg.MQTT.TopicPrefix+"/"+cfg.Region, "home_assistant", cfg.HomeAssistant.Enabled)
+	}
 
-    w.Header().Set("Content-Type", "application/json")
-    json.NewEncoder(w).Encode(data)
-}
+	site := web.New(a.store, a.wl, logs)
+	site.Airports = a.airports
+	site.LocalAirports = cfg.LocalAirports
+	site.Noise = a.meter
+	site.PredictMaxAge = cfg.PredictMaxAge.Duration
+	if rs != nil {
+		site.Clock = rs.clock.Now
+	}
+	server := &http.Server{Addr: ":4545", Handler: site.Handler()}
 
-// updateWebData updates the global aircraft data for the web server
-func updateWebData(aircraftList []WebAircraftInfo, updateTime string) {
-    aircraftMutex.Lock()
-    currentAircraft = aircraftList
-    lastUpdate = updateTime
-    aircraftMutex.Unlock()
-}
+	// Start web server in a goroutine
+	webLog := logs.For(logging.ComponentWeb)
+	serverErr := make(chan error, 1)
+	go func() {
+		webLog.Info("starting web server", "url", "http://localhost:4545", "api", "http://localhost:4545/api")
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serverErr <- err
+		}
+	}()
 
-func main() {
-	timeout := 10 * time.Second
-	client := &http.Client{Timeout: timeout}
-	ctx := context.Background()
+	logger.Info("starting aircraft monitoring", "interval", "5m")
 
-	// Set up web server
-	http.HandleFunc("/", aircraftHandler)
-	http.HandleFunc("/api", apiHandler)
-	
-	// Start web server in a goroutine
+	// Poll every 5 minutes until the context is cancelled, or replay until the recordings end.
+	loopDone := make(chan struct{})
 	go func() {
-		log.Printf("Starting web server on http://localhost:4545")
-		log.Printf("Visit http://localhost:4545 to view aircraft data")
-		log.Printf("API endpoint available at http://localhost:4545/api")
-		if err := http.ListenAndServe(":4545", nil); err != nil {
-			log.Fatal("Web server failed to start:", err)
+		defer close(loopDone)
+		if rs != nil {
+			replayLoop(ctx, mon, rs, logger)
+			return
 		}
+		mon.Run(ctx, 5*time.Minute)
 	}()
 
-	fmt.Println("Starting aircraft monitoring over North London area...")
-	fmt.Println("Checking every 5 minutes. Press Ctrl+C to stop.")
-	fmt.Println("Web server running on http://localhost:4545")
+	select {
+	case <-ctx.Done():
+		logger.Info("shutdown requested, draining")
+	case err := <-serverErr:
+		webLog.Error("web server failed", "err", err)
+		status = 1
+	}
+	stop()
+
+	deadline := time.NewTimer(cfg.ShutdownTimeout.Duration)
+	defer deadline.Stop()
+	select {
+	case <-loopDone:
+	case <-deadline.C:
+		logger.Warn("enrichment did not drain before the shutdown deadline", "timeout", cfg.ShutdownTimeout.Duration)
+		status = 1
+	}
 
-	// Run initial check
-	checkAircraftInArea(ctx, client)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout.Duration)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		webLog.Error("web server shutdown", "err", err)
+		status = 1
+	}
+	status = max(status, a.close())
+	if publisher != nil {
+		if err := publisher.Close(shutdownCtx); err != nil {
+			logs.For(logging.ComponentMQTT).Error("failed to disconnect cleanly", "err", err)
+			status = 1
+		}
+	}
 
-	// Set up ticker for 5-minute intervals
-	ticker := time.NewTicker(5 * time.Minute)
-	defer ticker.Stop()
+	logger.Info("stopped", "status", status)
+	return status
+}
 
-	// Run the check every 5 minutes
-	for range ticker.C {
-		checkAircraftInArea(ctx, client)
+// openReplay opens the recordings and a clock starting at from, or at the
+// earliest recording when from is empty, along with the adsbdb responses of
+// the first recording archive that holds any.
+func openReplay(paths []string, speed float64, from string) (*replaySession, error) {
+	if speed <= 0 {
+		return nil, fmt.Errorf("-replay-speed must be positive, got %g", speed)
+	}
+	rs := &replaySession{}
+	now := func() time.Time { return rs.clock.Now() }
+	var start time.Time
+	for _, path := range paths {
+		path = strings.TrimSpace(path)
+		rec, source, err := replay.Open(path, now)
+		if err != nil {
+			return nil, err
+		}
+		if info, err := os.Stat(path); err == nil && info.IsDir() && rs.lookups == nil {
+			if rs.lookups, err = replay.OpenResponses(path, "adsbdb", now); err != nil {
+				return nil, err
+			}
+		}
+		for _, f := range rs.feeds {
+			if f.Name == source {
+				return nil, fmt.Errorf("only one %s recording can be replayed at a time", source)
+			}
+		}
+		rs.recordings = append(rs.recordings, rec)
+		rs.feeds = append(rs.feeds, fusion.Feed{Name: source, Source: rec})
+		if start.IsZero() || rec.Start().Before(start) {
+			start = rec.Start()
+		}
 	}
+	if from != "" {
+		t, err := time.Parse(time.RFC3339, from)
+		if err != nil {
+			return nil, fmt.Errorf("-replay-from must be an RFC 3339 time: %w", err)
+		}
+		start = t
+	}
+	rs.clock = replay.NewClock(start, speed)
+	return rs, nil
 }
 
+// replayLoop runs a cycle every five minutes of replayed time until every
+// recording has ended, leaving the last cycle on the board.
+func replayLoop(ctx context.Context, mon *monitor.Monitor, rs *replaySession, logger *slog.Logger) {
+	ticker := time.NewTicker(rs.clock.Wall(5 * time.Minute))
+	defer ticker.Stop()
+	for {
+		mon.RunCycle(ctx)
+		finished := true
+		for _, rec := range rs.recordings {
+			finished = finished && rec.Finished()
+		}
+		if finished {
+			logger.Info("replay finished", "at", rs.clock.Now().Format(time.RFC3339))
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
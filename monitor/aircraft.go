@@ -0,0 +1,144 @@
+package monitor
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"AirTraffic-Monitor/geo"
+	"AirTraffic-Monitor/watchlist"
+)
+
+// AircraftInfo holds display-ready aircraft information for the board, API and publishers.
+type AircraftInfo struct {
+	ICAO24              string
+	Callsign            string
+	Registration        string
+	Owner               string
+	OperatorCode        string `json:",omitempty"` // ICAO code of the registered operator, from adsbdb
+	Airline             string `json:",omitempty"` // operator from the callsign prefix, e.g. "Ryanair"
+	AirlineIATA         string `json:",omitempty"`
+	AirlineCallsign     string `json:",omitempty"` // radiotelephony callsign, e.g. "RYANAIR"
+	Manufacturer        string
+	Type                string
+	ICAOType            string
+	TypeFamily          string `json:",omitempty"` // e.g. "Airbus A320 family"
+	TypeDescription     string `json:",omitempty"` // ICAO Doc 8643, e.g. "L2J"
+	WakeCategory        string `json:",omitempty"` // L, M, H or J
+	Engines             int    `json:",omitempty"`
+	EngineType          string `json:",omitempty"` // Jet, Turboprop, Piston…
+	Category            int    `json:",omitempty"` // ADS-B emitter category from OpenSky
+	Kind                string // airliner, business, light, helicopter, military…
+	Origin              string
+	Destination         string
+	OriginAirport       *AirportInfo `json:",omitempty"`
+	DestinationAirport  *AirportInfo `json:",omitempty"`
+	RouteKm             *float64     `json:",omitempty"` // great-circle origin to destination
+	RouteProgress       *float64     `json:",omitempty"` // percent of the great-circle route flown
+	RouteScore          *float64     `json:",omitempty"` // 0-1, how well position and track fit the route
+	RouteConfidence     string       `json:",omitempty"` // high, medium or low
+	RouteNotes          []string     `json:",omitempty"` // why the route scored below 1
+	Movement            string       `json:",omitempty"` // arrival, departure or overflight
+	LocalAirport        string       `json:",omitempty"` // the local airport arrived at or departed from
+	Status              string       `json:",omitempty"` // board status, e.g. APPROACH, CLIMBING, LANDED
+	Holding             string       `json:",omitempty"` // ident of the stack the aircraft is holding in
+	LastUpdated         string
+	PositionTime        *time.Time `json:",omitempty"` // when the position was measured
+	Latitude            *float64   `json:",omitempty"`
+	Longitude           *float64   `json:",omitempty"`
+	BaroAltitude        *float64   `json:",omitempty"` // metres
+	OnGround            bool
+	Velocity            *float64          `json:",omitempty"` // m/s
+	Track               *float64          `json:",omitempty"` // degrees
+	VerticalRate        *float64          `json:",omitempty"` // m/s
+	RawPosition         *geo.LatLon       `json:",omitempty"` // as reported, before smoothing
+	TrackQuality        string            `json:",omitempty"` // new, good, fair or poor
+	TrackScore          *float64          `json:",omitempty"` // 0-1
+	Outlier             string            `json:",omitempty"` // why this cycle's report was rejected
+	Sources             []string          `json:",omitempty"` // position sources that reported it, preferred first
+	Provenance          map[string]string `json:",omitempty"` // field group to the source it was taken from
+	AnomalyScore        float64           `json:",omitempty"` // 0-1, how suspicious the contact looks
+	Anomalies           []Anomaly         `json:",omitempty"` // the reasons behind AnomalyScore
+	Squawk              string            `json:",omitempty"`
+	DistanceKm          *float64          `json:",omitempty"`                     // from the configured home point
+	RegistrationCountry string            `json:"registration_country,omitempty"` // from the ICAO24 address block
+	LikelyMilitary      bool              `json:"likely_military"`                // ICAO24 in a known military block
+	Watched             bool
+	WatchLabels         []string    `json:",omitempty"`
+	Estimate            *Prediction `json:",omitempty"` // dead-reckoned position, filled in per API request
+}
+
+// AirportInfo is a route airport, merged from adsbdb and the airport table.
+type AirportInfo struct {
+	ICAO        string
+	IATA        string `json:",omitempty"`
+	Name        string
+	City        string `json:",omitempty"`
+	Country     string `json:",omitempty"`
+	CountryISO  string `json:",omitempty"`
+	Lat         float64
+	Lon         float64
+	ElevationFt float64
+}
+
+// RouteSummary describes route progress for the board, e.g. "62% of 1,234 km",
+// or "" when the route is unknown.
+func (a AircraftInfo) RouteSummary() string {
+	if a.RouteKm == nil || a.RouteProgress == nil {
+		return ""
+	}
+	return fmt.Sprintf("%.0f%% of %s km", *a.RouteProgress, thousands(int(*a.RouteKm+0.5)))
+}
+
+// RouteDoubtful reports whether the route should be shown as unconfirmed.
+func (a AircraftInfo) RouteDoubtful() bool {
+	return a.RouteConfidence == RouteConfidenceMedium || a.RouteConfidence == RouteConfidenceLow
+}
+
+// RouteNote joins the route notes for a tooltip or log line.
+func (a AircraftInfo) RouteNote() string {
+	return strings.Join(a.RouteNotes, "; ")
+}
+
+// FlightNumber is the IATA flight number ("BA 123") when the callsign is the
+// airline prefix followed by digits, and the callsign otherwise.
+func (a AircraftInfo) FlightNumber() string {
+	if a.AirlineIATA != "" && len(a.Callsign) > 3 {
+		if n := strings.TrimLeft(a.Callsign[3:], "0"); n != "" && strings.Trim(n, "0123456789") == "" {
+			return a.AirlineIATA + " " + n
+		}
+	}
+	return a.Callsign
+}
+
+// Altitude formats the barometric altitude for the board, e.g. "12,300 ft".
+func (a AircraftInfo) Altitude() string {
+	switch {
+	case a.OnGround:
+		return "GND"
+	case a.BaroAltitude == nil:
+		return "-"
+	}
+	return thousands(int(*a.BaroAltitude*geo.MetresToFeet+0.5)) + " ft"
+}
+
+// thousands formats n with comma separators.
+func thousands(n int) string {
+	s := fmt.Sprint(n)
+	for i := len(s) - 3; i > 0; i -= 3 {
+		s = s[:i] + "," + s[i:]
+	}
+	return s
+}
+
+// Target returns the fields watchlist entries match on.
+func (a AircraftInfo) Target() watchlist.Target {
+	return watchlist.Target{
+		ICAO24:       a.ICAO24,
+		Registration: a.Registration,
+		Owner:        a.Owner,
+		ICAOType:     a.ICAOType,
+		Type:         a.Type,
+		Callsign:     a.Callsign,
+	}
+}
@@ -0,0 +1,233 @@
+package monitor
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"AirTraffic-Monitor/airline"
+	"AirTraffic-Monitor/fusion"
+	"AirTraffic-Monitor/geo"
+	"AirTraffic-Monitor/icaoaddr"
+)
+
+// Anomaly kinds, each a sign of a spoofed, corrupt or misconfigured transponder.
+const (
+	AnomalyPositionJump     = "position_jump"     // the track filter rejected the reported position
+	AnomalySpeed            = "impossible_speed"  // reported ground speed beyond any civil aircraft
+	AnomalyVerticalRate     = "vertical_rate"     // altitude moved against the reported vertical rate
+	AnomalyAltitude         = "altitude_mismatch" // barometric and geometric altitude disagree
+	AnomalyAddressCountry   = "address_country"   // ICAO24 block state differs from the registration's
+	AnomalyDuplicateAddress = "duplicate_address" // the same ICAO24 reported in two places at once
+	AnomalyOperator         = "operator_mismatch" // callsign prefix differs from the registered operator
+)
+
+// anomalyWeights is how much each kind alone says about a contact. Kinds with
+// innocent explanations (leased aircraft, re-registrations) weigh less.
+var anomalyWeights = map[string]float64{
+	AnomalyPositionJump:     0.4,
+	AnomalySpeed:            0.5,
+	AnomalyVerticalRate:     0.3,
+	AnomalyAltitude:         0.3,
+	AnomalyAddressCountry:   0.3,
+	AnomalyDuplicateAddress: 0.6,
+	AnomalyOperator:         0.2,
+}
+
+const (
+	msToKnots = 1.94384
+	// Ground speed above maxCivilSpeedMS (about 680 kt) is flagged unless the
+	// address is in a military block.
+	maxCivilSpeedMS = 350
+	// Vertical rates beyond maxVerticalRateMS (about 12,000 ft/min) are flagged.
+	maxVerticalRateMS = 60
+	// A climb or descent is only checked against the altitude change when
+	// both reports give at least minCheckedRateMS and the altitude moved at
+	// least minContraryM the other way, within maxRateGap.
+	minCheckedRateMS = 2.5
+	minContraryM     = 300
+	maxRateGap       = 10 * time.Minute
+	// Barometric and geometric altitude may differ with the weather, but not
+	// by more than maxAltitudeSplitM.
+	maxAltitudeSplitM = 1000
+	// Two sources' positions more than duplicateKm apart, after allowing for
+	// the distance flown between them, mean two transmitters share an address.
+	// Only positions measured within duplicateWindow of each other are compared.
+	duplicateKm     = 5
+	duplicateWindow = 30 * time.Second
+	// Aircraft not seen for anomalyForget are dropped from the detector.
+	anomalyForget = 15 * time.Minute
+)
+
+// Anomaly is one reason a contact looks suspicious.
+type Anomaly struct {
+	Kind   string  `json:"kind"`
+	Detail string  `json:"detail"`
+	Weight float64 `json:"weight"`
+}
+
+// anomalySample is what the detector remembers of an aircraft's last report.
+type anomalySample struct {
+	at       time.Time
+	altitude *float64
+	rate     *float64
+	kinds    map[string]bool
+}
+
+// anomalyDetector checks each cycle's aircraft for signs of spoofing and
+// remembers enough of the previous cycle to compare altitudes and report only
+// newly seen anomalies as events.
+type anomalyDetector struct {
+	last map[string]anomalySample
+}
+
+func newAnomalyDetector() *anomalyDetector {
+	return &anomalyDetector{last: make(map[string]anomalySample)}
+}
+
+// update fills Anomalies and AnomalyScore on each aircraft, using merged for
+// the reports as received from each source, and returns an event for every
+// aircraft with a kind of anomaly it did not have in the previous cycle. It
+// runs after smoothTracks, whose outliers it reports as position jumps.
+func (d *anomalyDetector) update(list []AircraftInfo, merged []fusion.Aircraft, now time.Time) []Event {
+	reports := make(map[string]fusion.Aircraft, len(merged))
+	for _, a := range merged {
+		reports[a.ICAO24] = a
+	}
+	var events []Event
+	seen := make(map[string]bool, len(list))
+	for i := range list {
+		a := &list[i]
+		seen[a.ICAO24] = true
+		raw := reports[a.ICAO24]
+		prev, hasPrev := d.last[a.ICAO24]
+
+		var found []Anomaly
+		add := func(kind, format string, args ...any) {
+			found = append(found, Anomaly{Kind: kind, Detail: fmt.Sprintf(format, args...), Weight: anomalyWeights[kind]})
+		}
+		if a.Outlier != "" && a.RawPosition != nil && a.Latitude != nil && a.Longitude != nil {
+			off := geo.HaversineKm(*a.RawPosition, geo.LatLon{Lat: *a.Latitude, Lon: *a.Longitude})
+			add(AnomalyPositionJump, "%s: reported %.1f km from the predicted position", a.Outlier, off)
+		}
+		if v := reportedSpeed(raw); v != nil && *v > maxCivilSpeedMS && !a.LikelyMilitary {
+			add(AnomalySpeed, "ground speed %.0f kt", *v*msToKnots)
+		}
+		alt, rate := raw.BaroAltitude, raw.VerticalRate
+		if rate != nil && math.Abs(*rate) > maxVerticalRateMS {
+			add(AnomalyVerticalRate, "vertical rate %.0f ft/min", *rate*geo.MetresToFeet*60)
+		} else if hasPrev && contraryAltitude(prev, alt, rate, contactTime(raw, now)) {
+			add(AnomalyVerticalRate, "altitude went from %.0f to %.0f ft while reporting %+.0f ft/min",
+				*prev.altitude*geo.MetresToFeet, *alt*geo.MetresToFeet, *rate*geo.MetresToFeet*60)
+		}
+		if alt != nil && raw.GeoAltitude != nil && !raw.OnGround && math.Abs(*alt-*raw.GeoAltitude) > maxAltitudeSplitM {
+			add(AnomalyAltitude, "barometric %.0f ft, geometric %.0f ft", *alt*geo.MetresToFeet, *raw.GeoAltitude*geo.MetresToFeet)
+		}
+		if block, ok := icaoaddr.Country(a.ICAO24); ok && a.Registration != "" {
+			if reg, ok := icaoaddr.RegistrationCountry(a.Registration); ok && reg != block && !strings.HasPrefix(block, "ICAO") {
+				add(AnomalyAddressCountry, "address allocated to %s, registration %s is from %s", block, a.Registration, reg)
+			}
+		}
+		if detail := duplicatePositions(raw); detail != "" {
+			add(AnomalyDuplicateAddress, "%s", detail)
+		}
+		if prefix, ok := airline.Prefix(a.Callsign); ok && a.OperatorCode != "" && !strings.EqualFold(prefix, a.OperatorCode) {
+			add(AnomalyOperator, "callsign %s, registered operator %s", a.Callsign, strings.ToUpper(a.OperatorCode))
+		}
+
+		a.Anomalies, a.AnomalyScore = found, anomalyScore(found)
+		kinds := make(map[string]bool, len(found))
+		var fresh []string
+		for _, f := range found {
+			kinds[f.Kind] = true
+			if !prev.kinds[f.Kind] {
+				fresh = append(fresh, f.Kind+": "+f.Detail)
+			}
+		}
+		if len(fresh) > 0 {
+			events = append(events, newEvent(EventAnomaly, *a, now, strings.Join(fresh, "; ")))
+		}
+		d.last[a.ICAO24] = anomalySample{at: contactTime(raw, now), altitude: alt, rate: rate, kinds: kinds}
+	}
+	for icao, s := range d.last {
+		if !seen[icao] && now.Sub(s.at) > anomalyForget {
+			delete(d.last, icao)
+		}
+	}
+	return events
+}
+
+// contactTime is when the aircraft was last heard, or now when no source said.
+func contactTime(a fusion.Aircraft, now time.Time) time.Time {
+	if a.LastContact == 0 {
+		return now
+	}
+	return time.Unix(a.LastContact, 0)
+}
+
+// reportedSpeed is the fastest ground speed any source reported.
+func reportedSpeed(a fusion.Aircraft) *float64 {
+	var fastest *float64
+	for _, st := range a.Reports {
+		if st.Velocity != nil && (fastest == nil || *st.Velocity > *fastest) {
+			fastest = st.Velocity
+		}
+	}
+	return fastest
+}
+
+// contraryAltitude reports whether the altitude moved clearly the opposite way
+// to the vertical rate both this report and the previous one gave.
+func contraryAltitude(prev anomalySample, alt, rate *float64, at time.Time) bool {
+	if prev.altitude == nil || prev.rate == nil || alt == nil || rate == nil {
+		return false
+	}
+	gap := at.Sub(prev.at)
+	if gap <= 0 || gap > maxRateGap {
+		return false
+	}
+	if math.Abs(*rate) < minCheckedRateMS || math.Abs(*prev.rate) < minCheckedRateMS || (*rate > 0) != (*prev.rate > 0) {
+		return false
+	}
+	change := *alt - *prev.altitude
+	return math.Abs(change) >= minContraryM && (change > 0) != (*rate > 0)
+}
+
+// duplicatePositions describes two sources placing the aircraft too far apart
+// to be one transmitter, or returns "".
+func duplicatePositions(a fusion.Aircraft) string {
+	sources := make([]string, 0, len(a.Reports))
+	for s, st := range a.Reports {
+		if st.Latitude != nil && st.Longitude != nil && st.TimePosition != 0 {
+			sources = append(sources, s)
+		}
+	}
+	sort.Strings(sources)
+	for i, s1 := range sources {
+		for _, s2 := range sources[i+1:] {
+			r1, r2 := a.Reports[s1], a.Reports[s2]
+			gap := math.Abs(float64(r1.TimePosition - r2.TimePosition))
+			if gap > duplicateWindow.Seconds() {
+				continue
+			}
+			dist := geo.HaversineKm(geo.LatLon{Lat: *r1.Latitude, Lon: *r1.Longitude}, geo.LatLon{Lat: *r2.Latitude, Lon: *r2.Longitude})
+			flown := gap * maxCivilSpeedMS / 1000
+			if dist-flown > duplicateKm {
+				return fmt.Sprintf("%s and %s positions %.1f km apart", s1, s2, dist)
+			}
+		}
+	}
+	return ""
+}
+
+// anomalyScore combines the weights as independent evidence: 0 for none,
+// approaching 1 as reasons accumulate.
+func anomalyScore(found []Anomaly) float64 {
+	clean := 1.0
+	for _, f := range found {
+		clean *= 1 - f.Weight
+	}
+	return math.Round((1-clean)*100) / 100
+}
@@ -0,0 +1,209 @@
+package monitor
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"AirTraffic-Monitor/fusion"
+	"AirTraffic-Monitor/geo"
+	"AirTraffic-Monitor/opensky"
+)
+
+func TestAnomalies(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	// report returns a source's report of a cruising aircraft, measured now.
+	report := func() opensky.State {
+		return opensky.State{ICAO24: "4ca123", TimePosition: now.Unix(), LastContact: now.Unix(),
+			Latitude: f(51.5), Longitude: f(-0.1), BaroAltitude: f(3000), GeoAltitude: f(3100),
+			Velocity: f(200), VerticalRate: f(0)}
+	}
+	tests := []struct {
+		name      string
+		a         AircraftInfo
+		reports   map[string]opensky.State
+		prev      *anomalySample
+		wantKinds []string
+		wantScore float64
+	}{
+		{
+			name:    "clean",
+			a:       AircraftInfo{Callsign: "RYR1AB", Registration: "EI-ABC", OperatorCode: "RYR"},
+			reports: map[string]opensky.State{"opensky": report()},
+		},
+		{
+			name: "position rejected by the track filter",
+			a: AircraftInfo{Outlier: "implausible speed", RawPosition: &geo.LatLon{Lat: 51.7, Lon: -0.1},
+				Latitude: f(51.5), Longitude: f(-0.1)},
+			reports:   map[string]opensky.State{"opensky": report()},
+			wantKinds: []string{AnomalyPositionJump}, wantScore: 0.4,
+		},
+		{
+			name: "supersonic",
+			a:    AircraftInfo{},
+			reports: map[string]opensky.State{"opensky": func() opensky.State {
+				s := report()
+				s.Velocity = f(400)
+				return s
+			}()},
+			wantKinds: []string{AnomalySpeed}, wantScore: 0.5,
+		},
+		{
+			name: "supersonic in a military block",
+			a:    AircraftInfo{LikelyMilitary: true},
+			reports: map[string]opensky.State{"opensky": func() opensky.State {
+				s := report()
+				s.Velocity = f(400)
+				return s
+			}()},
+		},
+		{
+			name: "vertical rate beyond any airliner",
+			reports: map[string]opensky.State{"opensky": func() opensky.State {
+				s := report()
+				s.VerticalRate = f(-80)
+				return s
+			}()},
+			wantKinds: []string{AnomalyVerticalRate}, wantScore: 0.3,
+		},
+		{
+			name: "climbed while reporting a descent",
+			reports: map[string]opensky.State{"opensky": func() opensky.State {
+				s := report()
+				s.BaroAltitude, s.GeoAltitude, s.VerticalRate = f(3500), f(3600), f(-5)
+				return s
+			}()},
+			prev:      &anomalySample{at: now.Add(-time.Minute), altitude: f(3000), rate: f(-5)},
+			wantKinds: []string{AnomalyVerticalRate}, wantScore: 0.3,
+		},
+		{
+			name: "climbed while reporting a descent, too long ago to compare",
+			reports: map[string]opensky.State{"opensky": func() opensky.State {
+				s := report()
+				s.BaroAltitude, s.GeoAltitude, s.VerticalRate = f(3500), f(3600), f(-5)
+				return s
+			}()},
+			prev: &anomalySample{at: now.Add(-time.Hour), altitude: f(3000), rate: f(-5)},
+		},
+		{
+			name: "barometric and geometric altitude apart",
+			reports: map[string]opensky.State{"opensky": func() opensky.State {
+				s := report()
+				s.GeoAltitude = f(5000)
+				return s
+			}()},
+			wantKinds: []string{AnomalyAltitude}, wantScore: 0.3,
+		},
+		{
+			name:      "Irish address, British registration",
+			a:         AircraftInfo{Registration: "G-EZAA"},
+			reports:   map[string]opensky.State{"opensky": report()},
+			wantKinds: []string{AnomalyAddressCountry}, wantScore: 0.3,
+		},
+		{
+			name: "two sources 20 km apart",
+			reports: map[string]opensky.State{"opensky": report(), "sbs": func() opensky.State {
+				s := report()
+				s.Latitude = f(51.68)
+				return s
+			}()},
+			wantKinds: []string{AnomalyDuplicateAddress}, wantScore: 0.6,
+		},
+		{
+			name: "two sources apart, but a minute between them",
+			reports: map[string]opensky.State{"opensky": report(), "sbs": func() opensky.State {
+				s := report()
+				s.Latitude, s.TimePosition = f(51.68), now.Add(-time.Minute).Unix()
+				return s
+			}()},
+		},
+		{
+			name:      "callsign of another operator",
+			a:         AircraftInfo{Callsign: "EZY12AB", OperatorCode: "RYR"},
+			reports:   map[string]opensky.State{"opensky": report()},
+			wantKinds: []string{AnomalyOperator}, wantScore: 0.2,
+		},
+		{
+			name: "reasons combine as independent evidence",
+			a:    AircraftInfo{Registration: "G-EZAA", Callsign: "EZY12AB", OperatorCode: "RYR"},
+			reports: map[string]opensky.State{"opensky": func() opensky.State {
+				s := report()
+				s.Velocity = f(400)
+				return s
+			}()},
+			wantKinds: []string{AnomalySpeed, AnomalyAddressCountry, AnomalyOperator}, wantScore: 0.72,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := newAnomalyDetector()
+			if tt.prev != nil {
+				d.last["4CA123"] = *tt.prev
+			}
+			a := tt.a
+			a.ICAO24 = "4CA123"
+			list := []AircraftInfo{a}
+			// opensky is the preferred source, so the merged state is its report.
+			merged := []fusion.Aircraft{{State: tt.reports["opensky"], Reports: tt.reports}}
+			merged[0].ICAO24 = "4CA123"
+			events := d.update(list, merged, now)
+
+			var kinds []string
+			for _, an := range list[0].Anomalies {
+				kinds = append(kinds, an.Kind)
+				if an.Detail == "" || an.Weight != anomalyWeights[an.Kind] {
+					t.Errorf("%s: detail %q weight %v", an.Kind, an.Detail, an.Weight)
+				}
+			}
+			if strings.Join(kinds, ",") != strings.Join(tt.wantKinds, ",") || list[0].AnomalyScore != tt.wantScore {
+				t.Errorf("kinds %v score %v, want %v and %v", kinds, list[0].AnomalyScore, tt.wantKinds, tt.wantScore)
+			}
+			if wantEvents := min(len(tt.wantKinds), 1); len(events) != wantEvents {
+				t.Errorf("%d events, want %d", len(events), wantEvents)
+			}
+		})
+	}
+}
+
+func TestAnomalyEventsOnlyForNewKinds(t *testing.T) {
+	start := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	d := newAnomalyDetector()
+	cycle := func(after time.Duration, a AircraftInfo) []Event {
+		now := start.Add(after)
+		a.ICAO24 = "4CA123"
+		st := opensky.State{ICAO24: "4CA123", LastContact: now.Unix()}
+		return d.update([]AircraftInfo{a}, []fusion.Aircraft{{State: st, Reports: map[string]opensky.State{"opensky": st}}}, now)
+	}
+	swapped := AircraftInfo{Callsign: "EZY12AB", OperatorCode: "RYR"}
+	both := AircraftInfo{Callsign: "EZY12AB", OperatorCode: "RYR", Registration: "G-EZAA"}
+
+	tests := []struct {
+		after      time.Duration
+		a          AircraftInfo
+		wantDetail string // "" for no event
+	}{
+		{0, swapped, "operator_mismatch: callsign EZY12AB, registered operator RYR"},
+		{10 * time.Second, swapped, ""},
+		{20 * time.Second, both, "address_country: address allocated to Ireland, registration G-EZAA is from United Kingdom"},
+		{30 * time.Second, AircraftInfo{}, ""},
+		{40 * time.Second, swapped, "operator_mismatch: callsign EZY12AB, registered operator RYR"},
+	}
+	for _, tt := range tests {
+		events := cycle(tt.after, tt.a)
+		var got string
+		if len(events) == 1 && events[0].Type == EventAnomaly {
+			got = events[0].Detail
+		} else if len(events) > 1 {
+			t.Fatalf("+%v: %d events", tt.after, len(events))
+		}
+		if got != tt.wantDetail {
+			t.Errorf("+%v: event %q, want %q", tt.after, got, tt.wantDetail)
+		}
+	}
+
+	// Aircraft not seen for a while are forgotten.
+	d.update(nil, nil, start.Add(40*time.Second+anomalyForget+time.Second))
+	if len(d.last) != 0 {
+		t.Errorf("detector still remembers %d aircraft", len(d.last))
+	}
+}
@@ -0,0 +1,49 @@
+package monitor
+
+import (
+	"fmt"
+	"io"
+)
+
+// ConsoleTable writes the human-readable "Reg: … | Owner: …" table, separate from diagnostics.
+type ConsoleTable struct {
+	W io.Writer // nil disables the table
+}
+
+// Print writes one cycle's table.
+func (t ConsoleTable) Print(timestamp, region string, list []AircraftInfo) {
+	if t.W == nil {
+		return
+	}
+	fmt.Fprintf(t.W, "\n=== Aircraft over %s at %s ===\n", region, timestamp)
+	if len(list) == 0 {
+		fmt.Fprintln(t.W, "No aircraft currently reported.")
+		return
+	}
+	for _, a := range list {
+		prefix := ""
+		if a.Watched {
+			prefix = "* "
+		}
+		if a.LikelyMilitary {
+			prefix += "[MIL] "
+		}
+		reg := a.Registration
+		if reg == "" {
+			reg = a.ICAO24
+		}
+		airline := ""
+		if a.Airline != "" {
+			airline = fmt.Sprintf("Airline: %s | ", a.Airline)
+		}
+		destination := a.Destination
+		if a.RouteConfidence == RouteConfidenceLow {
+			destination += " (route unlikely: " + a.RouteNote() + ")"
+		} else if a.RouteDoubtful() {
+			destination += " (route unconfirmed)"
+		}
+		fmt.Fprintf(t.W, "%sReg: %s | %sOwner: %s | Manufacturer: %s | Type: %s | Origin: %s | Destination: %s\n",
+			prefix, reg, airline, a.Owner, a.Manufacturer, a.Type, a.Origin, destination)
+	}
+	fmt.Fprintf(t.W, "%d aircraft. Data sources: OpenSky Network (live positions) + adsbdb (aircraft metadata + routes).\n", len(list))
+}
@@ -0,0 +1,85 @@
+package monitor
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// Event types emitted between cycles.
+const (
+	EventEnter = "enter" // aircraft appeared in the area
+	EventExit  = "exit"  // aircraft no longer reported in the area
+	EventAlert = "alert" // a watched aircraft entered the area
+
+	EventTakeoff  = "takeoff"   // lifted off from a local airport
+	EventLanding  = "landing"   // touched down at a local airport
+	EventGoAround = "go_around" // climbed away from a low approach without landing
+
+	EventRunwayChange = "runway_change" // a local airport changed direction of operation
+
+	EventAnomaly = "anomaly" // an aircraft showed a kind of anomaly it did not have last cycle
+)
+
+// Event is a discrete change noticed between two monitoring cycles.
+type Event struct {
+	Time         time.Time `json:"time"`
+	Type         string    `json:"type"`
+	ICAO24       string    `json:"icao24"`
+	Registration string    `json:"registration,omitempty"`
+	Callsign     string    `json:"callsign,omitempty"`
+	Airport      string    `json:"airport,omitempty"` // takeoff, landing, go-around and runway change only
+	Runway       string    `json:"runway,omitempty"`  // likely runway end, e.g. "27R"
+	Detail       string    `json:"detail,omitempty"`
+}
+
+// EventTracker remembers the previous cycle's aircraft so it can report what changed.
+type EventTracker struct {
+	previous map[string]AircraftInfo
+}
+
+// NewEventTracker returns a tracker that treats every aircraft as new.
+func NewEventTracker() *EventTracker {
+	return &EventTracker{previous: make(map[string]AircraftInfo)}
+}
+
+// Update compares list with the previous cycle and returns enter, alert and exit events.
+func (t *EventTracker) Update(list []AircraftInfo, now time.Time) []Event {
+	var events []Event
+	current := make(map[string]AircraftInfo, len(list))
+	for _, a := range list {
+		current[a.ICAO24] = a
+		if _, seen := t.previous[a.ICAO24]; seen {
+			continue
+		}
+		events = append(events, newEvent(EventEnter, a, now, ""))
+		if a.Watched {
+			events = append(events, newEvent(EventAlert, a, now, strings.Join(a.WatchLabels, ", ")))
+		}
+	}
+
+	var gone []string
+	for icao := range t.previous {
+		if _, ok := current[icao]; !ok {
+			gone = append(gone, icao)
+		}
+	}
+	sort.Strings(gone)
+	for _, icao := range gone {
+		events = append(events, newEvent(EventExit, t.previous[icao], now, ""))
+	}
+
+	t.previous = current
+	return events
+}
+
+func newEvent(kind string, a AircraftInfo, now time.Time, detail string) Event {
+	return Event{
+		Time:         now,
+		Type:         kind,
+		ICAO24:       a.ICAO24,
+		Registration: a.Registration,
+		Callsign:     a.Callsign,
+		Detail:       detail,
+	}
+}
@@ -0,0 +1,145 @@
+package monitor
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"AirTraffic-Monitor/airports"
+	"AirTraffic-Monitor/geo"
+)
+
+const (
+	// A go-around is a descent below goAroundFloorM on approach followed by a
+	// climb of at least goAroundClimbM without touching down.
+	goAroundFloorM = 300
+	goAroundClimbM = 150
+	// Approaches are tracked within approachRadiusKm of a local airport, below approachCeilingM.
+	approachRadiusKm = 15
+	approachCeilingM = 600
+	// A first sighting this low and close while climbing is taken as a takeoff
+	// missed between cycles.
+	liftoffRadiusKm = 8
+	liftoffCeilingM = 500
+	// The track must be within runwayAlignDeg of a runway heading to name the runway.
+	runwayAlignDeg = 30
+	// Tracks and positions further than this from the airport say little about the runway.
+	runwayEvidenceKm = 20
+)
+
+// AirportCoverageKm is how far around each local airport the polled area must
+// reach for takeoffs, landings, go-arounds and the runway direction to be seen.
+const AirportCoverageKm = runwayEvidenceKm
+
+// approach is an aircraft low on approach to a local airport.
+type approach struct {
+	airport string
+	runway  string
+	lowest  float64 // metres
+}
+
+// detectFlightEvents compares an aircraft with its state last cycle and returns
+// any takeoff, landing or go-around at a local airport. It updates cur's
+// approach state for the next cycle.
+func detectFlightEvents(a AircraftInfo, local map[string]airports.Airport, prev trendSample, hadPrev bool, cur *trendSample, rate *float64, now time.Time) []Event {
+	if cur.position == nil {
+		return nil
+	}
+	ap, dist, found := nearestAirport(local, *cur.position)
+	if !found {
+		return nil
+	}
+	climbing := rate != nil && *rate > levelRateMS
+
+	switch {
+	case hadPrev && prev.onGround && !a.OnGround && dist <= terminalRadiusKm:
+		// Lifted off since the last cycle.
+		return []Event{flightEvent(EventTakeoff, a, ap, takeoffRunway(ap, a, *cur.position, dist), now)}
+
+	case !hadPrev && !a.OnGround && climbing && dist <= liftoffRadiusKm &&
+		a.BaroAltitude != nil && *a.BaroAltitude <= liftoffCeilingM:
+		// First seen just after lifting off.
+		return []Event{flightEvent(EventTakeoff, a, ap, takeoffRunway(ap, a, *cur.position, dist), now)}
+
+	case hadPrev && !prev.onGround && a.OnGround && dist <= airportRadiusKm:
+		runway := ""
+		if prev.approach != nil && prev.approach.airport == ap.ICAO {
+			runway = prev.approach.runway
+		} else if prev.track != nil && prev.position != nil && geo.HaversineKm(ap.Position(), *prev.position) <= runwayEvidenceKm {
+			runway = likelyRunway(ap, *prev.track, *prev.position)
+		}
+		return []Event{flightEvent(EventLanding, a, ap, runway, now)}
+	}
+
+	if a.OnGround || a.BaroAltitude == nil {
+		return nil
+	}
+	alt := *a.BaroAltitude
+	if p := prev.approach; hadPrev && p != nil && !prev.onGround {
+		if p.lowest <= goAroundFloorM && climbing && alt >= p.lowest+goAroundClimbM {
+			ev := flightEvent(EventGoAround, a, local[p.airport], p.runway, now)
+			ev.Detail += fmt.Sprintf(", lowest %s ft", thousands(int(p.lowest*geo.MetresToFeet+0.5)))
+			return []Event{ev}
+		}
+		if p.airport == ap.ICAO && dist <= approachRadiusKm {
+			cur.approach = &approach{airport: p.airport, runway: p.runway, lowest: math.Min(p.lowest, alt)}
+			if cur.approach.runway == "" && a.Track != nil {
+				cur.approach.runway = likelyRunway(ap, *a.Track, *cur.position)
+			}
+			return nil
+		}
+	}
+	if dist <= approachRadiusKm && alt <= approachCeilingM && !climbing {
+		cur.approach = &approach{airport: ap.ICAO, lowest: alt}
+		if a.Track != nil {
+			cur.approach.runway = likelyRunway(ap, *a.Track, *cur.position)
+		}
+	}
+	return nil
+}
+
+// takeoffRunway picks the departure runway from the aircraft's track while it is
+// still close, or else from the direction it has flown from the airport.
+func takeoffRunway(ap airports.Airport, a AircraftInfo, pos geo.LatLon, dist float64) string {
+	switch {
+	case dist > runwayEvidenceKm:
+		return ""
+	case a.Track != nil:
+		return likelyRunway(ap, *a.Track, pos)
+	case dist >= 1:
+		return likelyRunway(ap, geo.BearingDeg(ap.Position(), pos), pos)
+	}
+	return ""
+}
+
+// likelyRunway returns the runway end whose heading best matches track. Parallel
+// runways are told apart by which extended centreline pos is nearer.
+func likelyRunway(ap airports.Airport, track float64, pos geo.LatLon) string {
+	best, bestDiff, bestOffset := "", runwayAlignDeg+1.0, math.Inf(1)
+	for _, rw := range ap.Runways {
+		if rw.Closed {
+			continue
+		}
+		for i, end := range rw.Ends {
+			diff := geo.AngleDiff(track, end.HeadingTrue)
+			if diff > runwayAlignDeg {
+				continue
+			}
+			offset := geo.CrossTrackKm(end.Position(), rw.Ends[1-i].Position(), pos)
+			// Parallel runways share a heading to within a few degrees.
+			if diff < bestDiff-5 || (math.Abs(diff-bestDiff) <= 5 && offset < bestOffset) {
+				best, bestDiff, bestOffset = end.Ident, diff, offset
+			}
+		}
+	}
+	return best
+}
+
+func flightEvent(kind string, a AircraftInfo, ap airports.Airport, runway string, now time.Time) Event {
+	ev := newEvent(kind, a, now, ap.ICAO)
+	ev.Airport, ev.Runway = ap.ICAO, runway
+	if runway != "" {
+		ev.Detail += " runway " + runway
+	}
+	return ev
+}
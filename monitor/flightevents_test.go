@@ -0,0 +1,169 @@
+package monitor
+
+import (
+	"testing"
+	"time"
+
+	"AirTraffic-Monitor/airports"
+	"AirTraffic-Monitor/geo"
+)
+
+func f(v float64) *float64 { return &v }
+
+func at(lat, lon float64) *geo.LatLon { return &geo.LatLon{Lat: lat, Lon: lon} }
+
+func heathrow(t *testing.T) map[string]airports.Airport {
+	t.Helper()
+	egll, ok := airports.Bundled().Lookup("EGLL")
+	if !ok {
+		t.Fatal("EGLL missing from the bundled airports")
+	}
+	return map[string]airports.Airport{"EGLL": egll}
+}
+
+func TestDetectFlightEvents(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		a       AircraftInfo
+		pos     *geo.LatLon
+		prev    trendSample
+		hadPrev bool
+		rate    *float64
+
+		wantType     string // "" for no event
+		wantRunway   string
+		wantApproach *approach // cur.approach afterwards
+	}{
+		{
+			name:     "lifted off between cycles, climbing out west on 27R",
+			a:        AircraftInfo{BaroAltitude: f(400), Track: f(270)},
+			pos:      at(51.4777, -0.52),
+			prev:     trendSample{onGround: true, position: at(51.4777, -0.45)},
+			hadPrev:  true,
+			rate:     f(10),
+			wantType: EventTakeoff, wantRunway: "27R",
+		},
+		{
+			name:     "first seen just after lifting off east from 09R",
+			a:        AircraftInfo{BaroAltitude: f(300), Track: f(90)},
+			pos:      at(51.4649, -0.40),
+			rate:     f(8),
+			wantType: EventTakeoff, wantRunway: "09R",
+		},
+		{
+			name:    "first seen climbing but too high for a takeoff",
+			a:       AircraftInfo{BaroAltitude: f(900), Track: f(90)},
+			pos:     at(51.4649, -0.40),
+			rate:    f(8),
+			hadPrev: false,
+		},
+		{
+			name:     "touched down after an approach to 27L",
+			a:        AircraftInfo{OnGround: true, BaroAltitude: f(0)},
+			pos:      at(51.4650, -0.46),
+			prev:     trendSample{approach: &approach{airport: "EGLL", runway: "27L", lowest: 60}},
+			hadPrev:  true,
+			wantType: EventLanding, wantRunway: "27L",
+		},
+		{
+			name:     "touched down without a tracked approach, runway from the last track",
+			a:        AircraftInfo{OnGround: true},
+			pos:      at(51.4777, -0.46),
+			prev:     trendSample{track: f(270), position: at(51.4777, -0.40)},
+			hadPrev:  true,
+			wantType: EventLanding, wantRunway: "27R",
+		},
+		{
+			name:     "climbed away from a low approach",
+			a:        AircraftInfo{BaroAltitude: f(420), Track: f(270)},
+			pos:      at(51.4650, -0.47),
+			prev:     trendSample{approach: &approach{airport: "EGLL", runway: "27L", lowest: 120}},
+			hadPrev:  true,
+			rate:     f(6),
+			wantType: EventGoAround, wantRunway: "27L",
+		},
+		{
+			name:         "approach continues and keeps its lowest height",
+			a:            AircraftInfo{BaroAltitude: f(250), Track: f(270)},
+			pos:          at(51.4650, -0.40),
+			prev:         trendSample{approach: &approach{airport: "EGLL", runway: "27L", lowest: 300}},
+			hadPrev:      true,
+			rate:         f(-3),
+			wantApproach: &approach{airport: "EGLL", runway: "27L", lowest: 250},
+		},
+		{
+			name:         "new approach below the ceiling picks the runway from the track",
+			a:            AircraftInfo{BaroAltitude: f(500), Track: f(90)},
+			pos:          at(51.4648, -0.58),
+			rate:         f(-3),
+			wantApproach: &approach{airport: "EGLL", runway: "09R", lowest: 500},
+		},
+		{
+			name: "low but outside the approach radius",
+			a:    AircraftInfo{BaroAltitude: f(500), Track: f(90)},
+			pos:  at(51.4648, -0.80),
+			rate: f(-3),
+		},
+		{
+			name:    "still taxiing",
+			a:       AircraftInfo{OnGround: true},
+			pos:     at(51.47, -0.46),
+			prev:    trendSample{onGround: true},
+			hadPrev: true,
+		},
+		{
+			name: "no position",
+			a:    AircraftInfo{BaroAltitude: f(300)},
+			rate: f(8),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.a.ICAO24, tt.a.Callsign = "400A0B", "BAW12"
+			cur := trendSample{position: tt.pos, track: tt.a.Track, onGround: tt.a.OnGround, at: now}
+			events := detectFlightEvents(tt.a, heathrow(t), tt.prev, tt.hadPrev, &cur, tt.rate, now)
+
+			switch {
+			case tt.wantType == "" && len(events) > 0:
+				t.Fatalf("got %+v, want no events", events)
+			case tt.wantType != "" && len(events) != 1:
+				t.Fatalf("got %d events, want one %s", len(events), tt.wantType)
+			case tt.wantType != "":
+				ev := events[0]
+				if ev.Type != tt.wantType || ev.Airport != "EGLL" || ev.Runway != tt.wantRunway || ev.ICAO24 != "400A0B" {
+					t.Errorf("got %s at %s runway %q, want %s at EGLL runway %q", ev.Type, ev.Airport, ev.Runway, tt.wantType, tt.wantRunway)
+				}
+			}
+
+			switch {
+			case tt.wantApproach == nil && cur.approach != nil:
+				t.Errorf("approach = %+v, want none", *cur.approach)
+			case tt.wantApproach != nil && cur.approach == nil:
+				t.Errorf("no approach, want %+v", *tt.wantApproach)
+			case tt.wantApproach != nil && *cur.approach != *tt.wantApproach:
+				t.Errorf("approach = %+v, want %+v", *cur.approach, *tt.wantApproach)
+			}
+		})
+	}
+}
+
+func TestLikelyRunwayParallels(t *testing.T) {
+	egll := heathrow(t)["EGLL"]
+	tests := []struct {
+		track float64
+		pos   geo.LatLon
+		want  string
+	}{
+		{270, geo.LatLon{Lat: 51.4777, Lon: -0.40}, "27R"}, // northern runway
+		{270, geo.LatLon{Lat: 51.4650, Lon: -0.40}, "27L"}, // southern runway
+		{90, geo.LatLon{Lat: 51.4775, Lon: -0.55}, "09L"},
+		{90, geo.LatLon{Lat: 51.4648, Lon: -0.55}, "09R"},
+		{180, geo.LatLon{Lat: 51.50, Lon: -0.46}, ""}, // crossing, no runway aligned
+	}
+	for _, tt := range tests {
+		if got := likelyRunway(egll, tt.track, tt.pos); got != tt.want {
+			t.Errorf("likelyRunway(%v°, %v) = %q, want %q", tt.track, tt.pos, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package monitor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"AirTraffic-Monitor/adsbdb"
+	"AirTraffic-Monitor/aircraftdb"
+	"AirTraffic-Monitor/icaoaddr"
+	"AirTraffic-Monitor/logging"
+	"AirTraffic-Monitor/opensky"
+)
+
+// ErrUnknownAircraft is returned by Lookup when neither adsbdb nor the local
+// registry knows the aircraft and no callsign was given to look up instead.
+var ErrUnknownAircraft = errors.New("unknown aircraft")
+
+// Lookup enriches one aircraft outside the polling cycle, as the board would
+// show it without a position. id is an ICAO24 address or a registration,
+// which adsbdb resolves to the address; callsign, when given, adds the route
+// and airline.
+func (m *Monitor) Lookup(ctx context.Context, id, callsign string) (AircraftInfo, error) {
+	adsbLog := m.logs.For(logging.ComponentADSBDB).With("source", "adsbdb")
+	id = strings.TrimSpace(id)
+	icao24 := strings.ToUpper(id)
+	if _, ok := icaoaddr.Parse(icao24); !ok {
+		if m.cfg.AircraftPrecedence == aircraftdb.LocalOnly {
+			return AircraftInfo{}, fmt.Errorf("registration lookups need adsbdb, which aircraft_db.precedence %q rules out; give the ICAO24 address", aircraftdb.LocalOnly)
+		}
+		a, err := m.adsbdb.Aircraft(ctx, icao24)
+		if errors.Is(err, adsbdb.ErrUnknownAircraft) {
+			return AircraftInfo{}, fmt.Errorf("%w: %s", ErrUnknownAircraft, id)
+		} else if err != nil {
+			return AircraftInfo{}, err
+		}
+		icao24 = strings.ToUpper(a.ModeS)
+	}
+
+	state := opensky.State{ICAO24: icao24, Callsign: strings.ToUpper(strings.TrimSpace(callsign))}
+	info := m.enrich(ctx, state, m.Clock().Format("2006-01-02 15:04:05"), adsbLog)
+	if ctx.Err() != nil {
+		return AircraftInfo{}, ctx.Err()
+	}
+	if info.Registration == "" && info.Type == "" && info.Owner == "" && info.OriginAirport == nil && info.Airline == "" {
+		return info, fmt.Errorf("%w: %s", ErrUnknownAircraft, id)
+	}
+	return info, nil
+}
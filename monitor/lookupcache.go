@@ -0,0 +1,118 @@
+package monitor
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"AirTraffic-Monitor/adsbdb"
+)
+
+// How long adsbdb answers are reused. An airframe's registration rarely
+// changes; a callsign's route holds for the flight. Rate limits and other
+// failures are not cached, so the next cycle asks again.
+const (
+	aircraftTTL = 6 * time.Hour
+	routeTTL    = 30 * time.Minute
+	unknownTTL  = 30 * time.Minute // adsbdb did not know the aircraft or callsign
+)
+
+// lookupCache keeps adsbdb answers per ICAO24 and per ICAO24 and callsign, so
+// an aircraft in view for an hour is looked up once rather than every cycle.
+// It is safe for concurrent use by the enrichment workers.
+type lookupCache struct {
+	mu       sync.Mutex
+	aircraft map[string]cachedLookup // by ICAO24
+	routes   map[string]cachedLookup // by ICAO24 and callsign
+}
+
+// cachedLookup is one answer: a value or a known-unknown error.
+type cachedLookup struct {
+	aircraft *adsbdb.Aircraft
+	route    *adsbdb.FlightRoute
+	err      error
+	expires  time.Time
+}
+
+func newLookupCache() *lookupCache {
+	return &lookupCache{aircraft: make(map[string]cachedLookup), routes: make(map[string]cachedLookup)}
+}
+
+// get returns the unexpired answer under key.
+func (c *lookupCache) get(m map[string]cachedLookup, key string, now time.Time) (cachedLookup, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := m[key]
+	if !ok || !now.Before(e.expires) {
+		return cachedLookup{}, false
+	}
+	return e, true
+}
+
+// put keeps e under key for ttl, or unknownTTL when adsbdb did not know it.
+// Other errors are not kept.
+func (c *lookupCache) put(m map[string]cachedLookup, key string, e cachedLookup, now time.Time, ttl time.Duration) {
+	switch {
+	case e.err == nil:
+	case errors.Is(e.err, adsbdb.ErrUnknownAircraft), errors.Is(e.err, adsbdb.ErrUnknownCallsign):
+		ttl = unknownTTL
+	default:
+		return
+	}
+	e.expires = now.Add(ttl)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	m[key] = e
+}
+
+// forget drops the expired answers.
+func (c *lookupCache) forget(now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, m := range []map[string]cachedLookup{c.aircraft, c.routes} {
+		for key, e := range m {
+			if !now.Before(e.expires) {
+				delete(m, key)
+			}
+		}
+	}
+}
+
+// adsbdbAircraft is adsbdb.Client.Aircraft through the cache.
+func (m *Monitor) adsbdbAircraft(ctx context.Context, icao24 string) (*adsbdb.Aircraft, error) {
+	now := m.Clock()
+	// Callers fill blanks in the record, so each gets its own copy.
+	if e, ok := m.lookups.get(m.lookups.aircraft, icao24, now); ok {
+		if e.err != nil {
+			return nil, e.err
+		}
+		a := *e.aircraft
+		return &a, nil
+	}
+	a, err := m.adsbdb.Aircraft(ctx, icao24)
+	e := cachedLookup{err: err}
+	if err == nil {
+		kept := *a
+		e.aircraft = &kept
+	}
+	m.lookups.put(m.lookups.aircraft, icao24, e, now, aircraftTTL)
+	return a, err
+}
+
+// adsbdbRoute looks up the route flown under callsign through the cache,
+// falling back to the callsign alone for aircraft adsbdb does not know.
+func (m *Monitor) adsbdbRoute(ctx context.Context, icao24, callsign string) (*adsbdb.FlightRoute, error) {
+	now := m.Clock()
+	key := icao24 + "/" + callsign
+	if e, ok := m.lookups.get(m.lookups.routes, key, now); ok {
+		return e.route, e.err
+	}
+	r, err := m.adsbdb.FlightRoute(ctx, icao24, callsign)
+	if errors.Is(err, adsbdb.ErrUnknownAircraft) {
+		// Known only to the local registry; the route can still be found by callsign alone.
+		r, err = m.adsbdb.Callsign(ctx, callsign)
+	}
+	m.lookups.put(m.lookups.routes, key, cachedLookup{route: r, err: err}, now, routeTTL)
+	return r, err
+}
@@ -0,0 +1,706 @@
+// Package monitor runs the polling pipeline: fetch live states from OpenSky,
+// enrich them via adsbdb, flag watched aircraft, derive events, update the
+// store and hand each cycle to the configured publishers.
+package monitor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"AirTraffic-Monitor/actype"
+	"AirTraffic-Monitor/adsbdb"
+	"AirTraffic-Monitor/aircraftdb"
+	"AirTraffic-Monitor/airline"
+	"AirTraffic-Monitor/airports"
+	"AirTraffic-Monitor/fusion"
+	"AirTraffic-Monitor/geo"
+	"AirTraffic-Monitor/holding"
+	"AirTraffic-Monitor/icaoaddr"
+	"AirTraffic-Monitor/logging"
+	"AirTraffic-Monitor/noise"
+	"AirTraffic-Monitor/opensky"
+	"AirTraffic-Monitor/track"
+	"AirTraffic-Monitor/watchlist"
+)
+
+// Config is the part of the application config the pipeline needs.
+type Config struct {
+	Region        string
+	BBox          geo.BBox
+	Home          geo.LatLon
+	EnrichWorkers int
+
+	// LocalAirports are the ICAO codes aircraft are classified against as
+	// arrivals, departures or overflights.
+	LocalAirports []string
+
+	// Holds are the holding stacks to watch.
+	Holds []holding.Stack
+
+	// AircraftPrecedence chooses between AircraftDB and adsbdb for airframe data;
+	// one of the aircraftdb.Prefer*/LocalOnly constants (default PreferADSBDB).
+	AircraftPrecedence string
+
+	// SourcePriority orders the position sources when their reports are
+	// equally fresh (default fusion.DefaultPriority).
+	SourcePriority []string
+}
+
+// Cycle is the outcome of one successful poll.
+type Cycle struct {
+	ID       uint64
+	Time     time.Time
+	Updated  string // display timestamp, as shown on the board
+	Aircraft []AircraftInfo
+	Events   []Event
+}
+
+// Publisher receives every completed cycle, e.g. to mirror it to MQTT.
+type Publisher interface {
+	Publish(ctx context.Context, c Cycle) error
+}
+
+// Monitor wires the upstream clients to the store. AircraftDB, Watchlist,
+// Noise, Feeds, Console and Publishers are optional and may be set after New.
+// Clock defaults to time.Now; replay sets a simulated clock. Airports and
+// AircraftTypes default to the bundled tables.
+type Monitor struct {
+	cfg      Config
+	openSky  *opensky.Client
+	adsbdb   *adsbdb.Client
+	airlines *airline.Resolver
+	store    *Store
+	logs     *logging.Loggers
+
+	AircraftDB    *aircraftdb.DB
+	Airports      *airports.DB
+	AircraftTypes *actype.DB
+	Watchlist     *watchlist.Watchlist
+	Noise         *noise.Meter
+	Console       ConsoleTable
+	Publishers    []Publisher
+
+	// Feeds are position sources merged with OpenSky, e.g. a local receiver.
+	Feeds []fusion.Feed
+	Clock func() time.Time
+
+	tracker   *EventTracker
+	movements *movementTracker
+	holds     *holding.Tracker
+	ops       *opsTracker
+	tracks    *track.Tracker
+	fuser     *fusion.Fuser
+	lookups   *lookupCache
+	anomalies *anomalyDetector
+	cycleSeq  atomic.Uint64
+}
+
+// New returns a monitor polling sky and enriching via db into store.
+func New(cfg Config, sky *opensky.Client, db *adsbdb.Client, store *Store, logs *logging.Loggers) *Monitor {
+	if cfg.EnrichWorkers < 1 {
+		cfg.EnrichWorkers = 1
+	}
+	if cfg.AircraftPrecedence == "" {
+		cfg.AircraftPrecedence = aircraftdb.PreferADSBDB
+	}
+	if len(cfg.SourcePriority) == 0 {
+		cfg.SourcePriority = fusion.DefaultPriority
+	}
+	if logs == nil {
+		logs = logging.Discard()
+	}
+	return &Monitor{
+		cfg:           cfg,
+		openSky:       sky,
+		adsbdb:        db,
+		airlines:      airline.NewResolver(db),
+		store:         store,
+		logs:          logs,
+		tracker:       NewEventTracker(),
+		movements:     newMovementTracker(cfg.LocalAirports),
+		holds:         holding.NewTracker(cfg.Holds),
+		ops:           newOpsTracker(),
+		tracks:        track.NewTracker(),
+		fuser:         fusion.New(),
+		lookups:       newLookupCache(),
+		anomalies:     newAnomalyDetector(),
+		Airports:      airports.Bundled(),
+		AircraftTypes: actype.Bundled(),
+		Clock:         time.Now,
+	}
+}
+
+// Store returns the store the monitor updates.
+func (m *Monitor) Store() *Store {
+	return m.store
+}
+
+// Run polls immediately and then every interval until ctx is cancelled. It
+// returns once any in-flight cycle has drained.
+func (m *Monitor) Run(ctx context.Context, interval time.Duration) {
+	m.RunCycle(ctx)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.RunCycle(ctx)
+		}
+	}
+}
+
+// RunCycle runs one monitoring cycle: it updates the store, derives events and
+// publishes the result. If ctx is cancelled part way through, the lookups already
+// in flight finish but the cycle is discarded.
+func (m *Monitor) RunCycle(ctx context.Context) (Cycle, error) {
+	now := m.Clock()
+	cycle := Cycle{ID: m.cycleSeq.Add(1), Time: now, Updated: now.Format("2006-01-02 15:04:05")}
+	logger := m.logs.For(logging.ComponentMonitor).With("cycle", cycle.ID, "region", m.cfg.Region)
+	logger.Info("aircraft check started")
+
+	// Pick up a registry refreshed by another process since the last cycle.
+	if m.AircraftDB != nil {
+		dbLog := m.logs.For(logging.ComponentAircraftDB)
+		if reloaded, err := m.AircraftDB.Reload(); err != nil {
+			dbLog.Warn("failed to reload aircraft database", "err", err)
+		} else if reloaded {
+			dbLog.Info("aircraft database reloaded", "records", m.AircraftDB.Len())
+		}
+	}
+
+	// Step 1: Get live aircraft with both ICAO24 and callsigns over the area via
+	// OpenSky and any local feeds, merged into one report per aircraft.
+	merged, failed, err := m.fuser.Fetch(ctx, m.feeds(), m.cfg.BBox, now)
+	if ctx.Err() != nil {
+		return cycle, ctx.Err()
+	}
+	for source, ferr := range failed {
+		m.logs.For(source).Error("failed to fetch states",
+			"cycle", cycle.ID, "region", m.cfg.Region, "source", source, "status", logging.StatusCode(ferr), "err", ferr)
+	}
+	m.store.SetSources(m.fuser.Stats())
+	if err != nil {
+		m.store.Update([]AircraftInfo{}, cycle.Updated+" (Error fetching data)")
+		return cycle, err
+	}
+	states := make([]opensky.State, len(merged))
+	for i, a := range merged {
+		states[i] = a.State
+	}
+
+	if len(states) > 0 {
+		logger.Info("enriching aircraft via adsbdb", "sources", len(m.Feeds)+1, "count", len(states), "workers", m.cfg.EnrichWorkers)
+	}
+
+	// Step 2: Enrich each aircraft using adsbdb for both aircraft info and route info.
+	adsbLog := m.logs.For(logging.ComponentADSBDB).With("cycle", cycle.ID, "region", m.cfg.Region, "source", "adsbdb")
+	list := enrichAll(ctx, states, m.cfg.EnrichWorkers, func(callCtx context.Context, state opensky.State) AircraftInfo {
+		return m.enrich(callCtx, state, cycle.Updated, adsbLog)
+	})
+	if ctx.Err() != nil {
+		logger.Info("aircraft check interrupted", "enriched", len(list), "reported", len(states))
+		return cycle, ctx.Err()
+	}
+
+	m.lookups.forget(now)
+	setProvenance(list, merged)
+
+	// Smooth positions before anything derives from them.
+	m.smoothTracks(list, now, logger)
+
+	// Flag watched aircraft, pin them to the top and record them in the spotted log.
+	if m.Watchlist != nil {
+		m.applyWatchlist(list, now, m.logs.For(logging.ComponentWatchlist).With("cycle", cycle.ID, "region", m.cfg.Region))
+	}
+
+	anomalyEvents := m.anomalies.update(list, merged, now)
+
+	holds := m.updateHolds(list, now)
+	local := m.movements.airports(m.Airports)
+	flightEvents := m.movements.classify(list, local, now)
+	opsEvents := m.ops.update(list, flightEvents, local, now)
+	if m.Noise != nil {
+		m.updateNoise(list, now, m.logs.For(logging.ComponentNoise).With("cycle", cycle.ID, "region", m.cfg.Region))
+	}
+
+	cycle.Aircraft = list
+	cycle.Events = append(m.tracker.Update(list, now), flightEvents...)
+	cycle.Events = append(cycle.Events, opsEvents...)
+	cycle.Events = append(cycle.Events, anomalyEvents...)
+	for _, ev := range flightEvents {
+		logger.Info("flight event", "type", ev.Type, "icao24", ev.ICAO24, "callsign", ev.Callsign,
+			"airport", ev.Airport, "runway", ev.Runway)
+	}
+	for _, ev := range opsEvents {
+		logger.Info("runway operations changed", "airport", ev.Airport, "detail", ev.Detail)
+	}
+	for _, ev := range anomalyEvents {
+		logger.Warn("anomalous contact", "icao24", ev.ICAO24, "callsign", ev.Callsign, "detail", ev.Detail)
+	}
+
+	m.store.Update(list, cycle.Updated)
+	m.store.AddEvents(cycle.Events)
+	m.store.SetHolds(holds)
+	m.store.SetRunwayOps(m.ops.snapshot())
+	m.Console.Print(cycle.Updated, m.cfg.Region, list)
+	logger.Info("aircraft check finished", "enriched", len(list), "reported", len(states), "events", len(cycle.Events))
+
+	for _, p := range m.Publishers {
+		if err := p.Publish(ctx, cycle); err != nil && ctx.Err() == nil {
+			logger.Error("publish failed", "publisher", fmt.Sprintf("%T", p), "err", err)
+		}
+	}
+	return cycle, nil
+}
+
+// feeds returns OpenSky and the extra feeds in priority order.
+func (m *Monitor) feeds() []fusion.Feed {
+	feeds := m.Feeds
+	if m.openSky != nil {
+		feeds = append([]fusion.Feed{{Name: fusion.SourceOpenSky, Source: m.openSky}}, feeds...)
+	}
+	return fusion.Order(feeds, m.cfg.SourcePriority)
+}
+
+// setProvenance records which sources reported each aircraft and which of
+// them supplied each group of fields.
+func setProvenance(list []AircraftInfo, merged []fusion.Aircraft) {
+	byICAO := make(map[string]fusion.Aircraft, len(merged))
+	for _, a := range merged {
+		byICAO[a.ICAO24] = a
+	}
+	for i := range list {
+		if a, ok := byICAO[list[i].ICAO24]; ok {
+			list[i].Sources, list[i].Provenance = a.Sources, a.Provenance
+		}
+	}
+}
+
+// smoothTracks runs each reported position through the aircraft's track
+// filter, replacing position, speed and track with the smoothed values and
+// outliers with the prediction. The report is kept in RawPosition.
+func (m *Monitor) smoothTracks(list []AircraftInfo, now time.Time, logger *slog.Logger) {
+	for i := range list {
+		a := &list[i]
+		if a.Latitude == nil || a.Longitude == nil {
+			continue
+		}
+		at := now
+		if a.PositionTime != nil {
+			at = *a.PositionTime
+		}
+		raw := geo.LatLon{Lat: *a.Latitude, Lon: *a.Longitude}
+		est := m.tracks.Update(track.Report{ICAO24: a.ICAO24, At: at, Position: raw, AltM: a.BaroAltitude, SpeedMS: a.Velocity, TrackDeg: a.Track})
+
+		lat, lon := est.Position.Lat, est.Position.Lon
+		a.RawPosition, a.Latitude, a.Longitude = &raw, &lat, &lon
+		if a.BaroAltitude != nil {
+			a.BaroAltitude = est.AltM
+		}
+		if a.Velocity != nil && a.Track != nil {
+			speed, trk := est.SpeedMS, est.TrackDeg
+			a.Velocity, a.Track = &speed, &trk
+		}
+		a.DistanceKm = geo.DistanceFrom(m.cfg.Home, a.Latitude, a.Longitude)
+		score := est.Score
+		a.TrackQuality, a.TrackScore, a.Outlier = est.Quality, &score, est.Reason
+		if est.Rejected {
+			logger.Debug("position report rejected", "icao24", a.ICAO24, "callsign", a.Callsign, "reason", est.Reason,
+				"lat", raw.Lat, "lon", raw.Lon)
+		}
+	}
+	m.tracks.Forget(now)
+}
+
+// updateHolds feeds the cycle to the holding tracker and marks holding aircraft
+// with the stack and the airport it feeds. It runs before the movement
+// classification, which uses both.
+func (m *Monitor) updateHolds(list []AircraftInfo, now time.Time) holding.Report {
+	samples := make([]holding.Sample, 0, len(list))
+	for _, a := range list {
+		if a.Latitude == nil || a.Longitude == nil || a.BaroAltitude == nil {
+			continue
+		}
+		samples = append(samples, holding.Sample{
+			ICAO24:   a.ICAO24,
+			Callsign: a.Callsign,
+			Position: geo.LatLon{Lat: *a.Latitude, Lon: *a.Longitude},
+			AltFt:    *a.BaroAltitude * geo.MetresToFeet,
+			Track:    a.Track,
+			OnGround: a.OnGround,
+			Trail:    holdTrail(fusion.Trail(m.Feeds, a.ICAO24)),
+		})
+	}
+	report := m.holds.Update(samples, now)
+	byICAO := make(map[string]holding.Hold, len(report.Holds))
+	for _, h := range report.Holds {
+		byICAO[h.ICAO24] = h
+	}
+	feeds := make(map[string]string)
+	for _, st := range m.holds.Stacks() {
+		if len(st.Airports) > 0 {
+			feeds[st.Ident] = strings.ToUpper(st.Airports[0])
+		}
+	}
+	for i := range list {
+		if h, ok := byICAO[list[i].ICAO24]; ok {
+			// The stack says where it is going when the route does not.
+			list[i].Holding, list[i].LocalAirport = h.Stack, feeds[h.Stack]
+		}
+	}
+	if n := report.Holding(); n > 0 {
+		m.logs.For(logging.ComponentMonitor).Info("aircraft holding", "count", n)
+	}
+	return report
+}
+
+// holdTrail keeps the trail points with a track, which is all the holding
+// tracker follows.
+func holdTrail(trail []fusion.Fix) []holding.TrackPoint {
+	var out []holding.TrackPoint
+	for _, f := range trail {
+		if f.Track != nil {
+			out = append(out, holding.TrackPoint{At: f.At, Position: f.Position, Track: *f.Track})
+		}
+	}
+	return out
+}
+
+// updateNoise feeds the airborne aircraft to the noise meter and saves any
+// overflights it has counted.
+func (m *Monitor) updateNoise(list []AircraftInfo, now time.Time, logger *slog.Logger) {
+	samples := make([]noise.Sample, 0, len(list))
+	for _, a := range list {
+		if a.OnGround || a.Latitude == nil || a.Longitude == nil || a.BaroAltitude == nil {
+			continue
+		}
+		samples = append(samples, noise.Sample{
+			ICAO24:       a.ICAO24,
+			Callsign:     a.Callsign,
+			Type:         a.ICAOType,
+			Class:        noise.ClassFor(a.Kind, a.WakeCategory, a.EngineType),
+			Movement:     a.Movement,
+			Position:     geo.LatLon{Lat: *a.Latitude, Lon: *a.Longitude},
+			AltM:         *a.BaroAltitude,
+			Track:        a.Track,
+			SpeedMS:      a.Velocity,
+			VerticalRate: a.VerticalRate,
+		})
+	}
+	m.Noise.Update(samples, now)
+	if err := m.Noise.Save(); err != nil {
+		logger.Error("failed to save noise totals", "err", err)
+	}
+}
+
+// applyWatchlist flags matches in place and moves watched aircraft to the top,
+// keeping the original order otherwise.
+func (m *Monitor) applyWatchlist(list []AircraftInfo, now time.Time, logger *slog.Logger) {
+	for i := range list {
+		a := &list[i]
+		a.Watched, a.WatchLabels = false, nil
+		for _, e := range m.Watchlist.Observe(a.Target(), now) {
+			a.Watched = true
+			a.WatchLabels = append(a.WatchLabels, e.Label)
+		}
+		if a.Watched {
+			logger.Info("watched aircraft spotted", "icao24", a.ICAO24, "registration", a.Registration,
+				"labels", strings.Join(a.WatchLabels, ", "))
+		}
+	}
+	if err := m.Watchlist.SaveSpotted(); err != nil {
+		logger.Error("failed to save spotted log", "err", err)
+	}
+	sort.SliceStable(list, func(i, j int) bool {
+		return list[i].Watched && !list[j].Watched
+	})
+}
+
+// lookupAircraft returns the airframe record from the local registry and/or
+// adsbdb according to the configured precedence. It reports false when neither
+// knows the aircraft.
+func (m *Monitor) lookupAircraft(ctx context.Context, icao24 string, adsbLog *slog.Logger) (adsbdb.Aircraft, bool) {
+	var local *adsbdb.Aircraft
+	if m.AircraftDB != nil {
+		rec, ok, err := m.AircraftDB.Lookup(icao24)
+		if err != nil {
+			m.logs.For(logging.ComponentAircraftDB).Warn("aircraft database lookup failed", "icao24", icao24, "err", err)
+		}
+		if ok {
+			a := rec.Aircraft()
+			local = &a
+		}
+	}
+	switch m.cfg.AircraftPrecedence {
+	case aircraftdb.LocalOnly:
+		if local == nil {
+			return adsbdb.Aircraft{}, false
+		}
+		return *local, true
+	case aircraftdb.PreferLocal:
+		if local != nil {
+			return *local, true
+		}
+	}
+
+	a, err := m.adsbdbAircraft(ctx, icao24)
+	if errors.Is(err, adsbdb.ErrUnknownAircraft) {
+		adsbLog.Debug("aircraft unknown to adsbdb", "icao24", icao24, "status", logging.StatusCode(err), "local", local != nil)
+	} else if err != nil {
+		adsbLog.Warn("aircraft lookup failed", "icao24", icao24, "status", logging.StatusCode(err), "local", local != nil, "err", err)
+	}
+	if err != nil {
+		if local == nil {
+			return adsbdb.Aircraft{}, false
+		}
+		return *local, true
+	}
+	if local != nil {
+		fillBlanks(a, *local)
+	}
+	return *a, true
+}
+
+// fillBlanks copies the fields adsbdb left empty from the local record.
+func fillBlanks(a *adsbdb.Aircraft, local adsbdb.Aircraft) {
+	for _, f := range []struct {
+		dst *string
+		src string
+	}{
+		{&a.Registration, local.Registration},
+		{&a.Manufacturer, local.Manufacturer},
+		{&a.Type, local.Type},
+		{&a.ICAOType, local.ICAOType},
+		{&a.RegisteredOwner, local.RegisteredOwner},
+	} {
+		if *f.dst == "" {
+			*f.dst = f.src
+		}
+	}
+}
+
+// enrich looks up one OpenSky contact in the local registry and adsbdb. A
+// contact neither knows stays on the board with what its address and callsign
+// tell us (registration country, military block, airline, route).
+func (m *Monitor) enrich(ctx context.Context, state opensky.State, timestamp string, adsbLog *slog.Logger) AircraftInfo {
+	a, _ := m.lookupAircraft(ctx, state.ICAO24, adsbLog)
+
+	// Try to get route information if we have a callsign
+	var origin, destination string = "Unknown", "Unknown"
+	var originAirport, destAirport, midAirport *AirportInfo
+	var operator *adsbdb.Airline
+	if state.Callsign != "" {
+		r, rErr := m.adsbdbRoute(ctx, state.ICAO24, state.Callsign)
+		if rErr == nil {
+			o, d := m.airportInfo(r.Origin), m.airportInfo(r.Destination)
+			originAirport, destAirport = &o, &d
+			origin = fmt.Sprintf("%s (%s)", o.Name, o.ICAO)
+			destination = fmt.Sprintf("%s (%s)", d.Name, d.ICAO)
+			operator = r.Airline
+			if r.Midpoint != nil {
+				mid := m.airportInfo(*r.Midpoint)
+				midAirport = &mid
+			}
+		} else {
+			adsbLog.Debug("route lookup failed", "icao24", state.ICAO24, "callsign", state.Callsign,
+				"status", logging.StatusCode(rErr), "err", rErr)
+		}
+	}
+
+	// The route usually names the airline already; otherwise resolve it from the callsign prefix.
+	if operator == nil || operator.Name == "" {
+		al, ok, alErr := m.airlines.Resolve(ctx, state.Callsign)
+		if alErr != nil {
+			adsbLog.Debug("airline lookup failed, using offline table", "callsign", state.Callsign,
+				"status", logging.StatusCode(alErr), "err", alErr)
+		}
+		if ok {
+			operator = &al
+		}
+	}
+
+	adsbLog.Debug("aircraft enriched", "icao24", state.ICAO24, "registration", a.Registration)
+
+	info := AircraftInfo{
+		ICAO24:             state.ICAO24,
+		Callsign:           state.Callsign,
+		Registration:       a.Registration,
+		Owner:              a.RegisteredOwner,
+		Manufacturer:       a.Manufacturer,
+		Type:               a.Type,
+		ICAOType:           a.ICAOType,
+		Origin:             origin,
+		Destination:        destination,
+		OriginAirport:      originAirport,
+		DestinationAirport: destAirport,
+		LastUpdated:        timestamp,
+		Latitude:           state.Latitude,
+		Longitude:          state.Longitude,
+		BaroAltitude:       state.BaroAltitude,
+		OnGround:           state.OnGround,
+		Velocity:           state.Velocity,
+		Track:              state.TrueTrack,
+		VerticalRate:       state.VerticalRate,
+		Squawk:             state.Squawk,
+		DistanceKm:         geo.DistanceFrom(m.cfg.Home, state.Latitude, state.Longitude),
+	}
+	if country, ok := icaoaddr.Country(state.ICAO24); ok {
+		info.RegistrationCountry = country
+	} else {
+		info.RegistrationCountry = state.OriginCountry
+	}
+	info.LikelyMilitary = icaoaddr.LikelyMilitary(state.ICAO24)
+	if a.RegisteredOwnerOperatorFlag != nil {
+		info.OperatorCode = *a.RegisteredOwnerOperatorFlag
+	}
+	if state.TimePosition != 0 {
+		t := time.Unix(state.TimePosition, 0).UTC()
+		info.PositionTime = &t
+	}
+	m.classify(&info, state.Category)
+	if originAirport != nil && state.Latitude != nil && state.Longitude != nil {
+		pos := geo.LatLon{Lat: *state.Latitude, Lon: *state.Longitude}
+		info.RouteKm, info.RouteProgress = routeProgress(*originAirport, *destAirport, pos)
+		if stops := routeStops(originAirport, midAirport, destAirport); stops != nil {
+			score, notes := checkRoute(stops, pos, state.TrueTrack, state.OnGround)
+			info.RouteScore, info.RouteConfidence, info.RouteNotes = &score, routeConfidence(score), notes
+			if info.RouteConfidence != RouteConfidenceHigh {
+				adsbLog.Debug("route looks implausible", "icao24", state.ICAO24, "callsign", state.Callsign,
+					"score", score, "notes", notes)
+			}
+		}
+	}
+	if operator != nil {
+		info.Airline, info.AirlineIATA, info.AirlineCallsign = operator.Name, operator.IATA, operator.Callsign
+	}
+	return info
+}
+
+// classify fills the type attributes from the designator table and reconciles
+// them with the emitter category OpenSky reported.
+func (m *Monitor) classify(info *AircraftInfo, category int) {
+	info.Category = category
+	t, ok := m.AircraftTypes.Lookup(info.ICAOType)
+	if !ok {
+		info.Kind = actype.Classify(nil, category)
+	} else {
+		info.TypeFamily, info.TypeDescription, info.WakeCategory = t.Family, t.Description, t.WTC
+		info.Engines, info.EngineType = t.Engines(), t.EngineType()
+		info.Kind = actype.Classify(&t, category)
+	}
+	if info.LikelyMilitary && info.Kind != actype.KindGround {
+		info.Kind = actype.KindMilitary
+	}
+}
+
+// airportInfo takes adsbdb's view of an airport and fills whatever it left out
+// (city, IATA code, coordinates…) from the airport table.
+func (m *Monitor) airportInfo(a adsbdb.Airport) AirportInfo {
+	info := AirportInfo{
+		ICAO:        strings.ToUpper(a.ICAOCode),
+		IATA:        a.IATACode,
+		Name:        a.Name,
+		City:        a.Municipality,
+		Country:     a.CountryName,
+		CountryISO:  a.CountryISOName,
+		Lat:         a.Latitude,
+		Lon:         a.Longitude,
+		ElevationFt: a.Elevation,
+	}
+	known, ok := m.Airports.Lookup(info.ICAO)
+	if !ok {
+		return info
+	}
+	for _, f := range []struct {
+		dst *string
+		src string
+	}{
+		{&info.IATA, known.IATA},
+		{&info.Name, known.Name},
+		{&info.City, known.City},
+		{&info.Country, known.Country},
+		{&info.CountryISO, known.CountryISO},
+	} {
+		if *f.dst == "" {
+			*f.dst = f.src
+		}
+	}
+	if info.Lat == 0 && info.Lon == 0 {
+		info.Lat, info.Lon = known.Lat, known.Lon
+	}
+	if info.ElevationFt == 0 {
+		info.ElevationFt = known.ElevationFt
+	}
+	return info
+}
+
+// routeProgress returns the great-circle route length and how far along it pos
+// is, in percent. Progress is measured as flown/(flown+remaining) so an aircraft
+// off the direct line (holding, vectored) still reads sensibly.
+func routeProgress(origin, dest AirportInfo, pos geo.LatLon) (*float64, *float64) {
+	o, d := geo.LatLon{Lat: origin.Lat, Lon: origin.Lon}, geo.LatLon{Lat: dest.Lat, Lon: dest.Lon}
+	if (o.Lat == 0 && o.Lon == 0) || (d.Lat == 0 && d.Lon == 0) {
+		return nil, nil
+	}
+	total := geo.HaversineKm(o, d)
+	flown, remaining := geo.HaversineKm(o, pos), geo.HaversineKm(pos, d)
+	if flown+remaining == 0 {
+		return &total, nil
+	}
+	progress := 100 * flown / (flown + remaining)
+	return &total, &progress
+}
+
+// enrichAll runs enrich over states with a fixed pool of workers and returns the
+// results in input order. Cancelling ctx stops new lookups from starting; lookups
+// already in flight run to completion (bounded by the HTTP client timeout) so no
+// request is cut off mid-response, and enrichAll returns once they have drained.
+func enrichAll(ctx context.Context, states []opensky.State, workers int, enrich func(context.Context, opensky.State) AircraftInfo) []AircraftInfo {
+	if workers < 1 {
+		workers = 1
+	}
+	callCtx := context.WithoutCancel(ctx)
+	results := make([]*AircraftInfo, len(states))
+	jobs := make(chan int)
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				info := enrich(callCtx, states[i])
+				results[i] = &info
+			}
+		}()
+	}
+
+feed:
+	for i := range states {
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	out := []AircraftInfo{}
+	for _, r := range results {
+		if r != nil {
+			out = append(out, *r)
+		}
+	}
+	return out
+}
@@ -0,0 +1,188 @@
+package monitor_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"AirTraffic-Monitor/adsbdb"
+	"AirTraffic-Monitor/fakeupstream"
+	"AirTraffic-Monitor/geo"
+	"AirTraffic-Monitor/monitor"
+	"AirTraffic-Monitor/opensky"
+)
+
+func ptr(v float64) *float64 { return &v }
+
+// recorder is a publisher that keeps every cycle it is given.
+type recorder struct {
+	cycles []monitor.Cycle
+}
+
+func (r *recorder) Publish(_ context.Context, c monitor.Cycle) error {
+	r.cycles = append(r.cycles, c)
+	return nil
+}
+
+// newPipeline returns a monitor wired to a fake OpenSky and adsbdb, with one
+// Ryanair flight north of central London that both upstreams know about.
+func newPipeline(t *testing.T) (*fakeupstream.Server, *monitor.Monitor, *recorder) {
+	t.Helper()
+	fake := fakeupstream.New()
+	t.Cleanup(fake.Close)
+	fake.SetStates(opensky.State{ICAO24: "4ca123", Callsign: "RYR1AB", OriginCountry: "Ireland",
+		Latitude: ptr(51.60), Longitude: ptr(-0.10), BaroAltitude: ptr(3000), Velocity: ptr(140),
+		TrueTrack: ptr(80), VerticalRate: ptr(-4), Category: 3})
+	fake.AddAircraft(fakeupstream.Aircraft{ModeS: "4CA123", Registration: "EI-ABC", Type: "737-8AS",
+		ICAOType: "B738", Manufacturer: "Boeing", Owner: "Ryanair"})
+	fake.AddAirline(fakeupstream.Airline{Name: "Ryanair", ICAO: "RYR", IATA: "FR", Callsign: "RYANAIR"})
+	fake.AddRoute(fakeupstream.Route{Callsign: "RYR1AB",
+		Origin:      fakeupstream.Airport{ICAO: "EIDW", Name: "Dublin Airport", Latitude: 53.42, Longitude: -6.27},
+		Destination: fakeupstream.Airport{ICAO: "EGSS", Name: "London Stansted Airport", Latitude: 51.885, Longitude: 0.235},
+	})
+
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	m := monitor.New(monitor.Config{
+		Region:        "london",
+		BBox:          geo.BBox{LaMin: 51.2, LoMin: -0.6, LaMax: 51.8, LoMax: 0.3},
+		Home:          geo.LatLon{Lat: 51.5, Lon: -0.12},
+		EnrichWorkers: 2,
+	}, opensky.NewClient(fake.OpenSky.URL, nil), adsbdb.NewClient(fake.ADSBDB.URL, nil), monitor.NewStore(), nil)
+	m.Clock = func() time.Time { return now }
+	rec := &recorder{}
+	m.Publishers = []monitor.Publisher{rec}
+	return fake, m, rec
+}
+
+func TestRunCycleEnrichesAndPublishes(t *testing.T) {
+	_, m, rec := newPipeline(t)
+	cycle, err := m.RunCycle(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cycle.Aircraft) != 1 {
+		t.Fatalf("got %d aircraft, want 1", len(cycle.Aircraft))
+	}
+	a := cycle.Aircraft[0]
+	if a.ICAO24 != "4CA123" || a.Callsign != "RYR1AB" || a.Registration != "EI-ABC" || a.ICAOType != "B738" {
+		t.Errorf("identity = %s %s %s %s", a.ICAO24, a.Callsign, a.Registration, a.ICAOType)
+	}
+	if !strings.Contains(a.Origin, "EIDW") || !strings.Contains(a.Destination, "EGSS") {
+		t.Errorf("route = %q -> %q", a.Origin, a.Destination)
+	}
+	if a.Airline != "Ryanair" || a.AirlineCallsign != "RYANAIR" {
+		t.Errorf("airline = %q (%q)", a.Airline, a.AirlineCallsign)
+	}
+	if a.RegistrationCountry != "Ireland" || a.LikelyMilitary {
+		t.Errorf("address block = %q, military %v", a.RegistrationCountry, a.LikelyMilitary)
+	}
+	if a.WakeCategory != "M" || a.RouteProgress == nil {
+		t.Errorf("wake %q, progress %v", a.WakeCategory, a.RouteProgress)
+	}
+
+	if len(cycle.Events) != 1 || cycle.Events[0].Type != monitor.EventEnter {
+		t.Errorf("events = %+v, want one enter", cycle.Events)
+	}
+	if len(rec.cycles) != 1 || rec.cycles[0].ID != cycle.ID {
+		t.Errorf("publisher got %d cycles", len(rec.cycles))
+	}
+	stored, _ := m.Store().Snapshot()
+	if len(stored) != 1 || stored[0].Registration != "EI-ABC" {
+		t.Errorf("store holds %+v", stored)
+	}
+}
+
+func TestRunCycleOpenSkyFailure(t *testing.T) {
+	for _, status := range []int{http.StatusTooManyRequests, http.StatusServiceUnavailable} {
+		t.Run(http.StatusText(status), func(t *testing.T) {
+			fake, m, rec := newPipeline(t)
+			if _, err := m.RunCycle(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+
+			fake.FailPath("/api/states", status)
+			_, err := m.RunCycle(context.Background())
+			var se *opensky.StatusError
+			if !errors.As(err, &se) || se.StatusCode != status {
+				t.Fatalf("RunCycle() error = %v, want OpenSky status %d", err, status)
+			}
+			stored, updated := m.Store().Snapshot()
+			if len(stored) != 0 || !strings.Contains(updated, "Error") {
+				t.Errorf("store after failure = %d aircraft, %q", len(stored), updated)
+			}
+			if len(rec.cycles) != 1 {
+				t.Errorf("failed cycle was published (%d cycles)", len(rec.cycles))
+			}
+
+			// The next good poll picks up where it left off.
+			fake.FailPath("/api/states", 0)
+			cycle, err := m.RunCycle(context.Background())
+			if err != nil || len(cycle.Aircraft) != 1 {
+				t.Fatalf("after recovery: %d aircraft, %v", len(cycle.Aircraft), err)
+			}
+		})
+	}
+}
+
+func TestRunCycleADSBDBFailure(t *testing.T) {
+	for _, status := range []int{http.StatusTooManyRequests, http.StatusInternalServerError} {
+		t.Run(http.StatusText(status), func(t *testing.T) {
+			fake, m, _ := newPipeline(t)
+			fake.FailPath("/v0/", status)
+
+			cycle, err := m.RunCycle(context.Background())
+			if err != nil {
+				t.Fatalf("adsbdb failure failed the cycle: %v", err)
+			}
+			if len(cycle.Aircraft) != 1 {
+				t.Fatalf("got %d aircraft, want the contact kept without enrichment", len(cycle.Aircraft))
+			}
+			a := cycle.Aircraft[0]
+			if a.Registration != "" || a.Origin != "Unknown" {
+				t.Errorf("registration %q, origin %q; want unenriched", a.Registration, a.Origin)
+			}
+			// The offline airline table and the address block still apply.
+			if a.Airline != "Ryanair" || a.RegistrationCountry != "Ireland" {
+				t.Errorf("airline %q, country %q", a.Airline, a.RegistrationCountry)
+			}
+			if n := fake.Requests()["/v0/aircraft/4CA123"]; n == 0 {
+				t.Error("adsbdb was never asked")
+			}
+
+			// Failures are not cached: the next cycle asks again.
+			fake.FailPath("/v0/", 0)
+			if cycle, err = m.RunCycle(context.Background()); err != nil || cycle.Aircraft[0].Registration != "EI-ABC" {
+				t.Errorf("after recovery: %+v, %v", cycle.Aircraft, err)
+			}
+		})
+	}
+}
+
+func TestRunCycleReusesLookups(t *testing.T) {
+	fake, m, _ := newPipeline(t)
+	start := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	for i, tt := range []struct {
+		after time.Duration
+		want  int // requests to /v0/aircraft/4CA123 so far: the airframe and the route
+	}{
+		{0, 2},
+		{5 * time.Minute, 2},
+		{45 * time.Minute, 3}, // the route is asked again
+		{7 * time.Hour, 5},    // and so is the airframe
+		{7*time.Hour + time.Minute, 5},
+	} {
+		now := start.Add(tt.after)
+		m.Clock = func() time.Time { return now }
+		cycle, err := m.RunCycle(context.Background())
+		if err != nil || len(cycle.Aircraft) != 1 || cycle.Aircraft[0].Registration != "EI-ABC" ||
+			!strings.Contains(cycle.Aircraft[0].Destination, "EGSS") {
+			t.Fatalf("cycle %d: %+v, %v", i, cycle.Aircraft, err)
+		}
+		if n := fake.Requests()["/v0/aircraft/4CA123"]; n != tt.want {
+			t.Errorf("cycle %d at +%v: %d adsbdb requests, want %d", i, tt.after, n, tt.want)
+		}
+	}
+}
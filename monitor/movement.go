@@ -0,0 +1,226 @@
+package monitor
+
+import (
+	"math"
+	"strings"
+	"time"
+
+	"AirTraffic-Monitor/airports"
+	"AirTraffic-Monitor/geo"
+)
+
+// Movements relative to the local airports.
+const (
+	MovementArrival    = "arrival"
+	MovementDeparture  = "departure"
+	MovementOverflight = "overflight"
+)
+
+// Board statuses, as an airport display would show them.
+const (
+	StatusTaxi       = "TAXI"
+	StatusClimbing   = "CLIMBING"
+	StatusDeparted   = "DEPARTED"
+	StatusDescending = "DESCENDING"
+	StatusEnRoute    = "EN ROUTE"
+	StatusApproach   = "APPROACH"
+	StatusHolding    = "HOLDING"
+	StatusLanded     = "LANDED"
+	StatusCruise     = "CRUISE"
+	StatusOnGround   = "ON GROUND"
+)
+
+const (
+	// Contacts within this distance and below terminalCeilingM of a local
+	// airport are treated as using it when the route does not say otherwise.
+	terminalRadiusKm = 40
+	terminalCeilingM = 3000
+	// On the ground within this distance counts as at the airport.
+	airportRadiusKm = 6
+	// Vertical rates beyond ±levelRateMS (about 300 ft/min) count as climbing or descending.
+	levelRateMS = 1.5
+)
+
+// trendSample is what the tracker remembers of an aircraft from the last cycle.
+type trendSample struct {
+	altitude *float64
+	position *geo.LatLon
+	track    *float64
+	onGround bool
+	at       time.Time
+
+	approach *approach // set while the aircraft is low on approach to a local airport
+}
+
+// movementTracker classifies each cycle's aircraft as arriving at, departing
+// from or overflying the local airports, using the route where it is credible
+// and otherwise position, altitude and the altitude trend since the last cycle.
+// The same per-aircraft state drives takeoff, landing and go-around events.
+type movementTracker struct {
+	local []string
+	last  map[string]trendSample
+}
+
+func newMovementTracker(local []string) *movementTracker {
+	codes := make([]string, len(local))
+	for i, c := range local {
+		codes[i] = strings.ToUpper(c)
+	}
+	return &movementTracker{local: codes, last: make(map[string]trendSample)}
+}
+
+// airports returns the local airports found in db, by ICAO code.
+func (t *movementTracker) airports(db *airports.DB) map[string]airports.Airport {
+	local := make(map[string]airports.Airport, len(t.local))
+	for _, code := range t.local {
+		if a, ok := db.Lookup(code); ok {
+			local[code] = a
+		}
+	}
+	return local
+}
+
+// classify sets Movement, LocalAirport and Status on every aircraft in list and
+// returns the takeoff, landing and go-around events since the last cycle.
+func (t *movementTracker) classify(list []AircraftInfo, local map[string]airports.Airport, now time.Time) []Event {
+	var events []Event
+	seen := make(map[string]trendSample, len(list))
+	for i := range list {
+		a := &list[i]
+		prev, hadPrev := t.last[a.ICAO24]
+		rate := verticalRate(a, prev, hadPrev, now)
+		a.Movement, a.LocalAirport = t.movement(a, local, prev, hadPrev, rate)
+		a.Status = status(a, rate)
+
+		cur := trendSample{altitude: a.BaroAltitude, track: a.Track, onGround: a.OnGround, at: now}
+		if a.Latitude != nil && a.Longitude != nil {
+			cur.position = &geo.LatLon{Lat: *a.Latitude, Lon: *a.Longitude}
+		}
+		events = append(events, detectFlightEvents(*a, local, prev, hadPrev, &cur, rate, now)...)
+		seen[a.ICAO24] = cur
+	}
+	t.last = seen
+	return events
+}
+
+// movement decides the aircraft's relationship to the local airports.
+func (t *movementTracker) movement(a *AircraftInfo, local map[string]airports.Airport, prev trendSample, hadPrev bool, rate *float64) (string, string) {
+	// A credible route names the airport outright.
+	if a.OriginAirport != nil && a.RouteConfidence != RouteConfidenceLow {
+		from, to := a.OriginAirport.ICAO, a.DestinationAirport.ICAO
+		_, fromLocal := local[from]
+		_, toLocal := local[to]
+		switch {
+		case fromLocal && toLocal:
+			// A local hop: whichever end it is nearer.
+			if a.RouteProgress != nil && *a.RouteProgress < 50 {
+				return MovementDeparture, from
+			}
+			return MovementArrival, to
+		case toLocal:
+			return MovementArrival, to
+		case fromLocal:
+			return MovementDeparture, from
+		}
+		if a.RouteConfidence == RouteConfidenceHigh {
+			return MovementOverflight, ""
+		}
+	}
+
+	// A holding aircraft is waiting to land where its stack leads.
+	if a.Holding != "" && a.LocalAirport != "" {
+		return MovementArrival, a.LocalAirport
+	}
+
+	// Otherwise go by the nearest local airport, height and trend.
+	if a.Latitude == nil || a.Longitude == nil {
+		return MovementOverflight, ""
+	}
+	ap, dist, found := nearestAirport(local, geo.LatLon{Lat: *a.Latitude, Lon: *a.Longitude})
+	code := ap.ICAO
+	switch {
+	case !found:
+		return MovementOverflight, ""
+	case a.OnGround && dist <= airportRadiusKm:
+		if hadPrev && !prev.onGround {
+			return MovementArrival, code
+		}
+		return MovementDeparture, code
+	case a.OnGround || dist > terminalRadiusKm:
+		return MovementOverflight, ""
+	case a.BaroAltitude != nil && *a.BaroAltitude > terminalCeilingM:
+		return MovementOverflight, ""
+	case rate != nil && *rate < -levelRateMS:
+		return MovementArrival, code
+	case rate != nil && *rate > levelRateMS:
+		return MovementDeparture, code
+	}
+	return MovementOverflight, ""
+}
+
+// nearestAirport returns the local airport closest to pos and its distance.
+func nearestAirport(local map[string]airports.Airport, pos geo.LatLon) (airports.Airport, float64, bool) {
+	var best airports.Airport
+	dist, found := math.Inf(1), false
+	for code, a := range local {
+		if d := geo.HaversineKm(a.Position(), pos); d < dist || (d == dist && code < best.ICAO) {
+			best, dist, found = a, d, true
+		}
+	}
+	return best, dist, found
+}
+
+// status is the board status for the aircraft's movement.
+func status(a *AircraftInfo, rate *float64) string {
+	climbing := rate != nil && *rate > levelRateMS
+	descending := rate != nil && *rate < -levelRateMS
+	if a.Holding != "" && !a.OnGround {
+		return StatusHolding
+	}
+	switch a.Movement {
+	case MovementArrival:
+		switch {
+		case a.OnGround:
+			return StatusLanded
+		case a.BaroAltitude != nil && *a.BaroAltitude <= terminalCeilingM:
+			return StatusApproach
+		case descending:
+			return StatusDescending
+		}
+		return StatusEnRoute
+	case MovementDeparture:
+		switch {
+		case a.OnGround:
+			return StatusTaxi
+		case climbing:
+			return StatusClimbing
+		}
+		return StatusDeparted
+	}
+	switch {
+	case a.OnGround:
+		return StatusOnGround
+	case climbing:
+		return StatusClimbing
+	case descending:
+		return StatusDescending
+	}
+	return StatusCruise
+}
+
+// verticalRate prefers the reported rate and falls back to the altitude change
+// since the previous cycle. It is nil when neither is known.
+func verticalRate(a *AircraftInfo, prev trendSample, hadPrev bool, now time.Time) *float64 {
+	if a.VerticalRate != nil {
+		return a.VerticalRate
+	}
+	if !hadPrev || prev.altitude == nil || a.BaroAltitude == nil {
+		return nil
+	}
+	dt := now.Sub(prev.at).Seconds()
+	if dt <= 0 {
+		return nil
+	}
+	r := (*a.BaroAltitude - *prev.altitude) / dt
+	return &r
+}
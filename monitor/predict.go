@@ -0,0 +1,82 @@
+package monitor
+
+import (
+	"math"
+	"time"
+
+	"AirTraffic-Monitor/geo"
+)
+
+// DefaultPredictMaxAge caps how far past the last position report an aircraft
+// is extrapolated. It matches the default poll interval.
+const DefaultPredictMaxAge = 5 * time.Minute
+
+const (
+	// A measured position is good to about this.
+	positionErrorKm = 0.1
+	// Speed and track errors grow the uncertainty by this fraction of the distance flown.
+	driftFraction = 0.1
+	// Turns and speed changes are covered by this much unmodelled acceleration, m/s².
+	manoeuvreAccelMS2 = 0.2
+)
+
+// Prediction is an aircraft's estimated position at a given moment, dead
+// reckoned from its last report. Predicted is false when the position is the
+// measured one, because it is current, the aircraft is not moving or its
+// velocity is unknown.
+type Prediction struct {
+	Latitude      float64    `json:"lat"`
+	Longitude     float64    `json:"lon"`
+	Altitude      *float64   `json:"altitude,omitempty"` // metres
+	At            time.Time  `json:"at"`                 // the moment estimated
+	MeasuredAt    *time.Time `json:"measured_at,omitempty"`
+	AgeSeconds    float64    `json:"age_s"` // time extrapolated past the measurement
+	UncertaintyKm float64    `json:"uncertainty_km"`
+	Predicted     bool       `json:"predicted"`
+	Capped        bool       `json:"capped,omitempty"` // held at maxAge; the aircraft is further on
+}
+
+// Predict extrapolates the last position along the track at the ground speed,
+// and the altitude at the vertical rate, to now. Extrapolation stops at maxAge
+// past the report. It returns nil without a position.
+func (a AircraftInfo) Predict(now time.Time, maxAge time.Duration) *Prediction {
+	if a.Latitude == nil || a.Longitude == nil {
+		return nil
+	}
+	p := &Prediction{
+		Latitude:      *a.Latitude,
+		Longitude:     *a.Longitude,
+		Altitude:      a.BaroAltitude,
+		At:            now,
+		MeasuredAt:    a.PositionTime,
+		UncertaintyKm: positionErrorKm,
+	}
+	if a.PositionTime == nil {
+		return p
+	}
+	age := now.Sub(*a.PositionTime)
+	if age <= 0 {
+		p.At = *a.PositionTime
+		return p
+	}
+	p.AgeSeconds = age.Seconds()
+	if a.OnGround || a.Velocity == nil || a.Track == nil || *a.Velocity < 1 {
+		return p
+	}
+	if age > maxAge {
+		age, p.Capped = maxAge, true
+		p.At = a.PositionTime.Add(maxAge)
+		p.AgeSeconds = maxAge.Seconds()
+	}
+
+	secs := age.Seconds()
+	distKm := *a.Velocity * secs / 1000
+	pos := geo.Destination(geo.LatLon{Lat: *a.Latitude, Lon: *a.Longitude}, *a.Track, distKm)
+	p.Latitude, p.Longitude, p.Predicted = pos.Lat, pos.Lon, true
+	if a.BaroAltitude != nil && a.VerticalRate != nil {
+		alt := math.Max(0, *a.BaroAltitude+*a.VerticalRate*secs)
+		p.Altitude = &alt
+	}
+	p.UncertaintyKm = positionErrorKm + driftFraction*distKm + 0.5*manoeuvreAccelMS2*secs*secs/1000
+	return p
+}
@@ -0,0 +1,112 @@
+package monitor
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"AirTraffic-Monitor/geo"
+)
+
+func TestPredict(t *testing.T) {
+	measured := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	start := geo.LatLon{Lat: 51.5, Lon: -0.1}
+	// flying returns an aircraft heading east at 200 m/s, descending at 5 m/s.
+	flying := func() AircraftInfo {
+		return AircraftInfo{Latitude: f(start.Lat), Longitude: f(start.Lon), BaroAltitude: f(3000),
+			Velocity: f(200), Track: f(90), VerticalRate: f(-5), PositionTime: &measured}
+	}
+	tests := []struct {
+		name          string
+		a             func() AircraftInfo
+		after         time.Duration
+		wantPredicted bool
+		wantCapped    bool
+		wantKm        float64 // from the measured position
+		wantAltitude  float64
+		wantAge       float64
+		wantSigmaKm   float64
+	}{
+		{
+			name: "a minute on", a: flying, after: time.Minute,
+			wantPredicted: true, wantKm: 12, wantAltitude: 2700, wantAge: 60,
+			wantSigmaKm: 0.1 + 1.2 + 0.36,
+		},
+		{
+			name: "held at the age cap", a: flying, after: 20 * time.Minute,
+			wantPredicted: true, wantCapped: true, wantKm: 60, wantAltitude: 1500, wantAge: 300,
+			wantSigmaKm: 0.1 + 6 + 9,
+		},
+		{
+			name: "current", a: flying,
+			wantAltitude: 3000, wantSigmaKm: positionErrorKm,
+		},
+		{
+			name: "reported after now", a: flying, after: -time.Minute,
+			wantAltitude: 3000, wantSigmaKm: positionErrorKm,
+		},
+		{
+			name: "on the ground",
+			a: func() AircraftInfo {
+				a := flying()
+				a.OnGround, a.Velocity = true, f(10)
+				return a
+			},
+			after: time.Minute, wantAltitude: 3000, wantAge: 60, wantSigmaKm: positionErrorKm,
+		},
+		{
+			name: "no track",
+			a: func() AircraftInfo {
+				a := flying()
+				a.Track = nil
+				return a
+			},
+			after: time.Minute, wantAltitude: 3000, wantAge: 60, wantSigmaKm: positionErrorKm,
+		},
+		{
+			name: "no measurement time",
+			a: func() AircraftInfo {
+				a := flying()
+				a.PositionTime = nil
+				return a
+			},
+			after: time.Minute, wantAltitude: 3000, wantSigmaKm: positionErrorKm,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := tt.a().Predict(measured.Add(tt.after), DefaultPredictMaxAge)
+			if p == nil {
+				t.Fatal("no prediction")
+			}
+			if p.Predicted != tt.wantPredicted || p.Capped != tt.wantCapped {
+				t.Errorf("predicted %v capped %v, want %v and %v", p.Predicted, p.Capped, tt.wantPredicted, tt.wantCapped)
+			}
+			if d := geo.HaversineKm(start, geo.LatLon{Lat: p.Latitude, Lon: p.Longitude}); math.Abs(d-tt.wantKm) > 0.05 {
+				t.Errorf("%.2f km from the measured position, want %v", d, tt.wantKm)
+			}
+			if p.Altitude == nil || math.Abs(*p.Altitude-tt.wantAltitude) > 0.01 {
+				t.Errorf("altitude %v, want %v", p.Altitude, tt.wantAltitude)
+			}
+			if p.AgeSeconds != tt.wantAge || math.Abs(p.UncertaintyKm-tt.wantSigmaKm) > 1e-9 {
+				t.Errorf("age %v s uncertainty %v km, want %v and %v", p.AgeSeconds, p.UncertaintyKm, tt.wantAge, tt.wantSigmaKm)
+			}
+		})
+	}
+
+	if p := (AircraftInfo{Velocity: f(200)}).Predict(measured, DefaultPredictMaxAge); p != nil {
+		t.Errorf("prediction without a position: %+v", p)
+	}
+}
+
+func TestPredictCappedTime(t *testing.T) {
+	measured := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	a := AircraftInfo{Latitude: f(51.5), Longitude: f(-0.1), Velocity: f(200), Track: f(90), PositionTime: &measured}
+	p := a.Predict(measured.Add(time.Hour), 2*time.Minute)
+	if !p.Capped || !p.At.Equal(measured.Add(2*time.Minute)) || p.AgeSeconds != 120 {
+		t.Errorf("capped %v at %v age %v, want held at +2m", p.Capped, p.At, p.AgeSeconds)
+	}
+	if p.Altitude != nil {
+		t.Errorf("altitude %v without a reported one", *p.Altitude)
+	}
+}
@@ -0,0 +1,128 @@
+package monitor
+
+import (
+	"fmt"
+	"math"
+
+	"AirTraffic-Monitor/geo"
+)
+
+// Route confidence levels. adsbdb routes are keyed by callsign and are often
+// stale (a callsign reused for another sector), so the live position and track
+// are checked against them before the board states them as fact.
+const (
+	RouteConfidenceHigh   = "high"
+	RouteConfidenceMedium = "medium"
+	RouteConfidenceLow    = "low"
+)
+
+const (
+	// Within this distance of a leg's ends the track says little about the route:
+	// departures follow SIDs, arrivals hold and get vectored.
+	terminalAreaKm = 40
+	// On the ground, an aircraft this close to a route airport fits the route.
+	groundSlackKm = 15
+)
+
+// routeStops returns the route airports in order, skipping a missing midpoint,
+// or nil if an end has no coordinates.
+func routeStops(origin, mid, dest *AirportInfo) []geo.LatLon {
+	var stops []geo.LatLon
+	for _, a := range []*AirportInfo{origin, mid, dest} {
+		if a == nil {
+			continue
+		}
+		if a.Lat == 0 && a.Lon == 0 {
+			if a == mid {
+				continue
+			}
+			return nil
+		}
+		stops = append(stops, geo.LatLon{Lat: a.Lat, Lon: a.Lon})
+	}
+	return stops
+}
+
+// checkRoute scores 0-1 how plausible it is that an aircraft at pos, tracking
+// track (nil if unknown), is flying the route through stops (origin, any
+// midpoint, destination). Notes explain what lowered the score.
+func checkRoute(stops []geo.LatLon, pos geo.LatLon, track *float64, onGround bool) (float64, []string) {
+	if onGround {
+		nearest := math.Inf(1)
+		for _, s := range stops {
+			nearest = math.Min(nearest, geo.HaversineKm(s, pos))
+		}
+		if nearest <= groundSlackKm {
+			return 1, nil
+		}
+		return 0, []string{fmt.Sprintf("on the ground %.0f km from the nearest route airport", nearest)}
+	}
+
+	// Score every leg and keep the one the aircraft fits best.
+	best, bestNotes := -1.0, []string(nil)
+	for i := 0; i+1 < len(stops); i++ {
+		score, notes := checkLeg(stops[i], stops[i+1], pos, track)
+		if score > best {
+			best, bestNotes = score, notes
+		}
+	}
+	if best < 0 {
+		return 1, nil
+	}
+	return best, bestNotes
+}
+
+// checkLeg scores one leg: is pos near the great-circle path from a to b, and,
+// away from either end, is the aircraft heading towards b?
+func checkLeg(a, b, pos geo.LatLon, track *float64) (float64, []string) {
+	total := geo.HaversineKm(a, b)
+	if total < 1 {
+		return -1, nil
+	}
+	var notes []string
+	flown, remaining := geo.HaversineKm(a, pos), geo.HaversineKm(pos, b)
+
+	// Off the path: both the detour via pos and the cross-track distance count,
+	// the first matters on short legs, the second on long ones.
+	detour := (flown+remaining)/total - 1
+	crossTrack := geo.CrossTrackKm(a, b, pos)
+	allowed := math.Max(50, 0.1*total)
+	path := math.Min(ramp(detour, 0.15, 0.6), ramp(crossTrack, allowed, 3*allowed))
+	if extra := flown + remaining - total; path < 1 && extra > crossTrack {
+		notes = append(notes, fmt.Sprintf("route via the current position is %.0f km longer than direct", extra))
+	} else if path < 1 {
+		notes = append(notes, fmt.Sprintf("%.0f km off the great-circle path", crossTrack))
+	}
+
+	heading := 1.0
+	if track != nil && flown > terminalAreaKm && remaining > terminalAreaKm {
+		toDest := geo.BearingDeg(pos, b)
+		heading = ramp(geo.AngleDiff(*track, toDest), 60, 135)
+		if heading < 1 {
+			notes = append(notes, fmt.Sprintf("tracking %03.0f° but the destination bears %03.0f°", *track, toDest))
+		}
+	}
+	return math.Min(path, heading), notes
+}
+
+// ramp is 1 up to ok, 0 from bad, and linear in between.
+func ramp(v, ok, bad float64) float64 {
+	switch {
+	case v <= ok:
+		return 1
+	case v >= bad:
+		return 0
+	}
+	return (bad - v) / (bad - ok)
+}
+
+// routeConfidence buckets a plausibility score.
+func routeConfidence(score float64) string {
+	switch {
+	case score >= 0.75:
+		return RouteConfidenceHigh
+	case score >= 0.4:
+		return RouteConfidenceMedium
+	}
+	return RouteConfidenceLow
+}
@@ -0,0 +1,227 @@
+package monitor
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"AirTraffic-Monitor/airports"
+	"AirTraffic-Monitor/geo"
+)
+
+const (
+	// Runway use older than opsWindow no longer counts towards the mode.
+	opsWindow = 20 * time.Minute
+	// A mode needs at least opsMinUses observations, and a change needs the
+	// new direction to have two thirds of them.
+	opsMinUses = 2
+	// Departures are watched up to this height on the initial climb.
+	initialClimbCeilingM = 1500
+)
+
+// RunwayOps is the direction an airport is operating in and the runways in use.
+type RunwayOps struct {
+	Airport      string    `json:"airport"`
+	Mode         string    `json:"mode"`                 // e.g. "westerly", "easterly"
+	Arrivals     string    `json:"arrivals,omitempty"`   // runway end, e.g. "27L"
+	Departures   string    `json:"departures,omitempty"` // runway end, e.g. "27R"
+	Since        time.Time `json:"since"`                // when the mode was first seen or last changed
+	Changed      bool      `json:"changed"`              // false until a change has been seen since startup
+	LastObserved time.Time `json:"last_observed"`
+	Observations int       `json:"observations"` // runway uses in the window
+}
+
+// Summary describes the operation for the board, e.g. "EGLL WESTERLY, arrivals 27L, departures 27R".
+func (o RunwayOps) Summary() string {
+	s := fmt.Sprintf("%s %s", o.Airport, strings.ToUpper(o.Mode))
+	if o.Arrivals != "" {
+		s += ", arrivals " + o.Arrivals
+	}
+	if o.Departures != "" {
+		s += ", departures " + o.Departures
+	}
+	return s
+}
+
+// runwayUse is one aircraft seen using a runway end.
+type runwayUse struct {
+	at      time.Time
+	icao24  string
+	runway  string
+	heading float64
+	arrival bool
+}
+
+// opsTracker infers each local airport's runway direction from the tracks of
+// aircraft on final approach and initial climb, and from landings and takeoffs.
+type opsTracker struct {
+	uses    map[string][]runwayUse // by airport, oldest first
+	current map[string]RunwayOps
+}
+
+func newOpsTracker() *opsTracker {
+	return &opsTracker{uses: make(map[string][]runwayUse), current: make(map[string]RunwayOps)}
+}
+
+// update records this cycle's runway use and returns a runway_change event for
+// every airport whose direction changed. local holds the airports to watch.
+func (t *opsTracker) update(list []AircraftInfo, flightEvents []Event, local map[string]airports.Airport, now time.Time) []Event {
+	for _, a := range list {
+		ap, ok := local[a.LocalAirport]
+		if !ok || a.OnGround || a.Track == nil || a.BaroAltitude == nil || a.Latitude == nil || a.Longitude == nil {
+			continue
+		}
+		pos := geo.LatLon{Lat: *a.Latitude, Lon: *a.Longitude}
+		if geo.HaversineKm(ap.Position(), pos) > runwayEvidenceKm {
+			continue
+		}
+		switch {
+		case a.Movement == MovementArrival && a.Status == StatusApproach && *a.BaroAltitude <= approachCeilingM:
+			t.record(ap, a.ICAO24, likelyRunway(ap, *a.Track, pos), true, now)
+		case a.Movement == MovementDeparture && a.Status == StatusClimbing && *a.BaroAltitude <= initialClimbCeilingM:
+			t.record(ap, a.ICAO24, likelyRunway(ap, *a.Track, pos), false, now)
+		}
+	}
+	for _, ev := range flightEvents {
+		if ap, ok := local[ev.Airport]; ok && (ev.Type == EventLanding || ev.Type == EventTakeoff) {
+			t.record(ap, ev.ICAO24, ev.Runway, ev.Type == EventLanding, now)
+		}
+	}
+
+	var events []Event
+	codes := make([]string, 0, len(local))
+	for code := range local {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	for _, code := range codes {
+		if ev, ok := t.infer(code, now); ok {
+			events = append(events, ev)
+		}
+	}
+	return events
+}
+
+// record notes one use of runway at ap; an aircraft counts once per runway
+// within the window.
+func (t *opsTracker) record(ap airports.Airport, icao24, runway string, arrival bool, now time.Time) {
+	if runway == "" {
+		return
+	}
+	heading, ok := runwayHeading(ap, runway)
+	if !ok {
+		return
+	}
+	for _, u := range t.uses[ap.ICAO] {
+		if u.icao24 == icao24 && u.runway == runway && u.arrival == arrival {
+			return
+		}
+	}
+	t.uses[ap.ICAO] = append(t.uses[ap.ICAO], runwayUse{at: now, icao24: icao24, runway: runway, heading: heading, arrival: arrival})
+}
+
+// infer works out code's operation from the uses in the window. It reports an
+// event when the direction differs from the one previously inferred.
+func (t *opsTracker) infer(code string, now time.Time) (Event, bool) {
+	uses := t.uses[code]
+	for len(uses) > 0 && now.Sub(uses[0].at) > opsWindow {
+		uses = uses[1:]
+	}
+	t.uses[code] = uses
+	if len(uses) < opsMinUses {
+		return Event{}, false
+	}
+
+	byMode := make(map[string]int)
+	for _, u := range uses {
+		byMode[compassMode(u.heading)]++
+	}
+	mode, n := "", 0
+	for m, c := range byMode {
+		if c > n || (c == n && m < mode) {
+			mode, n = m, c
+		}
+	}
+	prev, known := t.current[code]
+	if known && mode != prev.Mode && 3*n < 2*len(uses) {
+		// Not yet a clear change: keep reporting the old mode.
+		mode = prev.Mode
+	}
+
+	ops := RunwayOps{
+		Airport:      code,
+		Mode:         mode,
+		Arrivals:     mostUsed(uses, mode, true),
+		Departures:   mostUsed(uses, mode, false),
+		Since:        now,
+		LastObserved: uses[len(uses)-1].at,
+		Observations: len(uses),
+	}
+	changed := known && prev.Mode != mode
+	if known && !changed {
+		ops.Since, ops.Changed = prev.Since, prev.Changed
+	}
+	if changed {
+		ops.Changed = true
+	}
+	t.current[code] = ops
+	if !changed {
+		return Event{}, false
+	}
+	return Event{
+		Time:    now,
+		Type:    EventRunwayChange,
+		Airport: code,
+		Runway:  ops.Arrivals,
+		Detail:  fmt.Sprintf("%s to %s: %s", prev.Mode, mode, ops.Summary()),
+	}, true
+}
+
+// snapshot returns every airport's current operation, sorted by airport.
+func (t *opsTracker) snapshot() []RunwayOps {
+	out := make([]RunwayOps, 0, len(t.current))
+	for _, o := range t.current {
+		out = append(out, o)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Airport < out[j].Airport })
+	return out
+}
+
+// mostUsed returns the runway end most used for arrivals or departures in mode.
+func mostUsed(uses []runwayUse, mode string, arrival bool) string {
+	counts := make(map[string]int)
+	for _, u := range uses {
+		if u.arrival == arrival && compassMode(u.heading) == mode {
+			counts[u.runway]++
+		}
+	}
+	best, n := "", 0
+	for rw, c := range counts {
+		if c > n || (c == n && rw < best) {
+			best, n = rw, c
+		}
+	}
+	return best
+}
+
+// runwayHeading returns the true heading of a runway end.
+func runwayHeading(ap airports.Airport, ident string) (float64, bool) {
+	for _, rw := range ap.Runways {
+		for _, end := range rw.Ends {
+			if end.Ident == ident {
+				return end.HeadingTrue, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// compassMode names the direction of operation from a runway heading: aircraft
+// land and take off into the wind, so runway 27 in use is "westerly".
+func compassMode(heading float64) string {
+	names := []string{"northerly", "north-easterly", "easterly", "south-easterly",
+		"southerly", "south-westerly", "westerly", "north-westerly"}
+	return names[int(math.Mod(heading+22.5, 360)/45)]
+}
@@ -0,0 +1,90 @@
+package monitor
+
+import (
+	"testing"
+	"time"
+)
+
+// onFinal is an arrival low on approach to Heathrow.
+func onFinal(icao string, lat, lon, track float64) AircraftInfo {
+	return AircraftInfo{ICAO24: icao, Latitude: f(lat), Longitude: f(lon), BaroAltitude: f(400), Track: f(track),
+		Movement: MovementArrival, Status: StatusApproach, LocalAirport: "EGLL"}
+}
+
+// climbingOut is a departure on initial climb from Heathrow.
+func climbingOut(icao string, lat, lon, track float64) AircraftInfo {
+	return AircraftInfo{ICAO24: icao, Latitude: f(lat), Longitude: f(lon), BaroAltitude: f(900), Track: f(track),
+		Movement: MovementDeparture, Status: StatusClimbing, LocalAirport: "EGLL"}
+}
+
+func TestOpsTrackerDirectionChange(t *testing.T) {
+	local := heathrow(t)
+	ops := newOpsTracker()
+	t0 := time.Date(2026, 10, 18, 6, 0, 0, 0, time.UTC)
+
+	cycles := []struct {
+		name      string
+		at        time.Duration
+		list      []AircraftInfo
+		flights   []Event
+		wantMode  string
+		wantArr   string
+		wantDep   string
+		wantEvent bool
+	}{
+		{
+			name:     "a single arrival is not enough",
+			list:     []AircraftInfo{onFinal("A1", 51.4650, -0.38, 270)},
+			wantMode: "",
+		},
+		{
+			name:     "westerly: arrivals 27L, departures 27R",
+			at:       time.Minute,
+			list:     []AircraftInfo{onFinal("A2", 51.4650, -0.36, 270), climbingOut("D1", 51.4777, -0.52, 270)},
+			wantMode: "westerly", wantArr: "27L", wantDep: "27R",
+		},
+		{
+			name:     "one easterly arrival does not flip the mode",
+			at:       2 * time.Minute,
+			list:     []AircraftInfo{onFinal("A3", 51.4648, -0.56, 90)},
+			wantMode: "westerly", wantArr: "27L", wantDep: "27R",
+		},
+		{
+			name:     "after the window only easterly traffic remains",
+			at:       30 * time.Minute,
+			list:     []AircraftInfo{onFinal("A4", 51.4648, -0.56, 90), onFinal("A5", 51.4648, -0.60, 90)},
+			flights:  []Event{{Type: EventTakeoff, ICAO24: "D2", Airport: "EGLL", Runway: "09L"}},
+			wantMode: "easterly", wantArr: "09R", wantDep: "09L", wantEvent: true,
+		},
+		{
+			name:     "aircraft beyond the evidence radius are ignored",
+			at:       31 * time.Minute,
+			list:     []AircraftInfo{onFinal("A6", 51.4650, 0.0, 270), onFinal("A7", 51.4650, 0.05, 270)},
+			wantMode: "easterly", wantArr: "09R", wantDep: "09L",
+		},
+	}
+	for _, c := range cycles {
+		events := ops.update(c.list, c.flights, local, t0.Add(c.at))
+		if got := len(events) > 0; got != c.wantEvent {
+			t.Errorf("%s: events = %+v, want event %v", c.name, events, c.wantEvent)
+		}
+		snap := ops.snapshot()
+		if c.wantMode == "" {
+			if len(snap) != 0 {
+				t.Errorf("%s: ops = %+v, want none yet", c.name, snap)
+			}
+			continue
+		}
+		if len(snap) != 1 {
+			t.Fatalf("%s: got %d airports, want 1", c.name, len(snap))
+		}
+		o := snap[0]
+		if o.Mode != c.wantMode || o.Arrivals != c.wantArr || o.Departures != c.wantDep {
+			t.Errorf("%s: got %s arr %q dep %q, want %s arr %q dep %q", c.name, o.Mode, o.Arrivals, o.Departures,
+				c.wantMode, c.wantArr, c.wantDep)
+		}
+		if c.wantEvent && (events[0].Type != EventRunwayChange || events[0].Airport != "EGLL" || events[0].Runway != c.wantArr) {
+			t.Errorf("%s: event = %+v", c.name, events[0])
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package monitor
+
+import (
+	"sync"
+
+	"AirTraffic-Monitor/fusion"
+	"AirTraffic-Monitor/holding"
+)
+
+// eventHistory is how many recent events the store keeps.
+const eventHistory = 1000
+
+// Store holds the latest cycle's aircraft and the recent event history for the
+// web server and other readers.
+type Store struct {
+	mu         sync.RWMutex
+	aircraft   []AircraftInfo
+	lastUpdate string
+	events     []Event // oldest first, at most eventHistory
+	holds      holding.Report
+	runwayOps  []RunwayOps
+	sources    []fusion.SourceStats
+}
+
+// NewStore returns an empty store.
+func NewStore() *Store {
+	return &Store{}
+}
+
+// Update replaces the current aircraft and last-update label.
+func (s *Store) Update(list []AircraftInfo, updated string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.aircraft = list
+	s.lastUpdate = updated
+}
+
+// Snapshot returns the current aircraft and when they were fetched. The slice
+// is shared with the store and must not be modified.
+func (s *Store) Snapshot() ([]AircraftInfo, string) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.aircraft, s.lastUpdate
+}
+
+// AddEvents appends events to the history, dropping the oldest beyond eventHistory.
+func (s *Store) AddEvents(events []Event) {
+	if len(events) == 0 {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, events...)
+	if n := len(s.events) - eventHistory; n > 0 {
+		s.events = append([]Event(nil), s.events[n:]...)
+	}
+}
+
+// Events returns the event history, most recent first.
+func (s *Store) Events() []Event {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]Event, len(s.events))
+	for i, ev := range s.events {
+		out[len(out)-1-i] = ev
+	}
+	return out
+}
+
+// SetHolds replaces the holding picture.
+func (s *Store) SetHolds(r holding.Report) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.holds = r
+}
+
+// Holds returns the latest holding picture. It is shared with the store and
+// must not be modified.
+func (s *Store) Holds() holding.Report {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.holds
+}
+
+// SetRunwayOps replaces the runway operations of the local airports.
+func (s *Store) SetRunwayOps(ops []RunwayOps) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.runwayOps = ops
+}
+
+// RunwayOps returns the runway operations of the local airports. The slice is
+// shared with the store and must not be modified.
+func (s *Store) RunwayOps() []RunwayOps {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.runwayOps
+}
+
+// SetSources replaces the per-source statistics.
+func (s *Store) SetSources(stats []fusion.SourceStats) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sources = stats
+}
+
+// Sources returns the statistics of each position source, preferred first.
+// The slice is shared with the store and must not be modified.
+func (s *Store) Sources() []fusion.SourceStats {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.sources
+}
@@ -0,0 +1,355 @@
+// Package mqtt contains a deliberately small MQTT 3.1.1 client (CONNECT with last
+// will, username/password and TLS, PUBLISH at QoS 0 or 1, keep-alive pings and
+// DISCONNECT; it never subscribes) and the publisher that mirrors each monitoring
+// cycle to a broker, including Home Assistant discovery.
+package mqtt
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+
+	"AirTraffic-Monitor/config"
+)
+
+// MQTT control packet types (high nibble of the fixed header).
+const (
+	mqttConnect    = 1
+	mqttConnack    = 2
+	mqttPublish    = 3
+	mqttPuback     = 4
+	mqttPingreq    = 12
+	mqttPingresp   = 13
+	mqttDisconnect = 14
+)
+
+// ErrClosed is returned when publishing on a connection that has dropped.
+var ErrClosed = errors.New("mqtt connection closed")
+
+// Will is the message the broker publishes on our behalf if we vanish.
+type Will struct {
+	Topic   string
+	Payload []byte
+	QoS     byte
+	Retain  bool
+}
+
+// Client is a single connection to a broker.
+type Client struct {
+	conn    net.Conn
+	r       *bufio.Reader
+	writeMu sync.Mutex
+
+	pendingMu sync.Mutex
+	pending   map[uint16]chan struct{}
+	nextID    uint16
+
+	done    chan struct{}
+	closeMu sync.Once
+	err     error
+}
+
+// Dial connects to cfg.Broker and completes the CONNECT/CONNACK handshake.
+func Dial(ctx context.Context, cfg config.MQTTConfig, will *Will) (*Client, error) {
+	u, err := url.Parse(cfg.Broker)
+	if err != nil {
+		return nil, fmt.Errorf("mqtt broker url: %w", err)
+	}
+
+	var conn net.Conn
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+	switch u.Scheme {
+	case "tcp", "mqtt", "":
+		conn, err = dialer.DialContext(ctx, "tcp", hostPort(u, "1883"))
+	case "ssl", "tls", "mqtts":
+		tlsCfg, tErr := tlsConfig(cfg, u.Hostname())
+		if tErr != nil {
+			return nil, tErr
+		}
+		td := &tls.Dialer{NetDialer: dialer, Config: tlsCfg}
+		conn, err = td.DialContext(ctx, "tcp", hostPort(u, "8883"))
+	default:
+		return nil, fmt.Errorf("unsupported mqtt scheme %q", u.Scheme)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Client{
+		conn:    conn,
+		r:       bufio.NewReader(conn),
+		pending: make(map[uint16]chan struct{}),
+		done:    make(chan struct{}),
+	}
+	if err := c.handshake(cfg, will); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	go c.readLoop()
+	if ka := cfg.KeepAlive.Duration; ka > 0 {
+		go c.pingLoop(ka * 3 / 4)
+	}
+	return c, nil
+}
+
+func hostPort(u *url.URL, defaultPort string) string {
+	if u.Port() != "" {
+		return u.Host
+	}
+	return net.JoinHostPort(u.Hostname(), defaultPort)
+}
+
+func tlsConfig(cfg config.MQTTConfig, serverName string) (*tls.Config, error) {
+	tlsCfg := &tls.Config{
+		ServerName:         serverName,
+		InsecureSkipVerify: cfg.TLS.InsecureSkipVerify,
+		MinVersion:         tls.VersionTLS12,
+	}
+	if cfg.TLS.CAFile != "" {
+		pem, err := os.ReadFile(cfg.TLS.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("mqtt ca file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("mqtt ca file %s: no certificates found", cfg.TLS.CAFile)
+		}
+		tlsCfg.RootCAs = pool
+	}
+	if cfg.TLS.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.TLS.CertFile, cfg.TLS.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("mqtt client certificate: %w", err)
+		}
+		tlsCfg.Certificates = []tls.Certificate{cert}
+	}
+	return tlsCfg, nil
+}
+
+func (c *Client) handshake(cfg config.MQTTConfig, will *Will) error {
+	var flags byte = 0x02 // clean session
+	var payload []byte
+	payload = appendMQTTString(payload, []byte(cfg.ClientID))
+	if will != nil {
+		flags |= 0x04 | will.QoS<<3
+		if will.Retain {
+			flags |= 0x20
+		}
+		payload = appendMQTTString(payload, []byte(will.Topic))
+		payload = appendMQTTString(payload, will.Payload)
+	}
+	if cfg.Username != "" {
+		flags |= 0x80
+		payload = appendMQTTString(payload, []byte(cfg.Username))
+		if cfg.Password != "" {
+			flags |= 0x40
+			payload = appendMQTTString(payload, []byte(cfg.Password))
+		}
+	}
+
+	var body []byte
+	body = appendMQTTString(body, []byte("MQTT"))
+	body = append(body, 4, flags) // protocol level 4 = MQTT 3.1.1
+	body = binary.BigEndian.AppendUint16(body, uint16(cfg.KeepAlive.Seconds()))
+	body = append(body, payload...)
+
+	c.conn.SetDeadline(time.Now().Add(10 * time.Second))
+	defer c.conn.SetDeadline(time.Time{})
+	if err := c.writePacket(mqttConnect<<4, body); err != nil {
+		return err
+	}
+	kind, resp, err := readMQTTPacket(c.r)
+	if err != nil {
+		return fmt.Errorf("mqtt connack: %w", err)
+	}
+	if kind>>4 != mqttConnack || len(resp) < 2 {
+		return fmt.Errorf("mqtt: expected CONNACK, got packet type %d", kind>>4)
+	}
+	if resp[1] != 0 {
+		return fmt.Errorf("mqtt: connection refused (return code %d)", resp[1])
+	}
+	return nil
+}
+
+// Publish sends one message. At QoS 1 it waits for the broker's PUBACK.
+func (c *Client) Publish(ctx context.Context, topic string, payload []byte, qos byte, retain bool) error {
+	header := byte(mqttPublish<<4) | qos<<1
+	if retain {
+		header |= 0x01
+	}
+	body := appendMQTTString(nil, []byte(topic))
+
+	var ack chan struct{}
+	var id uint16
+	if qos > 0 {
+		c.pendingMu.Lock()
+		c.nextID++
+		if c.nextID == 0 {
+			c.nextID = 1
+		}
+		id = c.nextID
+		ack = make(chan struct{})
+		c.pending[id] = ack
+		c.pendingMu.Unlock()
+		defer func() {
+			c.pendingMu.Lock()
+			delete(c.pending, id)
+			c.pendingMu.Unlock()
+		}()
+		body = binary.BigEndian.AppendUint16(body, id)
+	}
+	body = append(body, payload...)
+
+	if err := c.writePacket(header, body); err != nil {
+		return err
+	}
+	if ack == nil {
+		return nil
+	}
+	select {
+	case <-ack:
+		return nil
+	case <-c.done:
+		return c.closedErr()
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Disconnect sends DISCONNECT so the broker discards the last will, then closes.
+func (c *Client) Disconnect() error {
+	err := c.writePacket(mqttDisconnect<<4, nil)
+	c.close(ErrClosed)
+	return err
+}
+
+// Done is closed when the connection drops.
+func (c *Client) Done() <-chan struct{} {
+	return c.done
+}
+
+func (c *Client) closedErr() error {
+	if c.err != nil {
+		return c.err
+	}
+	return ErrClosed
+}
+
+func (c *Client) close(err error) {
+	c.closeMu.Do(func() {
+		c.err = err
+		c.conn.Close()
+		close(c.done)
+	})
+}
+
+func (c *Client) writePacket(header byte, body []byte) error {
+	pkt := []byte{header}
+	pkt = appendMQTTLength(pkt, len(body))
+	pkt = append(pkt, body...)
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	c.conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+	if _, err := c.conn.Write(pkt); err != nil {
+		c.close(err)
+		return err
+	}
+	return nil
+}
+
+func (c *Client) readLoop() {
+	for {
+		kind, body, err := readMQTTPacket(c.r)
+		if err != nil {
+			c.close(err)
+			return
+		}
+		switch kind >> 4 {
+		case mqttPuback:
+			if len(body) < 2 {
+				continue
+			}
+			id := binary.BigEndian.Uint16(body)
+			c.pendingMu.Lock()
+			if ch, ok := c.pending[id]; ok {
+				close(ch)
+				delete(c.pending, id)
+			}
+			c.pendingMu.Unlock()
+		case mqttPingresp:
+			// the broker is alive; nothing else to do
+		}
+	}
+}
+
+func (c *Client) pingLoop(every time.Duration) {
+	ticker := time.NewTicker(every)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-ticker.C:
+			if err := c.writePacket(mqttPingreq<<4, nil); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func readMQTTPacket(r *bufio.Reader) (byte, []byte, error) {
+	header, err := r.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+	length, multiplier := 0, 1
+	for i := 0; ; i++ {
+		if i == 4 {
+			return 0, nil, errors.New("mqtt: malformed remaining length")
+		}
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, nil, err
+		}
+		length += int(b&0x7f) * multiplier
+		if b&0x80 == 0 {
+			break
+		}
+		multiplier *= 128
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return 0, nil, err
+	}
+	return header, body, nil
+}
+
+func appendMQTTString(b, s []byte) []byte {
+	b = binary.BigEndian.AppendUint16(b, uint16(len(s)))
+	return append(b, s...)
+}
+
+func appendMQTTLength(b []byte, n int) []byte {
+	for {
+		digit := byte(n % 128)
+		n /= 128
+		if n > 0 {
+			digit |= 0x80
+		}
+		b = append(b, digit)
+		if n == 0 {
+			return b
+		}
+	}
+}
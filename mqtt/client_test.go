@@ -0,0 +1,209 @@
+package mqtt
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"net"
+	"testing"
+	"time"
+
+	"AirTraffic-Monitor/config"
+)
+
+func TestRemainingLength(t *testing.T) {
+	// Boundary values from the MQTT 3.1.1 spec, section 2.2.3.
+	tests := []struct {
+		n    int
+		want []byte
+	}{
+		{0, []byte{0x00}},
+		{127, []byte{0x7f}},
+		{128, []byte{0x80, 0x01}},
+		{16383, []byte{0xff, 0x7f}},
+		{16384, []byte{0x80, 0x80, 0x01}},
+		{2097151, []byte{0xff, 0xff, 0x7f}},
+		{2097152, []byte{0x80, 0x80, 0x80, 0x01}},
+		{268435455, []byte{0xff, 0xff, 0xff, 0x7f}},
+	}
+	for _, tt := range tests {
+		got := appendMQTTLength(nil, tt.n)
+		if !bytes.Equal(got, tt.want) {
+			t.Errorf("appendMQTTLength(%d) = % x, want % x", tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestReadPacketRoundTrip(t *testing.T) {
+	for _, n := range []int{0, 1, 127, 128, 16383, 16384} {
+		body := bytes.Repeat([]byte{0xab}, n)
+		pkt := append(appendMQTTLength([]byte{mqttPublish<<4 | 0x01}, n), body...)
+		kind, got, err := readMQTTPacket(bufio.NewReader(bytes.NewReader(pkt)))
+		if err != nil {
+			t.Fatalf("len %d: %v", n, err)
+		}
+		if kind != mqttPublish<<4|0x01 || !bytes.Equal(got, body) {
+			t.Errorf("len %d: got header %#x and %d body bytes", n, kind, len(got))
+		}
+	}
+}
+
+func TestReadPacketMalformedLength(t *testing.T) {
+	pkt := []byte{mqttPuback << 4, 0x80, 0x80, 0x80, 0x80, 0x01}
+	if _, _, err := readMQTTPacket(bufio.NewReader(bytes.NewReader(pkt))); err == nil {
+		t.Fatal("five length bytes accepted")
+	}
+}
+
+// pipeClient returns a client on one end of a pipe, without the handshake,
+// and the broker end for the test to drive.
+func pipeClient(t *testing.T) (*Client, net.Conn) {
+	t.Helper()
+	clientEnd, brokerEnd := net.Pipe()
+	c := &Client{
+		conn:    clientEnd,
+		r:       bufio.NewReader(clientEnd),
+		pending: make(map[uint16]chan struct{}),
+		done:    make(chan struct{}),
+	}
+	t.Cleanup(func() {
+		c.close(ErrClosed)
+		brokerEnd.Close()
+	})
+	return c, brokerEnd
+}
+
+func TestConnectFlags(t *testing.T) {
+	tests := []struct {
+		name      string
+		user      string
+		pass      string
+		will      *Will
+		wantFlags byte
+	}{
+		{"clean session only", "", "", nil, 0x02},
+		{"username without password", "u", "", nil, 0x82},
+		{"username and password", "u", "p", nil, 0xc2},
+		{"will qos0", "", "", &Will{Topic: "s", Payload: []byte("offline")}, 0x06},
+		{"retained will qos1", "", "", &Will{Topic: "s", Payload: []byte("offline"), QoS: 1, Retain: true}, 0x2e},
+		{"everything", "u", "p", &Will{Topic: "s", QoS: 1, Retain: true}, 0xee},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, broker := pipeClient(t)
+			cfg := config.MQTTConfig{ClientID: "atm", Username: tt.user, Password: tt.pass}
+			cfg.KeepAlive.Duration = 60 * time.Second
+
+			type connect struct {
+				kind byte
+				body []byte
+				err  error
+			}
+			got := make(chan connect, 1)
+			go func() {
+				kind, body, err := readMQTTPacket(bufio.NewReader(broker))
+				got <- connect{kind, body, err}
+				if err == nil {
+					broker.Write([]byte{mqttConnack << 4, 2, 0, 0})
+				}
+			}()
+			if err := c.handshake(cfg, tt.will); err != nil {
+				t.Fatal(err)
+			}
+			p := <-got
+			if p.err != nil {
+				t.Fatal(p.err)
+			}
+			if p.kind != mqttConnect<<4 {
+				t.Fatalf("packet type %#x, want CONNECT", p.kind)
+			}
+			// protocol name (2+4), level, flags, keep-alive
+			if !bytes.Equal(p.body[:6], []byte{0, 4, 'M', 'Q', 'T', 'T'}) || p.body[6] != 4 {
+				t.Fatalf("variable header % x", p.body[:7])
+			}
+			if p.body[7] != tt.wantFlags {
+				t.Errorf("connect flags = %#08b, want %#08b", p.body[7], tt.wantFlags)
+			}
+			if ka := binary.BigEndian.Uint16(p.body[8:10]); ka != 60 {
+				t.Errorf("keep-alive = %d, want 60", ka)
+			}
+		})
+	}
+}
+
+func TestConnackRefused(t *testing.T) {
+	c, broker := pipeClient(t)
+	go func() {
+		readMQTTPacket(bufio.NewReader(broker))
+		broker.Write([]byte{mqttConnack << 4, 2, 0, 5}) // not authorised
+	}()
+	if err := c.handshake(config.MQTTConfig{ClientID: "atm"}, nil); err == nil {
+		t.Fatal("refused CONNACK accepted")
+	}
+}
+
+func TestPublishWaitsForMatchingPuback(t *testing.T) {
+	c, broker := pipeClient(t)
+	go c.readLoop()
+
+	result := make(chan error, 1)
+	go func() {
+		result <- c.Publish(context.Background(), "atm/london/status", []byte("online"), 1, true)
+	}()
+
+	kind, body, err := readMQTTPacket(bufio.NewReader(broker))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kind != mqttPublish<<4|1<<1|0x01 {
+		t.Fatalf("header %#x, want PUBLISH qos1 retained", kind)
+	}
+	topicLen := int(binary.BigEndian.Uint16(body))
+	if topic := string(body[2 : 2+topicLen]); topic != "atm/london/status" {
+		t.Fatalf("topic %q", topic)
+	}
+	id := binary.BigEndian.Uint16(body[2+topicLen:])
+	if payload := string(body[4+topicLen:]); payload != "online" {
+		t.Fatalf("payload %q", payload)
+	}
+
+	// A PUBACK for some other packet must not complete this publish.
+	broker.Write([]byte{mqttPuback << 4, 2, byte((id + 1) >> 8), byte(id + 1)})
+	select {
+	case err := <-result:
+		t.Fatalf("Publish returned %v on a PUBACK for another packet id", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	broker.Write([]byte{mqttPuback << 4, 2, byte(id >> 8), byte(id)})
+	select {
+	case err := <-result:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Publish did not return after its PUBACK")
+	}
+}
+
+func TestPublishFailsWhenConnectionDrops(t *testing.T) {
+	c, broker := pipeClient(t)
+	go c.readLoop()
+
+	result := make(chan error, 1)
+	go func() {
+		result <- c.Publish(context.Background(), "t", nil, 1, false)
+	}()
+	readMQTTPacket(bufio.NewReader(broker))
+	broker.Close()
+
+	select {
+	case err := <-result:
+		if err == nil {
+			t.Fatal("Publish succeeded without a PUBACK")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Publish did not return after the connection dropped")
+	}
+}
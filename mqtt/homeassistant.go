@@ -0,0 +1,230 @@
+package mqtt
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+
+	"AirTraffic-Monitor/config"
+	"AirTraffic-Monitor/geo"
+	"AirTraffic-Monitor/monitor"
+	"AirTraffic-Monitor/watchlist"
+)
+
+// Squawk codes that signal an emergency: hijack, radio failure, general emergency.
+var emergencySquawks = map[string]bool{"7500": true, "7600": true, "7700": true}
+
+var haUnsafeChars = regexp.MustCompile(`[^a-zA-Z0-9_-]+`)
+
+// haDevice is the device block shared by every entity of a region.
+type haDevice struct {
+	Identifiers  []string `json:"identifiers"`
+	Name         string   `json:"name"`
+	Manufacturer string   `json:"manufacturer"`
+	Model        string   `json:"model"`
+}
+
+// haEntityConfig is the discovery payload for a sensor or binary_sensor.
+type haEntityConfig struct {
+	Name              string   `json:"name"`
+	UniqueID          string   `json:"unique_id"`
+	ObjectID          string   `json:"object_id"`
+	StateTopic        string   `json:"state_topic"`
+	ValueTemplate     string   `json:"value_template"`
+	Unit              string   `json:"unit_of_measurement,omitempty"`
+	DeviceClass       string   `json:"device_class,omitempty"`
+	StateClass        string   `json:"state_class,omitempty"`
+	Icon              string   `json:"icon,omitempty"`
+	PayloadOn         string   `json:"payload_on,omitempty"`
+	PayloadOff        string   `json:"payload_off,omitempty"`
+	AvailabilityTopic string   `json:"availability_topic"`
+	Device            haDevice `json:"device"`
+}
+
+// haState is published to <prefix>/<region>/homeassistant/state each cycle;
+// the discovered entities pick their value out of it with a template.
+type haState struct {
+	Count               int               `json:"count"`
+	ClosestRegistration string            `json:"closest_registration"`
+	ClosestDistanceKm   *float64          `json:"closest_distance_km"`
+	HighestAltitudeFt   *float64          `json:"highest_altitude_ft"`
+	LowestAltitudeFt    *float64          `json:"lowest_altitude_ft"`
+	Emergency           string            `json:"emergency"`
+	EmergencySquawks    []string          `json:"emergency_squawks"`
+	Watch               map[string]string `json:"watch,omitempty"`
+}
+
+// homeAssistant publishes discovery configs and the shared state topic.
+type homeAssistant struct {
+	cfg       config.HomeAssistantConfig
+	publisher *Publisher
+	watchlist *watchlist.Watchlist
+	// published holds the discovery payloads sent on the current connection.
+	published map[string]string
+}
+
+func newHomeAssistant(cfg config.HomeAssistantConfig, p *Publisher, wl *watchlist.Watchlist) *homeAssistant {
+	return &homeAssistant{cfg: cfg, publisher: p, watchlist: wl, published: make(map[string]string)}
+}
+
+// nodeID is the region name made safe for use in discovery topics and unique IDs.
+func (h *homeAssistant) nodeID() string {
+	return "airtraffic_" + haUnsafeChars.ReplaceAllString(h.publisher.region, "_")
+}
+
+func (h *homeAssistant) stateTopic() string {
+	return h.publisher.topic("homeassistant", "state")
+}
+
+// entities returns the discovery payload for each entity keyed by its config topic.
+func (h *homeAssistant) entities(entries []watchlist.Entry) map[string]haEntityConfig {
+	node := h.nodeID()
+	device := haDevice{
+		Identifiers:  []string{node},
+		Name:         "Air traffic " + h.publisher.region,
+		Manufacturer: "AirTraffic-Monitor",
+		Model:        "OpenSky + adsbdb",
+	}
+	base := func(component, object, name, template string) (string, haEntityConfig) {
+		topic := fmt.Sprintf("%s/%s/%s/%s/config", h.cfg.DiscoveryPrefix, component, node, object)
+		return topic, haEntityConfig{
+			Name:              name,
+			UniqueID:          node + "_" + object,
+			ObjectID:          node + "_" + object,
+			StateTopic:        h.stateTopic(),
+			ValueTemplate:     template,
+			AvailabilityTopic: h.publisher.topic("status"),
+			Device:            device,
+		}
+	}
+
+	out := make(map[string]haEntityConfig)
+	add := func(topic string, e haEntityConfig) { out[topic] = e }
+
+	t, e := base("sensor", "count", "Aircraft count", "{{ value_json.count }}")
+	e.Icon, e.StateClass, e.Unit = "mdi:airplane", "measurement", "aircraft"
+	add(t, e)
+
+	t, e = base("sensor", "closest_registration", "Closest aircraft", "{{ value_json.closest_registration }}")
+	e.Icon = "mdi:airplane-marker"
+	add(t, e)
+
+	t, e = base("sensor", "closest_distance", "Closest aircraft distance", "{{ value_json.closest_distance_km }}")
+	e.Unit, e.DeviceClass, e.StateClass = "km", "distance", "measurement"
+	add(t, e)
+
+	t, e = base("sensor", "highest_altitude", "Highest altitude", "{{ value_json.highest_altitude_ft }}")
+	e.Unit, e.DeviceClass, e.StateClass = "ft", "distance", "measurement"
+	add(t, e)
+
+	t, e = base("sensor", "lowest_altitude", "Lowest altitude", "{{ value_json.lowest_altitude_ft }}")
+	e.Unit, e.DeviceClass, e.StateClass = "ft", "distance", "measurement"
+	add(t, e)
+
+	t, e = base("binary_sensor", "emergency", "Emergency squawk", "{{ value_json.emergency }}")
+	e.DeviceClass, e.PayloadOn, e.PayloadOff = "problem", "ON", "OFF"
+	add(t, e)
+
+	if h.cfg.WatchlistSensors {
+		for _, entry := range entries {
+			object := "watch_" + haUnsafeChars.ReplaceAllString(entry.ID, "_")
+			tmpl := fmt.Sprintf("{{ value_json.watch[%q] | default('OFF') }}", entry.ID)
+			t, e = base("binary_sensor", object, "Watching "+entry.Label, tmpl)
+			e.DeviceClass, e.PayloadOn, e.PayloadOff = "presence", "ON", "OFF"
+			add(t, e)
+		}
+	}
+	return out
+}
+
+// state summarises the cycle for the discovered entities.
+func (h *homeAssistant) state(list []monitor.AircraftInfo, entries []watchlist.Entry) haState {
+	st := haState{Count: len(list), Emergency: "OFF", EmergencySquawks: []string{}}
+	for _, a := range list {
+		if a.DistanceKm != nil && (st.ClosestDistanceKm == nil || *a.DistanceKm < *st.ClosestDistanceKm) {
+			d := math.Round(*a.DistanceKm*10) / 10
+			st.ClosestDistanceKm = &d
+			st.ClosestRegistration = a.Registration
+			if st.ClosestRegistration == "" {
+				st.ClosestRegistration = a.ICAO24
+			}
+		}
+		if a.BaroAltitude != nil && !a.OnGround {
+			ft := math.Round(*a.BaroAltitude * geo.MetresToFeet)
+			if st.HighestAltitudeFt == nil || ft > *st.HighestAltitudeFt {
+				st.HighestAltitudeFt = &ft
+			}
+			if st.LowestAltitudeFt == nil || ft < *st.LowestAltitudeFt {
+				st.LowestAltitudeFt = &ft
+			}
+		}
+		if emergencySquawks[a.Squawk] {
+			st.Emergency = "ON"
+			st.EmergencySquawks = append(st.EmergencySquawks, a.Squawk)
+		}
+	}
+	if h.cfg.WatchlistSensors {
+		st.Watch = make(map[string]string, len(entries))
+		for _, entry := range entries {
+			st.Watch[entry.ID] = "OFF"
+		}
+		for _, a := range list {
+			if !a.Watched {
+				continue
+			}
+			for _, entry := range entries {
+				if entry.Matches(a.Target()) {
+					st.Watch[entry.ID] = "ON"
+				}
+			}
+		}
+	}
+	return st
+}
+
+// publish sends discovery configs that are new or changed on this connection, removes
+// configs for deleted watchlist entries, then publishes the state.
+func (h *homeAssistant) publish(ctx context.Context, c *Client, list []monitor.AircraftInfo) error {
+	var entries []watchlist.Entry
+	if h.watchlist != nil {
+		entries = h.watchlist.Entries()
+	}
+	qos := h.publisher.cfg.QoS
+	wanted := h.entities(entries)
+	for topic, cfg := range wanted {
+		payload, err := json.Marshal(cfg)
+		if err != nil {
+			return err
+		}
+		if h.published[topic] == string(payload) {
+			continue
+		}
+		if err := c.Publish(ctx, topic, payload, qos, true); err != nil {
+			return err
+		}
+		h.published[topic] = string(payload)
+	}
+	for topic := range h.published {
+		if _, ok := wanted[topic]; ok || !strings.Contains(topic, "/watch_") {
+			continue
+		}
+		if err := c.Publish(ctx, topic, nil, qos, true); err != nil {
+			return err
+		}
+		delete(h.published, topic)
+	}
+
+	payload, err := json.Marshal(h.state(list, entries))
+	if err != nil {
+		return err
+	}
+	return c.Publish(ctx, h.stateTopic(), payload, qos, true)
+}
+
+// reset forgets what was published, so a new connection re-sends discovery.
+func (h *homeAssistant) reset() {
+	h.published = make(map[string]string)
+}
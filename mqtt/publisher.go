@@ -0,0 +1,170 @@
+package mqtt
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"AirTraffic-Monitor/config"
+	"AirTraffic-Monitor/monitor"
+	"AirTraffic-Monitor/watchlist"
+)
+
+// Publisher mirrors each cycle to a broker under <prefix>/<region>/...:
+//
+//	status               retained "online"/"offline" (offline is also the last will)
+//	snapshot             retained JSON summary of the whole cycle
+//	aircraft/<icao24>    retained JSON per aircraft, cleared once it is no longer seen
+//	events/<type>        enter, exit, alert, takeoff, landing, go_around,
+//	                     runway_change and anomaly events (not retained)
+//
+// With Home Assistant enabled it also publishes discovery configs and
+// homeassistant/state.
+type Publisher struct {
+	cfg    config.MQTTConfig
+	region string
+	ha     *homeAssistant
+
+	mu      sync.Mutex
+	client  *Client
+	current map[string]bool // aircraft topics holding a retained message
+}
+
+// NewPublisher returns a publisher for region; it connects on first use.
+func NewPublisher(cfg config.MQTTConfig, region string) *Publisher {
+	return &Publisher{cfg: cfg, region: region}
+}
+
+// EnableHomeAssistant turns on Home Assistant MQTT discovery. wl may be nil;
+// otherwise it supplies the entries for per-watchlist presence sensors.
+func (p *Publisher) EnableHomeAssistant(cfg config.HomeAssistantConfig, wl *watchlist.Watchlist) {
+	p.ha = newHomeAssistant(cfg, p, wl)
+}
+
+// topic joins path segments onto the configured prefix and region.
+func (p *Publisher) topic(parts ...string) string {
+	segs := append([]string{strings.Trim(p.cfg.TopicPrefix, "/"), p.region}, parts...)
+	return strings.Join(segs, "/")
+}
+
+// connection returns the live client, dialling (again) if the previous one dropped.
+func (p *Publisher) connection(ctx context.Context) (*Client, error) {
+	if p.client != nil {
+		select {
+		case <-p.client.Done():
+			p.client = nil
+		default:
+			return p.client, nil
+		}
+	}
+	will := &Will{Topic: p.topic("status"), Payload: []byte("offline"), QoS: p.cfg.QoS, Retain: true}
+	c, err := Dial(ctx, p.cfg, will)
+	if err != nil {
+		return nil, fmt.Errorf("mqtt connect %s: %w", p.cfg.Broker, err)
+	}
+	if err := c.Publish(ctx, will.Topic, []byte("online"), p.cfg.QoS, true); err != nil {
+		c.Disconnect()
+		return nil, err
+	}
+	p.client = c
+	if p.ha != nil {
+		p.ha.reset()
+	}
+	return c, nil
+}
+
+// Publish sends the cycle's aircraft and events. Any aircraft topic retained
+// earlier whose aircraft is no longer in the cycle is cleared by publishing an
+// empty retained payload, whether or not its exit event was seen.
+func (p *Publisher) Publish(ctx context.Context, cycle monitor.Cycle) error {
+	list, events := cycle.Aircraft, cycle.Events
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	c, err := p.connection(ctx)
+	if err != nil {
+		return err
+	}
+	if p.current == nil {
+		p.current = make(map[string]bool)
+	}
+
+	snapshot := struct {
+		Count      int      `json:"count"`
+		LastUpdate string   `json:"last_update"`
+		ICAO24     []string `json:"icao24"`
+	}{Count: len(list), LastUpdate: cycle.Updated, ICAO24: make([]string, 0, len(list))}
+	current := make(map[string]bool, len(list))
+	for _, a := range list {
+		icao := strings.ToLower(a.ICAO24)
+		current[icao] = true
+		snapshot.ICAO24 = append(snapshot.ICAO24, a.ICAO24)
+		if err := p.publishJSON(ctx, c, p.topic("aircraft", icao), a, true); err != nil {
+			return err
+		}
+		p.current[icao] = true
+	}
+	for icao := range p.current {
+		if current[icao] {
+			continue
+		}
+		if err := c.Publish(ctx, p.topic("aircraft", icao), nil, p.cfg.QoS, true); err != nil {
+			return err
+		}
+		delete(p.current, icao)
+	}
+	if err := p.publishJSON(ctx, c, p.topic("snapshot"), snapshot, true); err != nil {
+		return err
+	}
+
+	for _, ev := range events {
+		if err := p.publishJSON(ctx, c, p.topic("events", ev.Type), ev, false); err != nil {
+			return err
+		}
+	}
+
+	if p.ha != nil {
+		if err := p.ha.publish(ctx, c, list); err != nil {
+			return fmt.Errorf("home assistant: %w", err)
+		}
+	}
+	return nil
+}
+
+func (p *Publisher) publishJSON(ctx context.Context, c *Client, topic string, v any, retain bool) error {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return c.Publish(ctx, topic, payload, p.cfg.QoS, retain)
+}
+
+// Close clears the retained per-aircraft topics, marks the region offline and
+// disconnects cleanly, so subscribers are not left with stale aircraft.
+func (p *Publisher) Close(ctx context.Context) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.client == nil {
+		return nil
+	}
+	c := p.client
+	p.client = nil
+	for icao := range p.current {
+		if err := c.Publish(ctx, p.topic("aircraft", icao), nil, p.cfg.QoS, true); err != nil {
+			c.Disconnect()
+			return err
+		}
+	}
+	p.current = nil
+	if err := c.Publish(ctx, p.topic("status"), []byte("offline"), p.cfg.QoS, true); err != nil {
+		c.Disconnect()
+		return err
+	}
+	return c.Disconnect()
+}
@@ -0,0 +1,123 @@
+package mqtt
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"net"
+	"sync"
+	"testing"
+
+	"AirTraffic-Monitor/config"
+	"AirTraffic-Monitor/monitor"
+)
+
+// fakeBroker accepts connections, acknowledges CONNECT and QoS 1 PUBLISH, and
+// keeps the last retained payload per topic the way a real broker would.
+type fakeBroker struct {
+	ln net.Listener
+
+	mu       sync.Mutex
+	retained map[string]string
+}
+
+func newFakeBroker(t *testing.T) *fakeBroker {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := &fakeBroker{ln: ln, retained: make(map[string]string)}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go b.serve(conn)
+		}
+	}()
+	return b
+}
+
+func (b *fakeBroker) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	for {
+		kind, body, err := readMQTTPacket(r)
+		if err != nil {
+			return
+		}
+		switch kind >> 4 {
+		case mqttConnect:
+			conn.Write([]byte{mqttConnack << 4, 2, 0, 0})
+		case mqttPublish:
+			qos := kind >> 1 & 0x03
+			n := int(binary.BigEndian.Uint16(body))
+			topic, rest := string(body[2:2+n]), body[2+n:]
+			if qos > 0 {
+				conn.Write([]byte{mqttPuback << 4, 2, rest[0], rest[1]})
+				rest = rest[2:]
+			}
+			if kind&0x01 != 0 {
+				b.mu.Lock()
+				if len(rest) == 0 {
+					delete(b.retained, topic)
+				} else {
+					b.retained[topic] = string(rest)
+				}
+				b.mu.Unlock()
+			}
+		case mqttDisconnect:
+			return
+		}
+	}
+}
+
+func (b *fakeBroker) has(topic string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	_, ok := b.retained[topic]
+	return ok
+}
+
+func TestPublisherClearsAircraftThatDisappear(t *testing.T) {
+	b := newFakeBroker(t)
+	p := NewPublisher(config.MQTTConfig{
+		Broker:      "tcp://" + b.ln.Addr().String(),
+		ClientID:    "atm-test",
+		TopicPrefix: "atm",
+		QoS:         1,
+	}, "london")
+	ctx := context.Background()
+	t.Cleanup(func() { p.Close(ctx) })
+
+	cycles := []struct {
+		aircraft []string
+		events   []monitor.Event
+		want     map[string]bool
+	}{
+		{[]string{"400A0B", "4CA123"}, nil, map[string]bool{"400a0b": true, "4ca123": true}},
+		// 4CA123 vanished without an exit event (e.g. a failed cycle in between).
+		{[]string{"400A0B"}, nil, map[string]bool{"400a0b": true, "4ca123": false}},
+		{nil, []monitor.Event{{Type: monitor.EventExit, ICAO24: "400A0B"}}, map[string]bool{"400a0b": false}},
+	}
+	for i, c := range cycles {
+		var list []monitor.AircraftInfo
+		for _, icao := range c.aircraft {
+			list = append(list, monitor.AircraftInfo{ICAO24: icao})
+		}
+		if err := p.Publish(ctx, monitor.Cycle{Aircraft: list, Events: c.events}); err != nil {
+			t.Fatalf("cycle %d: %v", i, err)
+		}
+		for icao, want := range c.want {
+			if got := b.has("atm/london/aircraft/" + icao); got != want {
+				t.Errorf("cycle %d: retained aircraft/%s = %v, want %v", i, icao, got, want)
+			}
+		}
+	}
+	if !b.has("atm/london/status") || !b.has("atm/london/snapshot") {
+		t.Error("status and snapshot should stay retained")
+	}
+}
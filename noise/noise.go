@@ -0,0 +1,496 @@
+// Package noise estimates aircraft noise at observer locations. Each cycle's
+// positions are projected along the aircraft's track to the point of closest
+// approach to every observer, and a peak level is estimated from the slant
+// distance and a noise class derived from the aircraft type. Each pass is one
+// overflight; overflights are summed into daily counts above thresholds and a
+// simplified Lden.
+//
+// The figures are estimates from a point-source model, good for comparing days
+// and places rather than for measuring compliance.
+package noise
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"AirTraffic-Monitor/geo"
+	"AirTraffic-Monitor/internal/jsonfile"
+)
+
+// Noise classes, from the aircraft type.
+const (
+	ClassHeavyJet   = "heavy_jet"  // wake category H or J
+	ClassJet        = "jet"        // airliners and military jets
+	ClassLightJet   = "light_jet"  // business jets
+	ClassTurboprop  = "turboprop"  //
+	ClassPiston     = "piston"     // light aircraft
+	ClassHelicopter = "helicopter" //
+	ClassUnknown    = "unknown"    // no type information
+)
+
+// referenceDB is each class's A-weighted peak level at referenceM directly
+// below the aircraft on approach. Departures add departureBoostDB for climb thrust.
+var referenceDB = map[string]float64{
+	ClassHeavyJet:   90,
+	ClassJet:        84,
+	ClassLightJet:   80,
+	ClassTurboprop:  78,
+	ClassPiston:     70,
+	ClassHelicopter: 82,
+	ClassUnknown:    82,
+}
+
+const (
+	referenceM       = 305 // 1,000 ft
+	departureBoostDB = 3
+	// Excess attenuation in air beyond spherical spreading, per km of slant distance.
+	absorptionDBPerKm = 4
+	// Overflights quieter than minEventDB, or further than maxRadiusKm, are not counted.
+	minEventDB  = 50
+	maxRadiusKm = 15
+	// A sample stands for the path flown up to maxHalfSpan either side of it.
+	maxHalfSpan = 150 * time.Second
+	// Speed assumed when none is reported, m/s.
+	defaultSpeedMS = 70
+	// Overflights kept for the API and CSV.
+	recentOverflights = 2000
+	// Days kept per observer.
+	maxDays = 400
+)
+
+// DefaultThresholds are the levels counted per day: N60, N65 and N70.
+var DefaultThresholds = []float64{60, 65, 70}
+
+// ClassFor returns the noise class for an aircraft type, or "" for aircraft
+// that make no meaningful noise on the ground (gliders, balloons, drones, vehicles).
+func ClassFor(kind, wake, engineType string) string {
+	switch {
+	case kind == "helicopter":
+		return ClassHelicopter
+	case kind == "glider" || kind == "uav" || kind == "ground":
+		return ""
+	case engineType == "Piston" || engineType == "Electric":
+		return ClassPiston
+	case engineType == "Turboprop":
+		return ClassTurboprop
+	case wake == "H" || wake == "J":
+		return ClassHeavyJet
+	case kind == "business" || (engineType == "Jet" && wake == "L"):
+		return ClassLightJet
+	case engineType == "Jet" || kind == "airliner" || kind == "military":
+		return ClassJet
+	case kind == "light":
+		return ClassPiston
+	}
+	return ClassUnknown
+}
+
+// Observer is a place noise is estimated for. A postcode without coordinates
+// is resolved at startup with ResolvePostcodes.
+type Observer struct {
+	Name     string  `json:"name"`
+	Postcode string  `json:"postcode,omitempty"`
+	Lat      float64 `json:"lat"`
+	Lon      float64 `json:"lon"`
+}
+
+// Validate reports an observer that cannot be placed.
+func (o Observer) Validate() error {
+	switch {
+	case o.Name == "":
+		return fmt.Errorf("noise observer needs a name")
+	case o.Postcode == "" && o.Lat == 0 && o.Lon == 0:
+		return fmt.Errorf("noise observer %q needs a postcode or lat and lon", o.Name)
+	}
+	return nil
+}
+
+// Position returns the observer's location.
+func (o Observer) Position() geo.LatLon {
+	return geo.LatLon{Lat: o.Lat, Lon: o.Lon}
+}
+
+func (o Observer) located() bool {
+	return o.Lat != 0 || o.Lon != 0
+}
+
+// Sample is one aircraft's state in a cycle.
+type Sample struct {
+	ICAO24       string
+	Callsign     string
+	Type         string
+	Class        string // from ClassFor; "" is ignored
+	Movement     string // arrival, departure or overflight
+	Position     geo.LatLon
+	AltM         float64
+	Track        *float64 // degrees
+	SpeedMS      *float64
+	VerticalRate *float64 // m/s
+}
+
+// Overflight is one aircraft passing one observer.
+type Overflight struct {
+	Observer   string    `json:"observer"`
+	ICAO24     string    `json:"icao24"`
+	Callsign   string    `json:"callsign,omitempty"`
+	Type       string    `json:"type,omitempty"`
+	Class      string    `json:"class"`
+	Movement   string    `json:"movement,omitempty"`
+	Time       time.Time `json:"time"`        // closest approach
+	DistanceKm float64   `json:"distance_km"` // horizontal, at closest approach
+	AltFt      float64   `json:"alt_ft"`      // at closest approach
+	LAmaxDB    float64   `json:"lamax_db"`    // estimated peak level
+	SELDB      float64   `json:"sel_db"`      // estimated sound exposure level
+}
+
+// Day is one observer's totals for one local calendar day.
+type Day struct {
+	Date        string         `json:"date"` // YYYY-MM-DD, local time
+	Observer    string         `json:"observer"`
+	Events      int            `json:"events"`
+	NightEvents int            `json:"night_events"` // 23:00-07:00
+	Above       map[string]int `json:"above"`        // overflights at or above each threshold, e.g. "N65"
+	MaxDB       float64        `json:"max_db"`
+	LdenDB      float64        `json:"lden_db"`
+	LnightDB    float64        `json:"lnight_db"`
+	Leq16DB     float64        `json:"leq16_db"` // 07:00-23:00
+
+	// Summed sound energy (10^(SEL/10)) for the day, evening and night periods.
+	// Only the state file keeps it.
+	energy [3]float64
+}
+
+// Lden periods and their penalties.
+const (
+	periodDay     = iota // 07:00-19:00
+	periodEvening        // 19:00-23:00, +5 dB
+	periodNight          // 23:00-07:00, +10 dB
+)
+
+func period(t time.Time) int {
+	switch h := t.Hour(); {
+	case h >= 7 && h < 19:
+		return periodDay
+	case h >= 19 && h < 23:
+		return periodEvening
+	}
+	return periodNight
+}
+
+// add counts one overflight and recomputes the aggregates.
+func (d *Day) add(o Overflight, thresholds []float64) {
+	d.Events++
+	p := period(o.Time.Local())
+	if p == periodNight {
+		d.NightEvents++
+	}
+	for _, th := range thresholds {
+		if o.LAmaxDB >= th {
+			d.Above[ThresholdKey(th)]++
+		}
+	}
+	d.MaxDB = math.Max(d.MaxDB, o.LAmaxDB)
+	d.energy[p] += math.Pow(10, o.SELDB/10)
+
+	e := d.energy
+	d.LdenDB = decibels((e[periodDay] + e[periodEvening]*math.Pow(10, 0.5) + e[periodNight]*10) / 86400)
+	d.LnightDB = decibels(e[periodNight] / (8 * 3600))
+	d.Leq16DB = decibels((e[periodDay] + e[periodEvening]) / (16 * 3600))
+}
+
+// ThresholdKey names the count of overflights at or above th in Day.Above and
+// in reports, e.g. "N65".
+func ThresholdKey(th float64) string {
+	return fmt.Sprintf("N%g", th)
+}
+
+// decibels converts a mean energy to a level rounded to 0.1 dB, 0 for none.
+func decibels(energy float64) float64 {
+	if energy <= 0 {
+		return 0
+	}
+	return math.Round(100*math.Log10(energy)) / 10
+}
+
+// Meter accumulates overflights per observer. It is safe for concurrent use.
+type Meter struct {
+	mu         sync.RWMutex
+	path       string
+	observers  []Observer
+	thresholds []float64
+	state      state
+	passes     map[string]Overflight // loudest point of each pass in progress, by ICAO24 and observer
+	last       map[string]time.Time  // when each aircraft was last sampled
+	dirty      bool
+}
+
+// state is what the meter persists.
+type state struct {
+	Days        []*Day       `json:"days"`
+	Overflights []Overflight `json:"overflights"` // oldest first
+}
+
+// diskState is state as written to the file, with each day's energy sums.
+type diskState struct {
+	Days        []diskDay    `json:"days"`
+	Overflights []Overflight `json:"overflights"`
+}
+
+type diskDay struct {
+	Day
+	Energy [3]float64 `json:"energy"`
+}
+
+func (s state) MarshalJSON() ([]byte, error) {
+	disk := diskState{Days: make([]diskDay, len(s.Days)), Overflights: s.Overflights}
+	for i, d := range s.Days {
+		disk.Days[i] = diskDay{Day: *d, Energy: d.energy}
+	}
+	return json.Marshal(disk)
+}
+
+func (s *state) UnmarshalJSON(data []byte) error {
+	var disk diskState
+	if err := json.Unmarshal(data, &disk); err != nil {
+		return err
+	}
+	s.Days, s.Overflights = make([]*Day, len(disk.Days)), disk.Overflights
+	for i, d := range disk.Days {
+		day := d.Day
+		day.energy = d.Energy
+		s.Days[i] = &day
+	}
+	return nil
+}
+
+// Load returns a meter for observers, reading earlier totals from path. An
+// empty path keeps everything in memory; a missing file starts afresh.
+func Load(path string, observers []Observer, thresholds []float64) (*Meter, error) {
+	m := &Meter{
+		path:       path,
+		observers:  observers,
+		thresholds: thresholds,
+		passes:     make(map[string]Overflight),
+		last:       make(map[string]time.Time),
+	}
+	if path != "" {
+		if err := jsonfile.Read(path, &m.state); err != nil {
+			return nil, fmt.Errorf("load noise totals: %w", err)
+		}
+	}
+	for _, d := range m.state.Days {
+		if d.Above == nil {
+			d.Above = make(map[string]int)
+		}
+	}
+	return m, nil
+}
+
+// Observers returns the observers the meter estimates for.
+func (m *Meter) Observers() []Observer {
+	return m.observers
+}
+
+// Thresholds returns the levels counted per day.
+func (m *Meter) Thresholds() []float64 {
+	return m.thresholds
+}
+
+// Update feeds one cycle's samples. A pass is counted once the aircraft no
+// longer comes within range of the observer, at its loudest point.
+func (m *Meter) Update(samples []Sample, now time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	last := make(map[string]time.Time, len(samples))
+	hit := make(map[string]bool)
+	for _, s := range samples {
+		last[s.ICAO24] = now
+		if s.Class == "" {
+			continue
+		}
+		half := maxHalfSpan
+		if prev, ok := m.last[s.ICAO24]; ok && now.Sub(prev)/2 < half {
+			half = now.Sub(prev) / 2
+		}
+		for _, o := range m.observers {
+			if !o.located() {
+				continue
+			}
+			ov, ok := estimate(s, o, now, half)
+			if !ok {
+				continue
+			}
+			key := s.ICAO24 + "|" + o.Name
+			hit[key] = true
+			if p, ok := m.passes[key]; !ok || ov.LAmaxDB > p.LAmaxDB {
+				m.passes[key] = ov
+			}
+		}
+	}
+	m.last = last
+
+	for key, p := range m.passes {
+		if !hit[key] {
+			m.recordLocked(p)
+			delete(m.passes, key)
+		}
+	}
+}
+
+// estimate projects s along its track to the closest approach to o within
+// half either side of now and returns the overflight if it is loud enough.
+func estimate(s Sample, o Observer, now time.Time, half time.Duration) (Overflight, bool) {
+	speed := float64(defaultSpeedMS)
+	if s.SpeedMS != nil && *s.SpeedMS > 1 {
+		speed = *s.SpeedMS
+	}
+	distKm := geo.HaversineKm(s.Position, o.Position())
+	if distKm > maxRadiusKm+speed*half.Seconds()/1000 {
+		return Overflight{}, false
+	}
+
+	// Along- and cross-track offsets of the observer from the aircraft, in km.
+	offsetS := 0.0
+	horizKm := distKm
+	if s.Track != nil {
+		rel := (geo.BearingDeg(s.Position, o.Position()) - *s.Track) * math.Pi / 180
+		along, cross := distKm*math.Cos(rel), distKm*math.Sin(rel)
+		reach := speed * half.Seconds() / 1000
+		at := math.Max(-reach, math.Min(reach, along))
+		offsetS = at * 1000 / speed
+		horizKm = math.Hypot(cross, along-at)
+	}
+	if horizKm > maxRadiusKm {
+		return Overflight{}, false
+	}
+	alt := s.AltM
+	if s.VerticalRate != nil {
+		alt += *s.VerticalRate * offsetS
+	}
+	alt = math.Max(alt, 0)
+
+	slantM := math.Max(math.Hypot(horizKm*1000, alt), 100)
+	level := referenceDB[s.Class] - 20*math.Log10(slantM/referenceM) - absorptionDBPerKm*(slantM-referenceM)/1000
+	if s.Movement == "departure" {
+		level += departureBoostDB
+	}
+	if level < minEventDB {
+		return Overflight{}, false
+	}
+	// A straight pass at constant speed exposes the observer for an effective
+	// pi*d/v seconds at the peak level.
+	sel := level + math.Max(0, 10*math.Log10(math.Pi*slantM/speed))
+	return Overflight{
+		Observer:   o.Name,
+		ICAO24:     s.ICAO24,
+		Callsign:   s.Callsign,
+		Type:       s.Type,
+		Class:      s.Class,
+		Movement:   s.Movement,
+		Time:       now.Add(time.Duration(offsetS * float64(time.Second))),
+		DistanceKm: math.Round(horizKm*100) / 100,
+		AltFt:      math.Round(alt * geo.MetresToFeet),
+		LAmaxDB:    math.Round(level*10) / 10,
+		SELDB:      math.Round(sel*10) / 10,
+	}, true
+}
+
+// recordLocked adds an overflight to its observer's day.
+func (m *Meter) recordLocked(o Overflight) {
+	date := o.Time.Local().Format(time.DateOnly)
+	var day *Day
+	for _, d := range m.state.Days {
+		if d.Date == date && d.Observer == o.Observer {
+			day = d
+			break
+		}
+	}
+	if day == nil {
+		day = &Day{Date: date, Observer: o.Observer, Above: make(map[string]int)}
+		for _, th := range m.thresholds {
+			day.Above[ThresholdKey(th)] = 0
+		}
+		m.state.Days = append(m.state.Days, day)
+		sort.Slice(m.state.Days, func(i, j int) bool {
+			a, b := m.state.Days[i], m.state.Days[j]
+			if a.Date != b.Date {
+				return a.Date < b.Date
+			}
+			return a.Observer < b.Observer
+		})
+		if n := len(m.state.Days) - maxDays*len(m.observers); n > 0 {
+			m.state.Days = m.state.Days[n:]
+		}
+	}
+	day.add(o, m.thresholds)
+
+	m.state.Overflights = append(m.state.Overflights, o)
+	if n := len(m.state.Overflights) - recentOverflights; n > 0 {
+		m.state.Overflights = m.state.Overflights[n:]
+	}
+	m.dirty = true
+}
+
+// Days returns copies of the daily totals, oldest first. An empty observer
+// matches all of them; from and to are inclusive YYYY-MM-DD dates, "" for open.
+func (m *Meter) Days(observer, from, to string) []Day {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	out := []Day{}
+	for _, d := range m.state.Days {
+		if (observer == "" || d.Observer == observer) && (from == "" || d.Date >= from) && (to == "" || d.Date <= to) {
+			c := *d
+			c.Above = make(map[string]int, len(d.Above))
+			for k, v := range d.Above {
+				c.Above[k] = v
+			}
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// Overflights returns the recent overflights, most recent first, for observer
+// ("" for all) on date (YYYY-MM-DD, "" for any).
+func (m *Meter) Overflights(observer, date string) []Overflight {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	out := []Overflight{}
+	for i := len(m.state.Overflights) - 1; i >= 0; i-- {
+		o := m.state.Overflights[i]
+		if (observer == "" || o.Observer == observer) && (date == "" || o.Time.Local().Format(time.DateOnly) == date) {
+			out = append(out, o)
+		}
+	}
+	return out
+}
+
+// Save writes the totals if anything was counted since the last save.
+func (m *Meter) Save() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.dirty || m.path == "" {
+		return nil
+	}
+	if err := jsonfile.Write(m.path, m.state); err != nil {
+		return err
+	}
+	m.dirty = false
+	return nil
+}
+
+// Flush counts the passes still in progress and writes the totals.
+func (m *Meter) Flush() error {
+	m.mu.Lock()
+	for key, p := range m.passes {
+		m.recordLocked(p)
+		delete(m.passes, key)
+	}
+	m.mu.Unlock()
+	return m.Save()
+}
@@ -0,0 +1,218 @@
+package noise
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"AirTraffic-Monitor/geo"
+)
+
+var home = Observer{Name: "Home", Lat: 51.5, Lon: -0.1}
+
+func ptr(v float64) *float64 { return &v }
+
+// local returns a time on 18 October 2026 in the local zone, which days and
+// Lden periods are counted in.
+func local(hour, minute int) time.Time {
+	return time.Date(2026, 10, 18, hour, minute, 0, 0, time.Local)
+}
+
+func TestClassFor(t *testing.T) {
+	tests := []struct{ kind, wake, engine, want string }{
+		{"airliner", "H", "Jet", ClassHeavyJet},
+		{"airliner", "M", "Jet", ClassJet},
+		{"business", "L", "Jet", ClassLightJet},
+		{"airliner", "M", "Turboprop", ClassTurboprop},
+		{"light", "L", "Piston", ClassPiston},
+		{"helicopter", "L", "Turboshaft", ClassHelicopter},
+		{"glider", "L", "", ""},
+		{"", "", "", ClassUnknown},
+	}
+	for _, tt := range tests {
+		if got := ClassFor(tt.kind, tt.wake, tt.engine); got != tt.want {
+			t.Errorf("ClassFor(%q, %q, %q) = %q, want %q", tt.kind, tt.wake, tt.engine, got, tt.want)
+		}
+	}
+}
+
+func TestEstimate(t *testing.T) {
+	now := local(12, 0)
+	tests := []struct {
+		name      string
+		s         Sample
+		wantOK    bool
+		wantLAmax float64
+		wantSEL   float64
+		wantAt    time.Time
+	}{
+		{
+			name:   "jet overhead at the reference height",
+			s:      Sample{Class: ClassJet, Position: home.Position(), AltM: referenceM, SpeedMS: ptr(70)},
+			wantOK: true, wantLAmax: 84, wantSEL: 95.4, wantAt: now,
+		},
+		{
+			name:   "departures add climb thrust",
+			s:      Sample{Class: ClassJet, Movement: "departure", Position: home.Position(), AltM: referenceM, SpeedMS: ptr(70)},
+			wantOK: true, wantLAmax: 87, wantSEL: 98.4, wantAt: now,
+		},
+		{
+			name:   "faster passes expose the observer for less time",
+			s:      Sample{Class: ClassJet, Position: home.Position(), AltM: referenceM, SpeedMS: ptr(100)},
+			wantOK: true, wantLAmax: 84, wantSEL: 93.8, wantAt: now,
+		},
+		{
+			name: "projected to the closest approach along the track",
+			s: Sample{Class: ClassJet, Position: geo.Destination(home.Position(), 270, 2), AltM: 1000,
+				Track: ptr(90), SpeedMS: ptr(100)},
+			wantOK: true, wantLAmax: 70.9, wantAt: now.Add(20 * time.Second),
+		},
+		{
+			name: "piston too high to count",
+			s:    Sample{Class: ClassPiston, Position: home.Position(), AltM: 3000},
+		},
+		{
+			name: "beyond the radius",
+			s:    Sample{Class: ClassHeavyJet, Position: geo.Destination(home.Position(), 0, 40), AltM: 300, Track: ptr(90)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o, ok := estimate(tt.s, home, now, maxHalfSpan)
+			if ok != tt.wantOK {
+				t.Fatalf("counted = %v, want %v (%+v)", ok, tt.wantOK, o)
+			}
+			if !ok {
+				return
+			}
+			if o.LAmaxDB != tt.wantLAmax || (tt.wantSEL != 0 && o.SELDB != tt.wantSEL) {
+				t.Errorf("LAmax %v SEL %v, want %v and %v", o.LAmaxDB, o.SELDB, tt.wantLAmax, tt.wantSEL)
+			}
+			if d := o.Time.Sub(tt.wantAt); d < -time.Second || d > time.Second {
+				t.Errorf("closest approach at %v, want %v", o.Time, tt.wantAt)
+			}
+		})
+	}
+}
+
+func TestDayLden(t *testing.T) {
+	// Each overflight has an SEL of 90 dB, a sound energy of 1e9.
+	tests := []struct {
+		name                  string
+		hours                 []int
+		lden, lnight, leq16   float64
+		wantEvents, wantNight int
+	}{
+		{"day", []int{12}, 40.6, 0, 42.4, 1, 0},
+		{"evening, +5 dB", []int{20}, 45.6, 0, 42.4, 1, 0},
+		{"night, +10 dB", []int{2}, 50.6, 45.4, 0, 1, 1},
+		{"one of each", []int{12, 20, 2}, 52.1, 45.4, 45.4, 3, 1},
+		{"period edges", []int{7, 19, 23}, 52.1, 45.4, 45.4, 3, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &Day{Above: make(map[string]int)}
+			for _, h := range tt.hours {
+				d.add(Overflight{Time: local(h, 0), LAmaxDB: 75, SELDB: 90}, nil)
+			}
+			if d.LdenDB != tt.lden || d.LnightDB != tt.lnight || d.Leq16DB != tt.leq16 {
+				t.Errorf("Lden %v Lnight %v Leq16 %v, want %v %v %v", d.LdenDB, d.LnightDB, d.Leq16DB, tt.lden, tt.lnight, tt.leq16)
+			}
+			if d.Events != tt.wantEvents || d.NightEvents != tt.wantNight {
+				t.Errorf("%d events, %d at night; want %d and %d", d.Events, d.NightEvents, tt.wantEvents, tt.wantNight)
+			}
+		})
+	}
+}
+
+func TestDayThresholds(t *testing.T) {
+	d := &Day{Above: make(map[string]int)}
+	for _, level := range []float64{58, 60, 64.9, 66, 71} {
+		d.add(Overflight{Time: local(12, 0), LAmaxDB: level, SELDB: level + 10}, DefaultThresholds)
+	}
+	want := map[string]int{"N60": 4, "N65": 2, "N70": 1}
+	for key, n := range want {
+		if d.Above[key] != n {
+			t.Errorf("%s = %d, want %d", key, d.Above[key], n)
+		}
+	}
+	if d.MaxDB != 71 {
+		t.Errorf("max %v, want 71", d.MaxDB)
+	}
+	if got := ThresholdKey(62.5); got != "N62.5" {
+		t.Errorf("ThresholdKey(62.5) = %q", got)
+	}
+}
+
+// overhead returns a jet passing over home at altM.
+func overhead(icao24 string, altM float64) Sample {
+	return Sample{ICAO24: icao24, Class: ClassJet, Position: home.Position(), AltM: altM, SpeedMS: ptr(70)}
+}
+
+func TestMeterPasses(t *testing.T) {
+	m, err := Load("", []Observer{home}, DefaultThresholds)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A pass is counted once, at its loudest, when the aircraft leaves.
+	m.Update([]Sample{overhead("400A0B", 600)}, local(12, 0))
+	m.Update([]Sample{overhead("400A0B", referenceM)}, local(12, 5))
+	if n := len(m.Overflights("", "")); n != 0 {
+		t.Fatalf("%d overflights counted while the pass is in progress", n)
+	}
+	m.Update(nil, local(12, 10))
+	got := m.Overflights("Home", "")
+	if len(got) != 1 || got[0].LAmaxDB != 84 || got[0].ICAO24 != "400A0B" {
+		t.Fatalf("overflights = %+v, want one at 84 dB", got)
+	}
+
+	// Passes either side of midnight fall on different days.
+	m.Update([]Sample{overhead("4CA123", referenceM)}, local(23, 59))
+	m.Update(nil, local(23, 59).Add(5*time.Minute))
+	m.Update([]Sample{overhead("4CA123", referenceM)}, local(23, 59).Add(10*time.Minute))
+	if err := m.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	days := m.Days("Home", "", "")
+	if len(days) != 2 || days[0].Date != "2026-10-18" || days[0].Events != 2 || days[1].Date != "2026-10-19" || days[1].Events != 1 {
+		t.Fatalf("days = %+v", days)
+	}
+	if days[0].Above["N70"] != 2 || days[1].NightEvents != 1 {
+		t.Errorf("N70 %d, night events %d", days[0].Above["N70"], days[1].NightEvents)
+	}
+	if got := m.Days("Home", "2026-10-19", ""); len(got) != 1 {
+		t.Errorf("from filter kept %d days", len(got))
+	}
+}
+
+func TestMeterSaveKeepsEnergyOutOfDays(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "noise.json")
+	m, err := Load(path, []Observer{home}, DefaultThresholds)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.Update([]Sample{overhead("400A0B", referenceM)}, local(12, 0))
+	if err := m.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	before := m.Days("", "", "")[0]
+	if data, _ := json.Marshal(before); strings.Contains(string(data), "energy") {
+		t.Errorf("public day carries the energy sums: %s", data)
+	}
+
+	// The energy survives a restart, so the next pass adds to the same Lden.
+	m, err = Load(path, []Observer{home}, DefaultThresholds)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.Update([]Sample{overhead("4CA123", referenceM)}, local(13, 0))
+	if err := m.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	after := m.Days("", "", "")[0]
+	if after.Events != 2 || after.LdenDB != before.LdenDB+3 {
+		t.Errorf("after reload: %d events, Lden %v; want 2 and %v", after.Events, after.LdenDB, before.LdenDB+3)
+	}
+}
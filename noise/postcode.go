@@ -0,0 +1,69 @@
+package noise
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// DefaultPostcodesURL is the public postcodes.io API, which covers the UK.
+const DefaultPostcodesURL = "https://api.postcodes.io"
+
+// ResolvePostcodes fills in the coordinates of observers given only a
+// postcode, looking them up at baseURL (DefaultPostcodesURL if empty).
+// Observers with coordinates are left alone.
+func ResolvePostcodes(ctx context.Context, hc *http.Client, baseURL string, observers []Observer) error {
+	if baseURL == "" {
+		baseURL = DefaultPostcodesURL
+	}
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+	for i, o := range observers {
+		if o.located() || o.Postcode == "" {
+			continue
+		}
+		lat, lon, err := lookupPostcode(ctx, hc, strings.TrimRight(baseURL, "/"), o.Postcode)
+		if err != nil {
+			return fmt.Errorf("noise observer %q: %w", o.Name, err)
+		}
+		observers[i].Lat, observers[i].Lon = lat, lon
+	}
+	return nil
+}
+
+func lookupPostcode(ctx context.Context, hc *http.Client, baseURL, postcode string) (float64, float64, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, baseURL+"/postcodes/"+url.PathEscape(strings.TrimSpace(postcode)), nil)
+	if err != nil {
+		return 0, 0, err
+	}
+	res, err := hc.Do(req)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer res.Body.Close()
+
+	switch res.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return 0, 0, fmt.Errorf("unknown postcode %q", postcode)
+	default:
+		return 0, 0, fmt.Errorf("postcode lookup %q: unexpected status %d", postcode, res.StatusCode)
+	}
+	var body struct {
+		Result *struct {
+			Latitude  *float64 `json:"latitude"`
+			Longitude *float64 `json:"longitude"`
+		} `json:"result"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+		return 0, 0, fmt.Errorf("postcode lookup %q: %w", postcode, err)
+	}
+	if body.Result == nil || body.Result.Latitude == nil || body.Result.Longitude == nil {
+		return 0, 0, fmt.Errorf("postcode %q has no coordinates", postcode)
+	}
+	return *body.Result.Latitude, *body.Result.Longitude, nil
+}
@@ -0,0 +1,234 @@
+// Package opensky is a client for the OpenSky Network states API.
+//
+// Reference: https://opensky-network.org/apidoc/rest.html#flights-in-a-bounding-box
+// The states endpoint returns JSON with field "states": [[icao24, callsign, origin_country,
+// time_position, last_contact, longitude, latitude, baro_altitude, on_ground, velocity,
+// true_track, vertical_rate, sensors, geo_altitude, squawk, spi, position_source, category]]
+package opensky
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"AirTraffic-Monitor/geo"
+)
+
+// DefaultBaseURL is the public, anonymous OpenSky API.
+const DefaultBaseURL = "https://opensky-network.org"
+
+// Client fetches state vectors from an OpenSky-compatible server.
+type Client struct {
+	BaseURL string
+	HTTP    *http.Client
+}
+
+// NewClient returns a client for baseURL (DefaultBaseURL if empty) using hc.
+func NewClient(baseURL string, hc *http.Client) *Client {
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+	return &Client{BaseURL: strings.TrimRight(baseURL, "/"), HTTP: hc}
+}
+
+// StatusError is returned for any non-200 response.
+type StatusError struct {
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("opensky unexpected status %d", e.StatusCode)
+}
+
+// HTTPStatus exposes the status code to logging.StatusCode.
+func (e *StatusError) HTTPStatus() int {
+	return e.StatusCode
+}
+
+// StatesResponse is the raw states/all payload.
+type StatesResponse struct {
+	Time   int64           `json:"time"`
+	States [][]interface{} `json:"states"`
+}
+
+// State holds the fields we use from one OpenSky state vector.
+// Optional numeric fields are nil when OpenSky reports null.
+type State struct {
+	ICAO24        string
+	Callsign      string
+	OriginCountry string
+	TimePosition  int64 // unix time of the last position report; 0 when none
+	LastContact   int64 // unix time of the last message of any kind; 0 when unknown
+	Longitude     *float64
+	Latitude      *float64
+	BaroAltitude  *float64 // metres
+	OnGround      bool
+	Velocity      *float64 // m/s over ground
+	TrueTrack     *float64 // degrees clockwise from north
+	VerticalRate  *float64 // m/s
+	GeoAltitude   *float64 // metres
+	Squawk        string
+	Category      int // ADS-B emitter category; 0 when not sent (see actype)
+}
+
+// States fetches the aircraft currently inside bbox.
+func (c *Client) States(ctx context.Context, bbox geo.BBox) ([]State, error) {
+	q := url.Values{}
+	q.Set("lamin", formatCoord(bbox.LaMin))
+	q.Set("lomin", formatCoord(bbox.LoMin))
+	q.Set("lamax", formatCoord(bbox.LaMax))
+	q.Set("lomax", formatCoord(bbox.LoMax))
+	q.Set("extended", "1") // adds the emitter category at index 17
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.BaseURL+"/api/states/all?"+q.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	res, err := c.HTTP.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, &StatusError{StatusCode: res.StatusCode}
+	}
+	var payload StatesResponse
+	if err := json.NewDecoder(res.Body).Decode(&payload); err != nil {
+		return nil, err
+	}
+	return ExtractStates(&payload), nil
+}
+
+func formatCoord(v float64) string {
+	return strconv.FormatFloat(v, 'f', 2, 64)
+}
+
+// ExtractStates parses the states array, keeping one entry per icao24 (index 0),
+// sorted by icao24 for consistent output.
+func ExtractStates(data *StatesResponse) []State {
+	if data == nil || len(data.States) == 0 {
+		return nil
+	}
+	seen := make(map[string]struct{})
+	var states []State
+
+	for _, row := range data.States {
+		if len(row) < 2 {
+			continue
+		}
+		hex, _ := row[0].(string)
+		if hex == "" {
+			continue
+		}
+		// OpenSky returns lowercase; adsbdb expects uppercase for Mode S. Convert.
+		hex = strings.ToUpper(hex)
+
+		// Avoid duplicates
+		if _, exists := seen[hex]; exists {
+			continue
+		}
+		seen[hex] = struct{}{}
+
+		callsign, _ := row[1].(string)
+		callsign = strings.TrimSpace(callsign)
+
+		states = append(states, State{
+			ICAO24:        hex,
+			Callsign:      callsign,
+			OriginCountry: stateString(row, 2),
+			TimePosition:  int64(stateInt(row, 3)),
+			LastContact:   int64(stateInt(row, 4)),
+			Longitude:     stateFloat(row, 5),
+			Latitude:      stateFloat(row, 6),
+			BaroAltitude:  stateFloat(row, 7),
+			OnGround:      stateBool(row, 8),
+			Velocity:      stateFloat(row, 9),
+			TrueTrack:     stateFloat(row, 10),
+			VerticalRate:  stateFloat(row, 11),
+			GeoAltitude:   stateFloat(row, 13),
+			Squawk:        stateString(row, 14),
+			Category:      stateInt(row, 17),
+		})
+	}
+
+	sort.Slice(states, func(i, j int) bool {
+		return states[i].ICAO24 < states[j].ICAO24
+	})
+	return states
+}
+
+// StateRow encodes a state in the positional array format of states/all, the
+// inverse of ExtractStates.
+func StateRow(st State) []interface{} {
+	f := func(v *float64) interface{} {
+		if v == nil {
+			return nil
+		}
+		return *v
+	}
+	var squawk, timePosition, lastContact interface{}
+	if st.Squawk != "" {
+		squawk = st.Squawk
+	}
+	if st.TimePosition != 0 {
+		timePosition = st.TimePosition
+	}
+	if st.LastContact != 0 {
+		lastContact = st.LastContact
+	}
+	return []interface{}{
+		strings.ToLower(st.ICAO24), padCallsign(st.Callsign), st.OriginCountry, timePosition, lastContact,
+		f(st.Longitude), f(st.Latitude), f(st.BaroAltitude), st.OnGround, f(st.Velocity),
+		f(st.TrueTrack), f(st.VerticalRate), nil, f(st.GeoAltitude), squawk, false, 0, st.Category,
+	}
+}
+
+// padCallsign mimics OpenSky's fixed-width, space-padded callsigns.
+func padCallsign(c string) string {
+	for len(c) < 8 {
+		c += " "
+	}
+	return c
+}
+
+// stateFloat returns row[i] as a float, or nil when it is missing or null.
+func stateFloat(row []interface{}, i int) *float64 {
+	if i >= len(row) {
+		return nil
+	}
+	v, ok := row[i].(float64)
+	if !ok {
+		return nil
+	}
+	return &v
+}
+
+func stateInt(row []interface{}, i int) int {
+	if v := stateFloat(row, i); v != nil {
+		return int(*v)
+	}
+	return 0
+}
+
+func stateString(row []interface{}, i int) string {
+	if i >= len(row) {
+		return ""
+	}
+	v, _ := row[i].(string)
+	return strings.TrimSpace(v)
+}
+
+func stateBool(row []interface{}, i int) bool {
+	if i >= len(row) {
+		return false
+	}
+	v, _ := row[i].(bool)
+	return v
+}
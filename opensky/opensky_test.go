@@ -0,0 +1,93 @@
+package opensky_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"AirTraffic-Monitor/fakeupstream"
+	"AirTraffic-Monitor/geo"
+	"AirTraffic-Monitor/opensky"
+)
+
+var london = geo.BBox{LaMin: 51.2, LoMin: -0.6, LaMax: 51.8, LoMax: 0.3}
+
+func ptr(v float64) *float64 { return &v }
+
+func TestStates(t *testing.T) {
+	fake := fakeupstream.New()
+	defer fake.Close()
+	fake.SetStates(
+		opensky.State{ICAO24: "4ca123", Callsign: "RYR1AB", OriginCountry: "Ireland", TimePosition: 1700000000,
+			Latitude: ptr(51.5), Longitude: ptr(-0.1), BaroAltitude: ptr(3000), Velocity: ptr(150),
+			TrueTrack: ptr(270), VerticalRate: ptr(-3.5), Squawk: "7000", Category: 3},
+		opensky.State{ICAO24: "400a0b", Callsign: "BAW12", OnGround: true},
+		opensky.State{ICAO24: "4CA123", Callsign: "DUPLICATE"},
+	)
+
+	states, err := opensky.NewClient(fake.OpenSky.URL, nil).States(context.Background(), london)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(states) != 2 {
+		t.Fatalf("got %d states, want 2 (duplicates dropped): %+v", len(states), states)
+	}
+
+	ba, ryr := states[0], states[1] // sorted by ICAO24
+	if ba.ICAO24 != "400A0B" || ba.Callsign != "BAW12" || !ba.OnGround || ba.Latitude != nil {
+		t.Errorf("BAW12 = %+v", ba)
+	}
+	if ryr.ICAO24 != "4CA123" || ryr.Callsign != "RYR1AB" || ryr.OriginCountry != "Ireland" {
+		t.Errorf("RYR1AB identity = %+v", ryr)
+	}
+	if ryr.TimePosition != 1700000000 || ryr.Squawk != "7000" || ryr.Category != 3 {
+		t.Errorf("RYR1AB time/squawk/category = %d/%q/%d", ryr.TimePosition, ryr.Squawk, ryr.Category)
+	}
+	for name, got := range map[string]*float64{"lat": ryr.Latitude, "lon": ryr.Longitude, "alt": ryr.BaroAltitude,
+		"speed": ryr.Velocity, "track": ryr.TrueTrack, "vrate": ryr.VerticalRate} {
+		if got == nil {
+			t.Errorf("RYR1AB %s missing", name)
+		}
+	}
+	if *ryr.VerticalRate != -3.5 || *ryr.TrueTrack != 270 {
+		t.Errorf("RYR1AB vrate/track = %v/%v", *ryr.VerticalRate, *ryr.TrueTrack)
+	}
+	if n := fake.Requests()["/api/states/all"]; n != 1 {
+		t.Errorf("states requests = %d, want 1", n)
+	}
+}
+
+func TestStatesEmpty(t *testing.T) {
+	fake := fakeupstream.New()
+	defer fake.Close()
+	states, err := opensky.NewClient(fake.OpenSky.URL, nil).States(context.Background(), london)
+	if err != nil || len(states) != 0 {
+		t.Fatalf("States() = %v, %v; want no states and no error", states, err)
+	}
+}
+
+func TestStatesErrorStatus(t *testing.T) {
+	for _, status := range []int{http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusServiceUnavailable} {
+		t.Run(http.StatusText(status), func(t *testing.T) {
+			fake := fakeupstream.New()
+			defer fake.Close()
+			fake.SetStates(opensky.State{ICAO24: "4ca123"})
+			fake.FailPath("/api/states", status)
+
+			_, err := opensky.NewClient(fake.OpenSky.URL, nil).States(context.Background(), london)
+			var se *opensky.StatusError
+			if !errors.As(err, &se) || se.StatusCode != status {
+				t.Fatalf("States() error = %v, want StatusError %d", err, status)
+			}
+			if se.HTTPStatus() != status {
+				t.Errorf("HTTPStatus() = %d", se.HTTPStatus())
+			}
+
+			fake.FailPath("/api/states", 0)
+			if _, err := opensky.NewClient(fake.OpenSky.URL, nil).States(context.Background(), london); err != nil {
+				t.Errorf("after recovery: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,293 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"AirTraffic-Monitor/geo"
+	"AirTraffic-Monitor/monitor"
+	"AirTraffic-Monitor/noise"
+	"AirTraffic-Monitor/watchlist"
+)
+
+// msToKnots converts speeds for the speed_kt column.
+const msToKnots = 1.94384
+
+// Output formats for -format.
+const (
+	formatTable = "table"
+	formatJSON  = "json"
+	formatCSV   = "csv"
+)
+
+func checkFormat(format string) error {
+	switch format {
+	case formatTable, formatJSON, formatCSV:
+		return nil
+	}
+	return fmt.Errorf("-format must be table, json or csv, got %q", format)
+}
+
+// column is one field of a table or CSV row. Columns marked wide are left out
+// of tables to keep them within a terminal; CSV has them all.
+type column[T any] struct {
+	name  string
+	wide  bool
+	value func(T) string
+}
+
+// rows is data laid out for the table and csv formats.
+type rows struct {
+	header []string
+	cells  [][]string
+}
+
+func tabulate[T any](cols []column[T], items []T, format string) rows {
+	var r rows
+	var keep []column[T]
+	for _, c := range cols {
+		if !c.wide || format == formatCSV {
+			keep = append(keep, c)
+			r.header = append(r.header, c.name)
+		}
+	}
+	for _, item := range items {
+		row := make([]string, len(keep))
+		for i, c := range keep {
+			row[i] = c.value(item)
+		}
+		r.cells = append(r.cells, row)
+	}
+	return r
+}
+
+// write renders v as indented JSON, or r as CSV (with a header line when
+// header is set) or as an aligned table.
+func write(w io.Writer, format string, v any, r rows, header bool) error {
+	switch format {
+	case formatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	case formatCSV:
+		cw := csv.NewWriter(w)
+		if header {
+			cw.Write(r.header)
+		}
+		cw.WriteAll(r.cells)
+		return cw.Error()
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	names := make([]string, len(r.header))
+	for i, h := range r.header {
+		names[i] = strings.ToUpper(strings.ReplaceAll(h, "_", " "))
+	}
+	fmt.Fprintln(tw, strings.Join(names, "\t"))
+	for _, row := range r.cells {
+		cells := make([]string, len(row))
+		for i, c := range row {
+			if cells[i] = c; c == "" {
+				cells[i] = "-"
+			}
+		}
+		fmt.Fprintln(tw, strings.Join(cells, "\t"))
+	}
+	return tw.Flush()
+}
+
+// writeRecord renders one item: a column/value list as a table, a single CSV
+// row, or v as JSON.
+func writeRecord[T any](w io.Writer, format string, cols []column[T], item T, v any) error {
+	if format != formatTable {
+		return write(w, format, v, tabulate(cols, []T{item}, format), true)
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, c := range cols {
+		if value := c.value(item); value != "" {
+			fmt.Fprintf(tw, "%s\t%s\n", strings.ReplaceAll(c.name, "_", " "), value)
+		}
+	}
+	return tw.Flush()
+}
+
+func formatFloat(v *float64, scale float64, prec int) string {
+	if v == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*v*scale, 'f', prec, 64)
+}
+
+func formatBool(b bool) string {
+	if b {
+		return "yes"
+	}
+	return ""
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Local().Format(time.RFC3339)
+}
+
+func airportCode(a *monitor.AirportInfo) string {
+	if a == nil {
+		return ""
+	}
+	return a.ICAO
+}
+
+var aircraftColumns = []column[monitor.AircraftInfo]{
+	{"icao24", false, func(a monitor.AircraftInfo) string { return a.ICAO24 }},
+	{"callsign", false, func(a monitor.AircraftInfo) string { return a.Callsign }},
+	{"registration", false, func(a monitor.AircraftInfo) string { return a.Registration }},
+	{"type", false, func(a monitor.AircraftInfo) string { return a.ICAOType }},
+	{"model", true, func(a monitor.AircraftInfo) string { return strings.TrimSpace(a.Manufacturer + " " + a.Type) }},
+	{"kind", true, func(a monitor.AircraftInfo) string { return a.Kind }},
+	{"owner", true, func(a monitor.AircraftInfo) string { return a.Owner }},
+	{"country", true, func(a monitor.AircraftInfo) string { return a.RegistrationCountry }},
+	{"airline", false, func(a monitor.AircraftInfo) string { return a.Airline }},
+	{"origin", false, func(a monitor.AircraftInfo) string { return airportCode(a.OriginAirport) }},
+	{"destination", false, func(a monitor.AircraftInfo) string { return airportCode(a.DestinationAirport) }},
+	{"route_confidence", true, func(a monitor.AircraftInfo) string { return a.RouteConfidence }},
+	{"movement", true, func(a monitor.AircraftInfo) string { return a.Movement }},
+	{"status", false, func(a monitor.AircraftInfo) string { return a.Status }},
+	{"latitude", true, func(a monitor.AircraftInfo) string { return formatFloat(a.Latitude, 1, 5) }},
+	{"longitude", true, func(a monitor.AircraftInfo) string { return formatFloat(a.Longitude, 1, 5) }},
+	{"altitude_ft", false, func(a monitor.AircraftInfo) string { return formatFloat(a.BaroAltitude, geo.MetresToFeet, 0) }},
+	{"speed_kt", false, func(a monitor.AircraftInfo) string { return formatFloat(a.Velocity, msToKnots, 0) }},
+	{"track", true, func(a monitor.AircraftInfo) string { return formatFloat(a.Track, 1, 0) }},
+	{"vertical_rate_fpm", true, func(a monitor.AircraftInfo) string { return formatFloat(a.VerticalRate, geo.MetresToFeet*60, 0) }},
+	{"on_ground", true, func(a monitor.AircraftInfo) string { return formatBool(a.OnGround) }},
+	{"squawk", true, func(a monitor.AircraftInfo) string { return a.Squawk }},
+	{"distance_km", false, func(a monitor.AircraftInfo) string { return formatFloat(a.DistanceKm, 1, 1) }},
+	{"sources", true, func(a monitor.AircraftInfo) string { return strings.Join(a.Sources, " ") }},
+	{"anomaly_score", true, func(a monitor.AircraftInfo) string {
+		if a.AnomalyScore == 0 {
+			return ""
+		}
+		return strconv.FormatFloat(a.AnomalyScore, 'f', 2, 64)
+	}},
+	{"military", true, func(a monitor.AircraftInfo) string { return formatBool(a.LikelyMilitary) }},
+	{"watched", true, func(a monitor.AircraftInfo) string { return strings.Join(a.WatchLabels, " ") }},
+	{"updated", true, func(a monitor.AircraftInfo) string { return a.LastUpdated }},
+}
+
+var eventColumns = []column[monitor.Event]{
+	{"time", false, func(e monitor.Event) string { return formatTime(e.Time) }},
+	{"type", false, func(e monitor.Event) string { return e.Type }},
+	{"icao24", false, func(e monitor.Event) string { return e.ICAO24 }},
+	{"callsign", false, func(e monitor.Event) string { return e.Callsign }},
+	{"registration", false, func(e monitor.Event) string { return e.Registration }},
+	{"airport", false, func(e monitor.Event) string { return e.Airport }},
+	{"runway", false, func(e monitor.Event) string { return e.Runway }},
+	{"detail", false, func(e monitor.Event) string { return e.Detail }},
+}
+
+var sightingColumns = []column[watchlist.Sighting]{
+	{"icao24", false, func(s watchlist.Sighting) string { return s.ICAO24 }},
+	{"registration", false, func(s watchlist.Sighting) string { return s.Registration }},
+	{"type", false, func(s watchlist.Sighting) string { return s.Type }},
+	{"callsign", false, func(s watchlist.Sighting) string { return s.Callsign }},
+	{"labels", false, func(s watchlist.Sighting) string { return strings.Join(s.Labels, " ") }},
+	{"entry_ids", true, func(s watchlist.Sighting) string { return strings.Join(s.EntryIDs, " ") }},
+	{"first_seen", false, func(s watchlist.Sighting) string { return formatTime(s.FirstSeen) }},
+	{"last_seen", false, func(s watchlist.Sighting) string { return formatTime(s.LastSeen) }},
+	{"cycles", false, func(s watchlist.Sighting) string { return strconv.Itoa(s.Cycles) }},
+}
+
+// noiseDayColumns includes a count column for each threshold, e.g. "N65".
+func noiseDayColumns(thresholds []float64) []column[noise.Day] {
+	cols := []column[noise.Day]{
+		{"date", false, func(d noise.Day) string { return d.Date }},
+		{"observer", false, func(d noise.Day) string { return d.Observer }},
+		{"events", false, func(d noise.Day) string { return strconv.Itoa(d.Events) }},
+		{"night_events", false, func(d noise.Day) string { return strconv.Itoa(d.NightEvents) }},
+	}
+	for _, th := range thresholds {
+		key := noise.ThresholdKey(th)
+		cols = append(cols, column[noise.Day]{key, false, func(d noise.Day) string { return strconv.Itoa(d.Above[key]) }})
+	}
+	dB := func(v float64) string { return strconv.FormatFloat(v, 'f', 1, 64) }
+	return append(cols,
+		column[noise.Day]{"max_db", false, func(d noise.Day) string { return dB(d.MaxDB) }},
+		column[noise.Day]{"lden_db", false, func(d noise.Day) string { return dB(d.LdenDB) }},
+		column[noise.Day]{"lnight_db", false, func(d noise.Day) string { return dB(d.LnightDB) }},
+		column[noise.Day]{"leq16_db", false, func(d noise.Day) string { return dB(d.Leq16DB) }},
+	)
+}
+
+var overflightColumns = []column[noise.Overflight]{
+	{"time", false, func(o noise.Overflight) string { return formatTime(o.Time) }},
+	{"observer", false, func(o noise.Overflight) string { return o.Observer }},
+	{"icao24", false, func(o noise.Overflight) string { return o.ICAO24 }},
+	{"callsign", false, func(o noise.Overflight) string { return o.Callsign }},
+	{"type", false, func(o noise.Overflight) string { return o.Type }},
+	{"class", true, func(o noise.Overflight) string { return o.Class }},
+	{"movement", false, func(o noise.Overflight) string { return o.Movement }},
+	{"distance_km", false, func(o noise.Overflight) string { return strconv.FormatFloat(o.DistanceKm, 'f', 1, 64) }},
+	{"alt_ft", false, func(o noise.Overflight) string { return strconv.FormatFloat(o.AltFt, 'f', 0, 64) }},
+	{"lamax_db", false, func(o noise.Overflight) string { return strconv.FormatFloat(o.LAmaxDB, 'f', 1, 64) }},
+	{"sel_db", true, func(o noise.Overflight) string { return strconv.FormatFloat(o.SELDB, 'f', 1, 64) }},
+}
+
+// cyclePrinter writes every cycle to W, for serve -format. CSV has one header
+// line for the whole run.
+type cyclePrinter struct {
+	W       io.Writer
+	Format  string
+	Region  string
+	started bool
+}
+
+func (p *cyclePrinter) Publish(ctx context.Context, c monitor.Cycle) error {
+	err := writeCycle(p.W, p.Format, p.Region, c, !p.started)
+	p.started = true
+	return err
+}
+
+// writeCycle renders a cycle's aircraft, plus its events in the table and
+// JSON formats.
+func writeCycle(w io.Writer, format, region string, c monitor.Cycle, header bool) error {
+	aircraft := tabulate(aircraftColumns, c.Aircraft, format)
+	switch format {
+	case formatJSON:
+		return write(w, format, struct {
+			Region     string                 `json:"region"`
+			LastUpdate string                 `json:"last_update"`
+			Count      int                    `json:"count"`
+			Aircraft   []monitor.AircraftInfo `json:"aircraft"`
+			Events     []monitor.Event        `json:"events"`
+		}{region, c.Updated, len(c.Aircraft), nonNil(c.Aircraft), nonNil(c.Events)}, rows{}, false)
+	case formatCSV:
+		return write(w, format, nil, aircraft, header)
+	}
+	fmt.Fprintf(w, "=== Aircraft over %s at %s ===\n", region, c.Updated)
+	if len(c.Aircraft) > 0 {
+		if err := write(w, format, nil, aircraft, true); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(w, "%d aircraft.\n", len(c.Aircraft))
+	if len(c.Events) > 0 {
+		fmt.Fprintln(w)
+		return write(w, format, nil, tabulate(eventColumns, c.Events, format), true)
+	}
+	return nil
+}
+
+// nonNil makes an empty list encode as [] rather than null.
+func nonNil[T any](s []T) []T {
+	if s == nil {
+		return []T{}
+	}
+	return s
+}
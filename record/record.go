@@ -0,0 +1,333 @@
+// Package record archives every raw upstream response, with the request that
+// produced it, for auditing and replay.
+//
+// Each upstream writes gzipped JSON lines, one Record per exchange, to hourly
+// files partitioned by UTC date:
+//
+//	<dir>/2024-06-30/opensky-14.jsonl.gz
+//	<dir>/2024-06-30/adsbdb-14.jsonl.gz
+//	<dir>/2024-06-30/adsbdb-14.1.jsonl.gz   (after the first passed MaxFileBytes)
+//
+// Files are appended to across restarts as further gzip members, which any
+// gzip reader handles. Once the archive exceeds MaxTotalBytes, or files are
+// older than MaxAge, the oldest closed files are deleted.
+package record
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Default caps.
+const (
+	DefaultMaxFileBytes  = 64 << 20
+	DefaultMaxTotalBytes = 1 << 30
+)
+
+// redacted request headers are recorded as "REDACTED".
+var redacted = []string{"Authorization", "Cookie", "Proxy-Authorization"}
+
+// Record is one request and its response.
+type Record struct {
+	Time           time.Time       `json:"time"` // when the request was sent
+	Upstream       string          `json:"upstream"`
+	Method         string          `json:"method"`
+	URL            string          `json:"url"`
+	RequestHeaders http.Header     `json:"request_headers,omitempty"`
+	Status         int             `json:"status"`          // 0 when no response arrived
+	Error          string          `json:"error,omitempty"` // transport error or body read error
+	Headers        http.Header     `json:"headers,omitempty"`
+	LatencyMs      float64         `json:"latency_ms"`          // until the whole body was read
+	Body           json.RawMessage `json:"body,omitempty"`      // when the body is JSON
+	BodyText       string          `json:"body_text,omitempty"` // otherwise
+}
+
+// Recorder writes records to the archive. It is safe for concurrent use.
+type Recorder struct {
+	Dir           string
+	MaxFileBytes  int64         // rotate a file once it is this big
+	MaxTotalBytes int64         // delete the oldest files beyond this; 0 is unlimited
+	MaxAge        time.Duration // delete files older than this; 0 keeps them
+
+	logger *slog.Logger
+	now    func() time.Time
+
+	mu    sync.Mutex
+	files map[string]*file // by upstream
+}
+
+// file is an open archive file.
+type file struct {
+	path string
+	hour string // partition, "2006-01-02/15"
+	f    *os.File
+	gz   *gzip.Writer
+	size int64 // compressed bytes written, including earlier runs
+}
+
+// New returns a recorder writing under dir with the default caps.
+func New(dir string, logger *slog.Logger) (*Recorder, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	if logger == nil {
+		logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+	}
+	return &Recorder{
+		Dir:           dir,
+		MaxFileBytes:  DefaultMaxFileBytes,
+		MaxTotalBytes: DefaultMaxTotalBytes,
+		logger:        logger,
+		now:           time.Now,
+		files:         make(map[string]*file),
+	}, nil
+}
+
+// Transport wraps next (http.DefaultTransport if nil) so every exchange is
+// recorded under upstream.
+func (r *Recorder) Transport(upstream string, next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &transport{rec: r, upstream: upstream, next: next}
+}
+
+type transport struct {
+	rec      *Recorder
+	upstream string
+	next     http.RoundTripper
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	started := t.rec.now()
+	rec := Record{
+		Time:           started.UTC(),
+		Upstream:       t.upstream,
+		Method:         req.Method,
+		URL:            req.URL.String(),
+		RequestHeaders: redact(req.Header),
+	}
+	res, err := t.next.RoundTrip(req)
+	if err != nil {
+		rec.Error = err.Error()
+		rec.LatencyMs = latency(started, t.rec.now())
+		t.rec.write(rec)
+		return nil, err
+	}
+	body, readErr := io.ReadAll(res.Body)
+	res.Body.Close()
+	rec.Status, rec.Headers = res.StatusCode, res.Header.Clone()
+	rec.LatencyMs = latency(started, t.rec.now())
+	if readErr != nil {
+		rec.Error = readErr.Error()
+	}
+	if json.Valid(body) {
+		rec.Body = json.RawMessage(compact(body))
+	} else {
+		rec.BodyText = string(body)
+	}
+	t.rec.write(rec)
+	res.Body = io.NopCloser(bytes.NewReader(body))
+	if readErr != nil {
+		// Hand the caller the same failure it would have seen unrecorded.
+		res.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), errReader{readErr}))
+	}
+	return res, nil
+}
+
+type errReader struct{ err error }
+
+func (e errReader) Read([]byte) (int, error) { return 0, e.err }
+
+func latency(from, to time.Time) float64 {
+	return float64(to.Sub(from).Microseconds()) / 1000
+}
+
+func compact(body []byte) []byte {
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, body); err != nil {
+		return body
+	}
+	return buf.Bytes()
+}
+
+func redact(h http.Header) http.Header {
+	if len(h) == 0 {
+		return nil
+	}
+	out := h.Clone()
+	for _, name := range redacted {
+		if out.Get(name) != "" {
+			out.Set(name, "REDACTED")
+		}
+	}
+	return out
+}
+
+// write appends rec to its upstream's current file. Failures are logged: a
+// broken archive must not take the monitor down.
+func (r *Recorder) write(rec Record) {
+	line, err := json.Marshal(rec)
+	if err != nil {
+		r.logger.Error("failed to encode record", "upstream", rec.Upstream, "url", rec.URL, "err", err)
+		return
+	}
+	line = append(line, '\n')
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	f, rotated, err := r.fileFor(rec.Upstream, rec.Time)
+	if err != nil {
+		r.logger.Error("failed to open archive file", "upstream", rec.Upstream, "err", err)
+		return
+	}
+	if _, err := f.gz.Write(line); err == nil {
+		err = f.gz.Flush()
+	}
+	if err != nil {
+		r.logger.Error("failed to write record", "path", f.path, "err", err)
+		return
+	}
+	if rotated {
+		r.pruneLocked()
+	}
+}
+
+// fileFor returns the file for upstream at t, rotating when the hour changes
+// or the file is full. It reports whether a new file was opened.
+func (r *Recorder) fileFor(upstream string, t time.Time) (*file, bool, error) {
+	hour := t.UTC().Format("2006-01-02/15")
+	f := r.files[upstream]
+	if f != nil && f.hour == hour && f.size < r.MaxFileBytes {
+		return f, false, nil
+	}
+	if f != nil {
+		if err := f.close(); err != nil {
+			r.logger.Warn("failed to close archive file", "path", f.path, "err", err)
+		}
+		delete(r.files, upstream)
+	}
+	day, hh, _ := strings.Cut(hour, "/")
+	if err := os.MkdirAll(filepath.Join(r.Dir, day), 0o755); err != nil {
+		return nil, false, err
+	}
+	for seq := 0; ; seq++ {
+		name := fmt.Sprintf("%s-%s.jsonl.gz", upstream, hh)
+		if seq > 0 {
+			name = fmt.Sprintf("%s-%s.%d.jsonl.gz", upstream, hh, seq)
+		}
+		path := filepath.Join(r.Dir, day, name)
+		var size int64
+		if info, err := os.Stat(path); err == nil {
+			if size = info.Size(); size >= r.MaxFileBytes {
+				continue
+			}
+		}
+		osf, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, false, err
+		}
+		f = &file{path: path, hour: hour, f: osf, size: size}
+		f.gz = gzip.NewWriter(&counter{w: osf, n: &f.size})
+		r.files[upstream] = f
+		return f, true, nil
+	}
+}
+
+func (f *file) close() error {
+	err := f.gz.Close()
+	if cerr := f.f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// counter counts the bytes written through it.
+type counter struct {
+	w io.Writer
+	n *int64
+}
+
+func (c *counter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	*c.n += int64(n)
+	return n, err
+}
+
+// Prune applies MaxAge and MaxTotalBytes now; the recorder also does so
+// whenever it opens a new file.
+func (r *Recorder) Prune() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.pruneLocked()
+}
+
+func (r *Recorder) pruneLocked() {
+	type entry struct {
+		path string
+		size int64
+		mod  time.Time
+	}
+	open := make(map[string]bool, len(r.files))
+	for _, f := range r.files {
+		open[f.path] = true
+	}
+	var entries []entry
+	var total int64
+	filepath.WalkDir(r.Dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !strings.HasSuffix(path, ".jsonl.gz") {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		total += info.Size()
+		if !open[path] {
+			entries = append(entries, entry{path: path, size: info.Size(), mod: info.ModTime()})
+		}
+		return nil
+	})
+	sort.Slice(entries, func(i, j int) bool { return entries[i].mod.Before(entries[j].mod) })
+	now := r.now()
+	for _, e := range entries {
+		expired := r.MaxAge > 0 && now.Sub(e.mod) > r.MaxAge
+		over := r.MaxTotalBytes > 0 && total > r.MaxTotalBytes
+		if !expired && !over {
+			continue
+		}
+		if err := os.Remove(e.path); err != nil {
+			r.logger.Warn("failed to delete archive file", "path", e.path, "err", err)
+			continue
+		}
+		total -= e.size
+		r.logger.Info("deleted archive file", "path", e.path, "expired", expired)
+		os.Remove(filepath.Dir(e.path)) // only succeeds once the day is empty
+	}
+}
+
+// Close flushes and closes the open files.
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var first error
+	for upstream, f := range r.files {
+		if err := f.close(); err != nil && first == nil {
+			first = err
+		}
+		delete(r.files, upstream)
+	}
+	return first
+}
@@ -0,0 +1,265 @@
+package record
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// roundTripper answers every request with status and body, or fails with err.
+type roundTripper struct {
+	status int
+	body   string
+	err    error
+}
+
+func (rt roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if rt.err != nil {
+		return nil, rt.err
+	}
+	return &http.Response{StatusCode: rt.status, Header: http.Header{"Content-Type": {"application/json"}},
+		Body: io.NopCloser(strings.NewReader(rt.body)), Request: req}, nil
+}
+
+// newRecorder returns a recorder under a temporary directory whose clock
+// reads *now.
+func newRecorder(t *testing.T, now *time.Time) *Recorder {
+	t.Helper()
+	r, err := New(t.TempDir(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.now = func() time.Time { return *now }
+	t.Cleanup(func() { r.Close() })
+	return r
+}
+
+// files returns the archive's files relative to dir, sorted.
+func files(t *testing.T, dir string) []string {
+	t.Helper()
+	var out []string
+	filepath.WalkDir(dir, func(p string, d os.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			rel, _ := filepath.Rel(dir, p)
+			out = append(out, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	sort.Strings(out)
+	return out
+}
+
+// readRecords decodes every record in the gzipped file at path.
+func readRecords(t *testing.T, path string) []Record {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var recs []Record
+	dec := json.NewDecoder(zr)
+	for {
+		var rec Record
+		if err := dec.Decode(&rec); errors.Is(err, io.EOF) {
+			return recs
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		recs = append(recs, rec)
+	}
+}
+
+func get(t *testing.T, rt http.RoundTripper, url string, header http.Header) (*http.Response, error) {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header = header
+	return rt.RoundTrip(req)
+}
+
+func TestTransportRecords(t *testing.T) {
+	now := time.Date(2026, 10, 18, 14, 30, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		next     roundTripper
+		want     Record
+		wantBody string // the caller still reads it
+	}{
+		{
+			name:     "JSON body kept as JSON",
+			next:     roundTripper{status: http.StatusOK, body: "{\"time\": 1,\n \"states\": []}"},
+			want:     Record{Status: http.StatusOK, Body: json.RawMessage(`{"time":1,"states":[]}`)},
+			wantBody: "{\"time\": 1,\n \"states\": []}",
+		},
+		{
+			name:     "other bodies kept as text",
+			next:     roundTripper{status: http.StatusTooManyRequests, body: "Too many requests"},
+			want:     Record{Status: http.StatusTooManyRequests, BodyText: "Too many requests"},
+			wantBody: "Too many requests",
+		},
+		{
+			name: "transport error",
+			next: roundTripper{err: errors.New("connection refused")},
+			want: Record{Error: "connection refused"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newRecorder(t, &now)
+			header := http.Header{"Authorization": {"Basic c2VjcmV0"}, "User-Agent": {"monitor"}}
+			res, err := get(t, r.Transport("opensky", tt.next), "https://opensky-network.org/api/states/all?lamin=51", header)
+			if tt.next.err != nil {
+				if err == nil {
+					t.Fatal("transport error was swallowed")
+				}
+			} else {
+				body, _ := io.ReadAll(res.Body)
+				if string(body) != tt.wantBody {
+					t.Errorf("caller read %q, want %q", body, tt.wantBody)
+				}
+			}
+			if err := r.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			recs := readRecords(t, filepath.Join(r.Dir, "2026-10-18", "opensky-14.jsonl.gz"))
+			if len(recs) != 1 {
+				t.Fatalf("%d records, want 1", len(recs))
+			}
+			got := recs[0]
+			if !got.Time.Equal(now) || got.Upstream != "opensky" || got.Method != http.MethodGet ||
+				got.URL != "https://opensky-network.org/api/states/all?lamin=51" {
+				t.Errorf("exchange %v %s %s %s", got.Time, got.Upstream, got.Method, got.URL)
+			}
+			if got.RequestHeaders.Get("Authorization") != "REDACTED" || got.RequestHeaders.Get("User-Agent") != "monitor" {
+				t.Errorf("request headers %v", got.RequestHeaders)
+			}
+			if got.Status != tt.want.Status || got.Error != tt.want.Error || string(got.Body) != string(tt.want.Body) ||
+				got.BodyText != tt.want.BodyText {
+				t.Errorf("got status %d error %q body %s text %q", got.Status, got.Error, got.Body, got.BodyText)
+			}
+			if tt.want.Status != 0 && got.Headers.Get("Content-Type") != "application/json" {
+				t.Errorf("response headers %v", got.Headers)
+			}
+		})
+	}
+}
+
+func TestRotation(t *testing.T) {
+	now := time.Date(2026, 10, 18, 23, 59, 0, 0, time.UTC)
+	r := newRecorder(t, &now)
+	r.MaxFileBytes = 1 // every file is full after one record
+	rt := r.Transport("adsbdb", roundTripper{status: http.StatusOK, body: `{"response":"ok"}`})
+	for _, at := range []time.Time{now, now.Add(10 * time.Second), now.Add(20 * time.Second), now.Add(2 * time.Minute)} {
+		now = at
+		if _, err := get(t, rt, "https://api.adsbdb.com/v0/aircraft/4CA123", nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"2026-10-18/adsbdb-23.1.jsonl.gz",
+		"2026-10-18/adsbdb-23.2.jsonl.gz",
+		"2026-10-18/adsbdb-23.jsonl.gz",
+		"2026-10-19/adsbdb-00.jsonl.gz",
+	}
+	if got := files(t, r.Dir); strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("files %v, want %v", got, want)
+	}
+
+	// A restart appends to the hour's file while it has room.
+	r2, err := New(r.Dir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r2.now = func() time.Time { return now }
+	for i := 0; i < 2; i++ {
+		if _, err := get(t, r2.Transport("adsbdb", roundTripper{status: http.StatusOK, body: `{}`}), "https://api.adsbdb.com/v0/airline/RYR", nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := r2.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(readRecords(t, filepath.Join(r.Dir, "2026-10-19", "adsbdb-00.jsonl.gz"))); n != 3 {
+		t.Errorf("%d records in the reopened file, want the earlier one and two more", n)
+	}
+}
+
+func TestPrune(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name          string
+		maxTotalBytes int64
+		maxAge        time.Duration
+		want          []string
+	}{
+		{"no caps", 0, 0, []string{"2026-10-16/opensky-09.jsonl.gz", "2026-10-17/opensky-09.jsonl.gz", "2026-10-18/opensky-09.jsonl.gz", "notes.txt"}},
+		{"older than a day", 0, 36 * time.Hour, []string{"2026-10-17/opensky-09.jsonl.gz", "2026-10-18/opensky-09.jsonl.gz", "notes.txt"}},
+		{"over the total, oldest first", 250, 0, []string{"2026-10-17/opensky-09.jsonl.gz", "2026-10-18/opensky-09.jsonl.gz", "notes.txt"}},
+		{"both", 150, 36 * time.Hour, []string{"2026-10-18/opensky-09.jsonl.gz", "notes.txt"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newRecorder(t, &now)
+			r.MaxTotalBytes, r.MaxAge = tt.maxTotalBytes, tt.maxAge
+			for days, day := range []string{"2026-10-18", "2026-10-17", "2026-10-16"} {
+				path := filepath.Join(r.Dir, day, "opensky-09.jsonl.gz")
+				if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(path, make([]byte, 100), 0o644); err != nil {
+					t.Fatal(err)
+				}
+				mod := now.Add(-time.Duration(days) * 24 * time.Hour)
+				if err := os.Chtimes(path, mod, mod); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := os.WriteFile(filepath.Join(r.Dir, "notes.txt"), make([]byte, 1000), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			r.Prune()
+			if got := files(t, r.Dir); strings.Join(got, " ") != strings.Join(tt.want, " ") {
+				t.Errorf("files %v, want %v", got, tt.want)
+			}
+			if _, err := os.Stat(filepath.Join(r.Dir, "2026-10-16")); (err == nil) != (len(tt.want) == 4) {
+				t.Errorf("day directory kept: %v", err == nil)
+			}
+		})
+	}
+}
+
+func TestPruneKeepsOpenFiles(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	r := newRecorder(t, &now)
+	r.MaxTotalBytes = 1
+	rt := r.Transport("opensky", roundTripper{status: http.StatusOK, body: `{"time":1}`})
+	for _, at := range []time.Time{now, now.Add(time.Hour)} {
+		now = at
+		if _, err := get(t, rt, "https://opensky-network.org/api/states/all", nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Opening the 13:00 file pruned the closed 12:00 one but not itself.
+	if got := files(t, r.Dir); len(got) != 1 || got[0] != "2026-10-18/opensky-13.jsonl.gz" {
+		t.Errorf("files %v, want only the open one", got)
+	}
+}
@@ -0,0 +1,330 @@
+// Package replay feeds recorded position data into the monitor in place of the
+// live upstreams, on a simulated clock that runs at wall-clock speed or faster.
+//
+// Two kinds of recording are read, optionally gzipped:
+//
+//   - OpenSky archives: states/all JSON responses, one per file in a directory
+//     tree, or several concatenated or one per line in a single file. Each
+//     response's "time" says when it was fetched. A recording archive (see
+//     package record) is one too, and also replays the adsbdb lookups, and so
+//     is the position history a serve keeps (see package history).
+//   - SBS captures: BaseStation text as saved from port 30003, e.g. with
+//     `nc adsb.local 30003 > capture.sbs`. Messages are timed by their
+//     generated date and time fields, in local time as dump1090 writes them.
+package replay
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"AirTraffic-Monitor/fusion"
+	"AirTraffic-Monitor/geo"
+	"AirTraffic-Monitor/opensky"
+	"AirTraffic-Monitor/sbs"
+)
+
+// maxGap is how old the latest OpenSky response may be before the archive
+// reports a gap in the recording instead of serving it.
+const maxGap = 10 * time.Minute
+
+// ErrFinished is returned once the clock has passed the end of a recording.
+var ErrFinished = errors.New("replay: end of recording")
+
+// Clock is simulated time: it starts at a given moment and advances speed
+// times faster than the wall clock. It is safe for concurrent use.
+type Clock struct {
+	start, wallStart time.Time
+	speed            float64
+}
+
+// NewClock returns a clock reading start now and running speed times real time.
+func NewClock(start time.Time, speed float64) *Clock {
+	return &Clock{start: start, wallStart: time.Now(), speed: speed}
+}
+
+// Now returns the simulated time.
+func (c *Clock) Now() time.Time {
+	elapsed := time.Since(c.wallStart)
+	return c.start.Add(time.Duration(float64(elapsed) * c.speed))
+}
+
+// Wall converts a simulated duration to the wall-clock time it takes to pass.
+func (c *Clock) Wall(d time.Duration) time.Duration {
+	return time.Duration(float64(d) / c.speed)
+}
+
+// Recording is a replayable source.
+type Recording interface {
+	fusion.Source
+	// Start is the time of the first record.
+	Start() time.Time
+	// Finished reports whether the clock has passed the last record.
+	Finished() bool
+}
+
+// Open reads the recording at path, telling OpenSky archives from SBS
+// captures by their content. A directory is read as an OpenSky archive.
+// It returns the fusion source name the recording stands in for.
+func Open(path string, clock func() time.Time) (Recording, string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, "", err
+	}
+	if info.IsDir() {
+		a, err := OpenSkyArchive(path, clock)
+		return a, fusion.SourceOpenSky, err
+	}
+	r, err := openFile(path)
+	if err != nil {
+		return nil, "", err
+	}
+	head, err := bufio.NewReader(r).Peek(512)
+	r.Close()
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, bufio.ErrBufferFull) {
+		return nil, "", fmt.Errorf("read %s: %w", path, err)
+	}
+	switch {
+	case bytes.HasPrefix(bytes.TrimSpace(head), []byte("{")):
+		a, err := OpenSkyArchive(path, clock)
+		return a, fusion.SourceOpenSky, err
+	case bytes.HasPrefix(head, []byte("MSG")), bytes.HasPrefix(head, []byte("AIR")),
+		bytes.HasPrefix(head, []byte("ID,")), bytes.HasPrefix(head, []byte("STA")):
+		c, err := SBSCapture(path, clock)
+		return c, fusion.SourceSBS, err
+	}
+	return nil, "", fmt.Errorf("%s is neither an OpenSky archive nor an SBS capture", path)
+}
+
+// openFile opens path, decompressing it if it is gzipped.
+func openFile(path string) (io.ReadCloser, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	br := bufio.NewReader(f)
+	if magic, _ := br.Peek(2); len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+		zr, err := gzip.NewReader(br)
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		return readCloser{zr, f}, nil
+	}
+	return readCloser{br, f}, nil
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// Archive replays OpenSky states/all responses: States returns the latest
+// response fetched at or before the clock.
+type Archive struct {
+	clock     func() time.Time
+	responses []opensky.StatesResponse // by Time
+}
+
+// OpenSkyArchive loads every response under path, a file or a directory.
+func OpenSkyArchive(path string, clock func() time.Time) (*Archive, error) {
+	a := &Archive{clock: clock}
+	err := filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		if p != path && !strings.HasSuffix(p, ".json") && !strings.HasSuffix(p, ".json.gz") &&
+			!strings.HasPrefix(d.Name(), fusion.SourceOpenSky+"-") {
+			return nil
+		}
+		return a.load(p)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(a.responses) == 0 {
+		return nil, fmt.Errorf("%s: no OpenSky responses", path)
+	}
+	sort.SliceStable(a.responses, func(i, j int) bool { return a.responses[i].Time < a.responses[j].Time })
+	return a, nil
+}
+
+func (a *Archive) load(path string) error {
+	r, err := openFile(path)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	dec := json.NewDecoder(r)
+	for {
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); errors.Is(err, io.EOF) {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("parse %s: %w", path, err)
+		}
+		res, err := decodeResponse(raw)
+		if err != nil {
+			return fmt.Errorf("parse %s: %w", path, err)
+		}
+		if res.Time != 0 {
+			a.responses = append(a.responses, res)
+		}
+	}
+}
+
+// decodeResponse reads a states/all response, or a recorded exchange holding
+// one. Other recorded exchanges decode to a zero response.
+func decodeResponse(raw json.RawMessage) (opensky.StatesResponse, error) {
+	var res opensky.StatesResponse
+	var rec struct {
+		URL    string          `json:"url"`
+		Status int             `json:"status"`
+		Body   json.RawMessage `json:"body"`
+	}
+	if json.Unmarshal(raw, &rec) == nil && rec.URL != "" {
+		if rec.Status != http.StatusOK || !strings.Contains(rec.URL, "/states/all") {
+			return res, nil
+		}
+		raw = rec.Body
+	}
+	err := json.Unmarshal(raw, &res)
+	return res, err
+}
+
+// Start is when the first response was fetched.
+func (a *Archive) Start() time.Time {
+	return time.Unix(a.responses[0].Time, 0)
+}
+
+// Finished reports whether the clock is past the last response.
+func (a *Archive) Finished() bool {
+	return a.clock().Unix() > a.responses[len(a.responses)-1].Time
+}
+
+// States returns the aircraft inside bbox in the latest response.
+func (a *Archive) States(ctx context.Context, bbox geo.BBox) ([]opensky.State, error) {
+	now := a.clock().Unix()
+	i := sort.Search(len(a.responses), func(i int) bool { return a.responses[i].Time > now }) - 1
+	if i < 0 {
+		return nil, fmt.Errorf("replay: recording starts at %s", a.Start().Format(time.RFC3339))
+	}
+	res := a.responses[i]
+	if age := time.Duration(now-res.Time) * time.Second; age > maxGap {
+		if i == len(a.responses)-1 {
+			return nil, ErrFinished
+		}
+		return nil, fmt.Errorf("replay: no response recorded in the %s before %s", maxGap, time.Unix(now, 0).Format(time.RFC3339))
+	}
+	var states []opensky.State
+	for _, st := range opensky.ExtractStates(&res) {
+		if st.Latitude != nil && st.Longitude != nil && bbox.Contains(geo.LatLon{Lat: *st.Latitude, Lon: *st.Longitude}) {
+			states = append(states, st)
+		}
+	}
+	return states, nil
+}
+
+// Capture replays an SBS capture through an sbs.Feed, applying each message
+// once the clock reaches it.
+type Capture struct {
+	clock func() time.Time
+	feed  *sbs.Feed
+	start time.Time
+
+	mu      sync.Mutex
+	r       io.ReadCloser
+	sc      *bufio.Scanner
+	next    string // the first message not yet applied
+	nextAt  time.Time
+	done    bool
+	readErr error
+}
+
+// SBSCapture opens the capture at path.
+func SBSCapture(path string, clock func() time.Time) (*Capture, error) {
+	r, err := openFile(path)
+	if err != nil {
+		return nil, err
+	}
+	feed := sbs.NewFeed("")
+	feed.Clock = clock
+	c := &Capture{clock: clock, feed: feed, r: r, sc: bufio.NewScanner(r)}
+	c.advance()
+	if c.done {
+		if c.readErr != nil {
+			return nil, fmt.Errorf("read %s: %w", path, c.readErr)
+		}
+		return nil, fmt.Errorf("%s: no timestamped SBS messages", path)
+	}
+	c.start = c.nextAt
+	return c, nil
+}
+
+// advance reads up to the next timestamped message.
+func (c *Capture) advance() {
+	for c.sc.Scan() {
+		line := c.sc.Text()
+		if at, ok := messageTime(line); ok {
+			c.next, c.nextAt = line, at
+			return
+		}
+	}
+	c.readErr = c.sc.Err()
+	c.done = true
+	c.r.Close()
+}
+
+// messageTime parses the generated date and time (fields 6 and 7).
+func messageTime(line string) (time.Time, bool) {
+	fields := strings.SplitN(line, ",", 9)
+	if len(fields) < 8 {
+		return time.Time{}, false
+	}
+	at, err := time.ParseInLocation("2006/01/02 15:04:05.000", fields[6]+" "+fields[7], time.Local)
+	if err != nil {
+		at, err = time.ParseInLocation("2006/01/02 15:04:05", fields[6]+" "+fields[7], time.Local)
+	}
+	return at, err == nil
+}
+
+// Start is the time of the first message.
+func (c *Capture) Start() time.Time {
+	return c.start
+}
+
+// Finished reports whether every message has been applied.
+func (c *Capture) Finished() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.done
+}
+
+// States applies the messages up to the clock and returns the feed's aircraft.
+func (c *Capture) States(ctx context.Context, bbox geo.BBox) ([]opensky.State, error) {
+	now := c.clock()
+	c.mu.Lock()
+	for !c.done && !c.nextAt.After(now) {
+		c.feed.Apply(c.next, c.nextAt)
+		c.advance()
+	}
+	err := c.readErr
+	c.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	return c.feed.States(ctx, bbox)
+}
@@ -0,0 +1,215 @@
+package replay
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"AirTraffic-Monitor/fusion"
+	"AirTraffic-Monitor/geo"
+	"AirTraffic-Monitor/opensky"
+	"AirTraffic-Monitor/record"
+)
+
+var london = geo.BBox{LaMin: 51.2, LoMin: -0.6, LaMax: 51.8, LoMax: 0.3}
+
+func ptr(v float64) *float64 { return &v }
+
+// statesJSON is a states/all response fetched at t with one aircraft per
+// ICAO24, all over London except 3C6444, which is over Frankfurt.
+func statesJSON(t time.Time, icao24s ...string) string {
+	res := opensky.StatesResponse{Time: t.Unix()}
+	for _, icao := range icao24s {
+		st := opensky.State{ICAO24: icao, Latitude: ptr(51.5), Longitude: ptr(-0.1), LastContact: t.Unix()}
+		if icao == "3c6444" {
+			st.Latitude, st.Longitude = ptr(50.03), ptr(8.56)
+		}
+		res.States = append(res.States, opensky.StateRow(st))
+	}
+	data, _ := json.Marshal(res)
+	return string(data)
+}
+
+func TestClock(t *testing.T) {
+	start := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	c := NewClock(start, 3600)
+	if got := c.Wall(time.Hour); got != time.Second {
+		t.Errorf("an hour passes in %v, want 1s", got)
+	}
+	time.Sleep(20 * time.Millisecond)
+	// 20 ms of wall time is at least 72 s simulated.
+	if elapsed := c.Now().Sub(start); elapsed < 72*time.Second || elapsed > time.Hour {
+		t.Errorf("clock moved %v after 20ms at 3600x", elapsed)
+	}
+	if got := NewClock(start, 1).Wall(time.Minute); got != time.Minute {
+		t.Errorf("real time: a minute passes in %v", got)
+	}
+}
+
+func TestArchiveStates(t *testing.T) {
+	t0 := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	dir := t.TempDir()
+	for i, f := range []struct {
+		name string
+		body string
+	}{
+		{"a/1.json", statesJSON(t0, "4ca123", "3c6444")},
+		{"a/2.json", statesJSON(t0.Add(5*time.Minute), "4ca123", "400a0b")},
+		// Concatenated responses in one file, after a 25 minute gap.
+		{"b.json", statesJSON(t0.Add(30*time.Minute), "400a0b") + "\n" + statesJSON(t0.Add(35*time.Minute))},
+		{"notes.txt", "not a response"},
+	} {
+		path := filepath.Join(dir, f.name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(f.body), 0o644); err != nil {
+			t.Fatalf("file %d: %v", i, err)
+		}
+	}
+
+	var now time.Time
+	a, err := OpenSkyArchive(dir, func() time.Time { return now })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !a.Start().Equal(t0) {
+		t.Errorf("starts at %v, want %v", a.Start(), t0)
+	}
+	tests := []struct {
+		after    time.Duration
+		want     string // ICAO24s in the bbox, sorted
+		wantErr  string
+		finished bool
+	}{
+		{after: -time.Minute, wantErr: "recording starts at"},
+		{after: 0, want: "4CA123"},
+		{after: 4 * time.Minute, want: "4CA123"},
+		{after: 5 * time.Minute, want: "400A0B 4CA123"},
+		{after: 20 * time.Minute, wantErr: "no response recorded"},
+		{after: 31 * time.Minute, want: "400A0B"},
+		{after: 40 * time.Minute, want: "", finished: true},
+		{after: 46 * time.Minute, wantErr: ErrFinished.Error(), finished: true},
+	}
+	for _, tt := range tests {
+		now = t0.Add(tt.after)
+		states, err := a.States(context.Background(), london)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("+%v: error %v, want %q", tt.after, err, tt.wantErr)
+			}
+		} else if err != nil {
+			t.Errorf("+%v: %v", tt.after, err)
+		}
+		var got []string
+		for _, st := range states {
+			got = append(got, st.ICAO24)
+		}
+		sort.Strings(got)
+		if strings.Join(got, " ") != tt.want {
+			t.Errorf("+%v: states %v, want %q", tt.after, got, tt.want)
+		}
+		if a.Finished() != tt.finished {
+			t.Errorf("+%v: finished %v", tt.after, a.Finished())
+		}
+	}
+	if _, err := a.States(context.Background(), london); !errors.Is(err, ErrFinished) {
+		t.Errorf("after the end: %v, want ErrFinished", err)
+	}
+}
+
+func TestRecordThenReplayStates(t *testing.T) {
+	t0 := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/states/all" {
+			http.NotFound(w, r)
+			return
+		}
+		if r.URL.Query().Get("fail") != "" {
+			http.Error(w, "Too many requests", http.StatusTooManyRequests)
+			return
+		}
+		fmt.Fprint(w, statesJSON(t0, "4ca123"))
+	}))
+	t.Cleanup(upstream.Close)
+
+	dir := t.TempDir()
+	rec, err := record.New(dir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &http.Client{Transport: rec.Transport(fusion.SourceOpenSky, nil)}
+	for _, path := range []string{"/api/states/all", "/api/states/all?fail=1", "/api/flights"} {
+		res, err := client.Get(upstream.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+	}
+	if err := rec.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	now := t0.Add(time.Minute)
+	src, name, err := Open(dir, func() time.Time { return now })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name != fusion.SourceOpenSky || !src.Start().Equal(t0) {
+		t.Errorf("opened as %s starting %v", name, src.Start())
+	}
+	states, err := src.States(context.Background(), london)
+	if err != nil || len(states) != 1 || states[0].ICAO24 != "4CA123" {
+		t.Errorf("replayed %+v, %v", states, err)
+	}
+}
+
+func TestOpenCapture(t *testing.T) {
+	dir := t.TempDir()
+	capture := filepath.Join(dir, "capture.sbs")
+	lines := []string{
+		"MSG,3,1,1,406A1B,1,2026/10/18,12:00:00.000,2026/10/18,12:00:00.000,,9000,,,51.50000,-0.10000,,,0,0,0,0",
+		"MSG,3,1,1,406A1B,1,2026/10/18,12:00:10.000,2026/10/18,12:00:10.000,,9000,,,51.50000,-0.05000,,,0,0,0,0",
+		"MSG,3,1,1,3C6444,1,2026/10/18,12:00:20.000,2026/10/18,12:00:20.000,,9000,,,51.55000,-0.20000,,,0,0,0,0",
+	}
+	if err := os.WriteFile(capture, []byte(strings.Join(lines, "\n")+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "junk.txt"), []byte("hello\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	t0 := time.Date(2026, 10, 18, 12, 0, 0, 0, time.Local)
+	now := t0.Add(15 * time.Second)
+	src, name, err := Open(capture, func() time.Time { return now })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name != fusion.SourceSBS || !src.Start().Equal(t0) {
+		t.Errorf("opened as %s starting %v, want sbs at %v", name, src.Start(), t0)
+	}
+	states, err := src.States(context.Background(), london)
+	if err != nil || len(states) != 1 || *states[0].Longitude != -0.05 {
+		t.Fatalf("at +15s: %+v, %v; want 406A1B's second position", states, err)
+	}
+	if src.Finished() {
+		t.Error("finished with a message still to apply")
+	}
+	now = t0.Add(30 * time.Second)
+	if states, err = src.States(context.Background(), london); err != nil || len(states) != 2 || !src.Finished() {
+		t.Errorf("at +30s: %d states, %v, finished %v", len(states), err, src.Finished())
+	}
+
+	if _, _, err := Open(filepath.Join(dir, "junk.txt"), time.Now); err == nil {
+		t.Error("opened a file that is neither kind of recording")
+	}
+}
@@ -0,0 +1,116 @@
+package replay
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"AirTraffic-Monitor/record"
+)
+
+// Responses answers HTTP requests from the responses one upstream gave in a
+// recording archive (see package record), so lookups replay as they happened.
+// It is an http.RoundTripper.
+type Responses struct {
+	clock   func() time.Time
+	records map[string][]record.Record // by path and query, oldest first
+}
+
+// OpenResponses loads upstream's records from the archive under dir. It
+// returns nil when the archive holds none.
+func OpenResponses(dir, upstream string, clock func() time.Time) (*Responses, error) {
+	rs := &Responses{clock: clock, records: make(map[string][]record.Record)}
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !strings.HasPrefix(d.Name(), upstream+"-") || !strings.HasSuffix(p, ".jsonl.gz") {
+			return err
+		}
+		return rs.load(p, upstream)
+	})
+	if err != nil || len(rs.records) == 0 {
+		return nil, err
+	}
+	for _, recs := range rs.records {
+		sort.SliceStable(recs, func(i, j int) bool { return recs[i].Time.Before(recs[j].Time) })
+	}
+	return rs, nil
+}
+
+func (rs *Responses) load(path, upstream string) error {
+	r, err := openFile(path)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	dec := json.NewDecoder(r)
+	for {
+		var rec record.Record
+		if err := dec.Decode(&rec); errors.Is(err, io.EOF) {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("parse %s: %w", path, err)
+		}
+		if rec.Upstream != upstream || rec.Status == 0 {
+			continue
+		}
+		key, err := requestKey(rec.URL)
+		if err != nil {
+			continue
+		}
+		rs.records[key] = append(rs.records[key], rec)
+	}
+}
+
+// requestKey drops the scheme and host, so the replay works whatever base
+// URL the client is configured with.
+func requestKey(rawURL string) (string, error) {
+	req, err := http.NewRequest(http.MethodGet, rawURL, nil)
+	if err != nil {
+		return "", err
+	}
+	return req.URL.RequestURI(), nil
+}
+
+// RoundTrip returns the latest response recorded for the request at or before
+// the clock, or the earliest when all are later. Requests never recorded get
+// a 404, as adsbdb gives for unknown aircraft.
+func (rs *Responses) RoundTrip(req *http.Request) (*http.Response, error) {
+	recs := rs.records[req.URL.RequestURI()]
+	if len(recs) == 0 {
+		return response(req, http.StatusNotFound, nil, []byte(`{"response":"not in the recording"}`)), nil
+	}
+	now := rs.clock()
+	i := sort.Search(len(recs), func(i int) bool { return recs[i].Time.After(now) }) - 1
+	rec := recs[max(i, 0)]
+	body := []byte(rec.Body)
+	if rec.Body == nil {
+		body = []byte(rec.BodyText)
+	}
+	return response(req, rec.Status, rec.Headers, body), nil
+}
+
+func response(req *http.Request, status int, header http.Header, body []byte) *http.Response {
+	header = header.Clone()
+	if header == nil {
+		header = make(http.Header)
+	}
+	header.Del("Content-Length") // the recorded body may have been compacted
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
@@ -0,0 +1,140 @@
+package replay
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"AirTraffic-Monitor/adsbdb"
+	"AirTraffic-Monitor/fakeupstream"
+	"AirTraffic-Monitor/record"
+)
+
+// writeArchive writes records as a gzipped recording file at dir/name.
+func writeArchive(t *testing.T, dir, name string, records ...record.Record) {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	zw := gzip.NewWriter(f)
+	enc := json.NewEncoder(zw)
+	for _, rec := range records {
+		if err := enc.Encode(rec); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRecordThenReplayLookups(t *testing.T) {
+	fake := fakeupstream.New()
+	t.Cleanup(fake.Close)
+	fake.AddAircraft(fakeupstream.Aircraft{ModeS: "4CA123", Registration: "EI-ABC", Type: "737-8AS",
+		ICAOType: "B738", Manufacturer: "Boeing", Owner: "Ryanair"})
+	fake.AddRoute(fakeupstream.Route{Callsign: "RYR1AB",
+		Origin:      fakeupstream.Airport{ICAO: "EIDW", Name: "Dublin Airport"},
+		Destination: fakeupstream.Airport{ICAO: "EGSS", Name: "London Stansted Airport"},
+	})
+
+	dir := t.TempDir()
+	rec, err := record.New(dir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	live := adsbdb.NewClient(fake.ADSBDB.URL, &http.Client{Transport: rec.Transport("adsbdb", nil)})
+	ctx := context.Background()
+	if _, err := live.Aircraft(ctx, "4CA123"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := live.FlightRoute(ctx, "4CA123", "RYR1AB"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := live.Aircraft(ctx, "400A0B"); !errors.Is(err, adsbdb.ErrUnknownAircraft) {
+		t.Fatalf("live lookup of an unknown aircraft: %v", err)
+	}
+	if err := rec.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	rs, err := OpenResponses(dir, "adsbdb", time.Now)
+	if err != nil || rs == nil {
+		t.Fatalf("OpenResponses() = %v, %v", rs, err)
+	}
+	// The replay answers whatever base URL the client has.
+	replayed := adsbdb.NewClient("http://adsbdb.invalid", &http.Client{Transport: rs})
+	a, err := replayed.Aircraft(ctx, "4CA123")
+	if err != nil || a.Registration != "EI-ABC" || a.ICAOType != "B738" {
+		t.Errorf("replayed aircraft %+v, %v", a, err)
+	}
+	r, err := replayed.FlightRoute(ctx, "4CA123", "RYR1AB")
+	if err != nil || r.Origin.ICAOCode != "EIDW" || r.Destination.ICAOCode != "EGSS" {
+		t.Errorf("replayed route %+v, %v", r, err)
+	}
+	for _, icao24 := range []string{"400A0B", "3C6444"} { // recorded as unknown, and never asked
+		if _, err := replayed.Aircraft(ctx, icao24); !errors.Is(err, adsbdb.ErrUnknownAircraft) {
+			t.Errorf("replayed %s: %v, want unknown", icao24, err)
+		}
+	}
+}
+
+func TestResponsesFollowTheClock(t *testing.T) {
+	t0 := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	url := "https://api.adsbdb.com/v0/callsign/RYR1AB"
+	dir := t.TempDir()
+	writeArchive(t, dir, "2026-10-18/adsbdb-12.jsonl.gz",
+		record.Record{Time: t0, Upstream: "adsbdb", URL: url, Status: http.StatusOK, Body: json.RawMessage(`"first"`)},
+		record.Record{Time: t0.Add(20 * time.Minute), Upstream: "adsbdb", URL: url, Error: "timeout"}, // no response
+		record.Record{Time: t0.Add(30 * time.Minute), Upstream: "adsbdb", URL: url, Status: http.StatusTooManyRequests,
+			BodyText: "slow down"})
+	writeArchive(t, dir, "2026-10-18/adsbdb-13.jsonl.gz",
+		record.Record{Time: t0.Add(time.Hour), Upstream: "adsbdb", URL: url, Status: http.StatusOK, Body: json.RawMessage(`"second"`)})
+	writeArchive(t, dir, "2026-10-18/opensky-13.jsonl.gz",
+		record.Record{Time: t0.Add(time.Hour), Upstream: "opensky", URL: url, Status: http.StatusOK, Body: json.RawMessage(`"other upstream"`)})
+
+	tests := []struct {
+		after      time.Duration
+		wantStatus int
+		wantBody   string
+	}{
+		{-time.Hour, http.StatusOK, `"first"`}, // before the recording: the earliest
+		{0, http.StatusOK, `"first"`},
+		{25 * time.Minute, http.StatusOK, `"first"`}, // the failed exchange is skipped
+		{45 * time.Minute, http.StatusTooManyRequests, "slow down"},
+		{2 * time.Hour, http.StatusOK, `"second"`},
+	}
+	for _, tt := range tests {
+		now := t0.Add(tt.after)
+		rs, err := OpenResponses(dir, "adsbdb", func() time.Time { return now })
+		if err != nil {
+			t.Fatal(err)
+		}
+		req, _ := http.NewRequest(http.MethodGet, "http://localhost:8080/v0/callsign/RYR1AB", nil)
+		res, err := rs.RoundTrip(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var body [64]byte
+		n, _ := res.Body.Read(body[:])
+		if res.StatusCode != tt.wantStatus || string(body[:n]) != tt.wantBody {
+			t.Errorf("+%v: %d %s, want %d %s", tt.after, res.StatusCode, body[:n], tt.wantStatus, tt.wantBody)
+		}
+	}
+
+	if rs, err := OpenResponses(t.TempDir(), "adsbdb", time.Now); rs != nil || err != nil {
+		t.Errorf("empty archive: %v, %v", rs, err)
+	}
+}
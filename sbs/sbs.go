@@ -0,0 +1,244 @@
+// Package sbs reads a BaseStation (SBS-1) feed, the comma-separated text a
+// local dump1090 or readsb serves on port 30003, and keeps the latest state of
+// every aircraft heard so it can be merged with the other position sources.
+//
+// Each line is one message; only MSG lines are used:
+//
+//	MSG,3,1,1,4CA123,1,2024/06/30,14:00:00.000,2024/06/30,14:00:00.000,,36000,,,51.5,-0.1,,,0,0,0,0
+//
+// Fields used: 4 hex ident, 10 callsign, 11 altitude (ft), 12 ground speed
+// (kt), 13 track, 14 latitude, 15 longitude, 16 vertical rate (ft/min),
+// 17 squawk and 21 on ground. Messages are timestamped on arrival.
+package sbs
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"AirTraffic-Monitor/fusion"
+	"AirTraffic-Monitor/geo"
+	"AirTraffic-Monitor/opensky"
+)
+
+const (
+	knotsToMS = 0.514444
+	fpmToMS   = 0.00508
+	// Aircraft not heard for staleAfter are left out of States and dropped
+	// after forgetAfter.
+	staleAfter  = 60 * time.Second
+	forgetAfter = 5 * time.Minute
+	// Each aircraft's trail keeps a position at most every trailStep for the
+	// last trailKeep, enough to follow the turns between polls.
+	trailStep = 5 * time.Second
+	trailKeep = 10 * time.Minute
+	// Reconnect delay bounds.
+	minBackoff = time.Second
+	maxBackoff = time.Minute
+)
+
+// ErrNoData is returned by States before anything has been received.
+var ErrNoData = errors.New("sbs: no data received")
+
+// aircraft is the merged state of one address.
+type aircraft struct {
+	callsign     string
+	squawk       string
+	lat, lon     *float64
+	alt          *float64 // metres
+	speed, track *float64
+	vrate        *float64
+	onGround     bool
+	posAt, last  time.Time
+	trail        []fusion.Fix // oldest first
+}
+
+// Feed holds the latest state per aircraft. It is safe for concurrent use.
+// Clock ages the aircraft in States; it is time.Now except when replaying.
+type Feed struct {
+	Address string
+	Clock   func() time.Time
+
+	mu       sync.Mutex
+	aircraft map[string]*aircraft
+	received bool
+}
+
+// NewFeed returns a feed that Run connects to address (host:port).
+func NewFeed(address string) *Feed {
+	return &Feed{Address: address, Clock: time.Now, aircraft: make(map[string]*aircraft)}
+}
+
+// Run connects to the feed and reads it until ctx is cancelled, reconnecting
+// with backoff whenever the connection drops.
+func (f *Feed) Run(ctx context.Context, logger *slog.Logger) {
+	backoff := minBackoff
+	for ctx.Err() == nil {
+		var d net.Dialer
+		conn, err := d.DialContext(ctx, "tcp", f.Address)
+		if err == nil {
+			logger.Info("connected to sbs feed", "address", f.Address)
+			backoff = minBackoff
+			stop := context.AfterFunc(ctx, func() { conn.Close() })
+			err = f.Read(conn, time.Now)
+			stop()
+			conn.Close()
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		logger.Warn("sbs feed unavailable, reconnecting", "address", f.Address, "retry_in", backoff, "err", err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(2*backoff, maxBackoff)
+	}
+}
+
+// Read consumes messages from r until it ends, stamping each with clock().
+func (f *Feed) Read(r io.Reader, clock func() time.Time) error {
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		f.Apply(sc.Text(), clock())
+	}
+	if err := sc.Err(); err != nil {
+		return err
+	}
+	return io.EOF
+}
+
+// Apply updates the state from one line received at at. Lines that are not
+// MSG lines, or are malformed, are ignored.
+func (f *Feed) Apply(line string, at time.Time) {
+	fields := strings.Split(strings.TrimSpace(line), ",")
+	if len(fields) < 11 || fields[0] != "MSG" || fields[4] == "" {
+		return
+	}
+	field := func(i int) string {
+		if i < len(fields) {
+			return strings.TrimSpace(fields[i])
+		}
+		return ""
+	}
+	num := func(i int) *float64 {
+		v, err := strconv.ParseFloat(field(i), 64)
+		if err != nil {
+			return nil
+		}
+		return &v
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.received = true
+	icao := strings.ToUpper(field(4))
+	a := f.aircraft[icao]
+	if a == nil {
+		a = &aircraft{}
+		f.aircraft[icao] = a
+	}
+	a.last = at
+	if cs := field(10); cs != "" {
+		a.callsign = cs
+	}
+	if alt := num(11); alt != nil {
+		m := *alt / geo.MetresToFeet
+		a.alt = &m
+	}
+	if gs := num(12); gs != nil {
+		v := *gs * knotsToMS
+		a.speed = &v
+	}
+	if trk := num(13); trk != nil {
+		a.track = trk
+	}
+	if lat, lon := num(14), num(15); lat != nil && lon != nil {
+		a.lat, a.lon, a.posAt = lat, lon, at
+		a.addFix(at)
+	}
+	if vr := num(16); vr != nil {
+		v := *vr * fpmToMS
+		a.vrate = &v
+	}
+	if sq := field(17); sq != "" {
+		a.squawk = sq
+	}
+	if g := field(21); g != "" {
+		a.onGround = g != "0"
+	}
+}
+
+// addFix appends the current position to the trail, thinned to one every
+// trailStep, and drops what is older than trailKeep.
+func (a *aircraft) addFix(at time.Time) {
+	if n := len(a.trail); n > 0 && at.Sub(a.trail[n-1].At) < trailStep {
+		return
+	}
+	drop := 0
+	for drop < len(a.trail) && at.Sub(a.trail[drop].At) > trailKeep {
+		drop++
+	}
+	a.trail = append(a.trail[drop:], fusion.Fix{
+		At:       at,
+		Position: geo.LatLon{Lat: *a.lat, Lon: *a.lon},
+		AltM:     a.alt,
+		Track:    a.track,
+	})
+}
+
+// Trail returns the positions heard from icao24 in the last ten minutes,
+// oldest first. It implements fusion.TrailSource.
+func (f *Feed) Trail(icao24 string) []fusion.Fix {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	a := f.aircraft[strings.ToUpper(icao24)]
+	if a == nil {
+		return nil
+	}
+	return append([]fusion.Fix(nil), a.trail...)
+}
+
+// States returns the aircraft heard recently with a position inside bbox.
+func (f *Feed) States(ctx context.Context, bbox geo.BBox) ([]opensky.State, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !f.received {
+		return nil, ErrNoData
+	}
+	now := f.Clock()
+	var states []opensky.State
+	for icao, a := range f.aircraft {
+		age := now.Sub(a.last)
+		if age > forgetAfter {
+			delete(f.aircraft, icao)
+			continue
+		}
+		if age > staleAfter || a.lat == nil || !bbox.Contains(geo.LatLon{Lat: *a.lat, Lon: *a.lon}) {
+			continue
+		}
+		states = append(states, opensky.State{
+			ICAO24:       icao,
+			Callsign:     a.callsign,
+			TimePosition: a.posAt.Unix(),
+			LastContact:  a.last.Unix(),
+			Latitude:     a.lat,
+			Longitude:    a.lon,
+			BaroAltitude: a.alt,
+			OnGround:     a.onGround,
+			Velocity:     a.speed,
+			TrueTrack:    a.track,
+			VerticalRate: a.vrate,
+			Squawk:       a.squawk,
+		})
+	}
+	return states, nil
+}
@@ -0,0 +1,41 @@
+package sbs
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestTrail(t *testing.T) {
+	f := NewFeed("")
+	t0 := time.Date(2026, 10, 18, 8, 0, 0, 0, time.UTC)
+	f.Apply("MSG,4,1,1,406A1B,1,,,,,,,250,110,,,0,,,,,0", t0)
+	// A position every two seconds for twelve minutes.
+	for s := 0; s <= 720; s += 2 {
+		lat := 51.70 + float64(s)/100000
+		f.Apply(fmt.Sprintf("MSG,3,1,1,406A1B,1,,,,,,9000,,,%.5f,-0.55,,,0,0,0,0", lat), t0.Add(time.Duration(s)*time.Second))
+	}
+
+	trail := f.Trail("406a1b")
+	if len(trail) == 0 {
+		t.Fatal("no trail")
+	}
+	last := t0.Add(720 * time.Second)
+	if first := trail[0].At; last.Sub(first) > trailKeep {
+		t.Errorf("trail starts %v before the last fix, want at most %v", last.Sub(first), trailKeep)
+	}
+	if !trail[len(trail)-1].At.Equal(last) {
+		t.Errorf("trail ends at %v, want %v", trail[len(trail)-1].At, last)
+	}
+	for i := 1; i < len(trail); i++ {
+		if gap := trail[i].At.Sub(trail[i-1].At); gap < trailStep {
+			t.Fatalf("fixes %d and %d only %v apart, want at least %v", i-1, i, gap, trailStep)
+		}
+	}
+	if tr := trail[0].Track; tr == nil || *tr != 110 {
+		t.Errorf("track = %v, want 110", tr)
+	}
+	if f.Trail("000000") != nil {
+		t.Error("trail for an aircraft never heard")
+	}
+}
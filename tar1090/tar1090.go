@@ -0,0 +1,154 @@
+// Package tar1090 reads aircraft from a local receiver's tar1090 or readsb web
+// interface (data/aircraft.json) and converts them to OpenSky state vectors,
+// so they can be merged with the other position sources.
+//
+// Reference: https://github.com/wiedehopf/readsb/blob/dev/README-json.md
+package tar1090
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+
+	"AirTraffic-Monitor/actype"
+	"AirTraffic-Monitor/geo"
+	"AirTraffic-Monitor/opensky"
+)
+
+const (
+	knotsToMS  = 0.514444
+	fpmToMS    = 0.00508
+	staleAfter = 60 // seconds without a message before an aircraft is left out
+)
+
+// Client polls one receiver.
+type Client struct {
+	BaseURL string // e.g. http://adsb.local/tar1090
+	HTTP    *http.Client
+}
+
+// NewClient returns a client for baseURL using hc.
+func NewClient(baseURL string, hc *http.Client) *Client {
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+	return &Client{BaseURL: strings.TrimRight(baseURL, "/"), HTTP: hc}
+}
+
+// StatusError is returned for any non-200 response.
+type StatusError struct {
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("tar1090 unexpected status %d", e.StatusCode)
+}
+
+// HTTPStatus exposes the status code to logging.StatusCode.
+func (e *StatusError) HTTPStatus() int {
+	return e.StatusCode
+}
+
+// Response is the aircraft.json payload.
+type Response struct {
+	Now      float64    `json:"now"` // unix seconds
+	Aircraft []Aircraft `json:"aircraft"`
+}
+
+// Aircraft is one entry of aircraft.json. Altitudes are in feet, speeds in
+// knots and rates in feet per minute; "ground" is a valid alt_baro.
+type Aircraft struct {
+	Hex      string          `json:"hex"`
+	Flight   string          `json:"flight"`
+	AltBaro  json.RawMessage `json:"alt_baro"`
+	AltGeom  *float64        `json:"alt_geom"`
+	GS       *float64        `json:"gs"`
+	Track    *float64        `json:"track"`
+	BaroRate *float64        `json:"baro_rate"`
+	GeomRate *float64        `json:"geom_rate"`
+	Squawk   string          `json:"squawk"`
+	Category string          `json:"category"`
+	Lat      *float64        `json:"lat"`
+	Lon      *float64        `json:"lon"`
+	SeenPos  *float64        `json:"seen_pos"` // seconds since the last position
+	Seen     float64         `json:"seen"`     // seconds since the last message
+}
+
+// States fetches the receiver's aircraft inside bbox.
+func (c *Client) States(ctx context.Context, bbox geo.BBox) ([]opensky.State, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.BaseURL+"/data/aircraft.json", nil)
+	if err != nil {
+		return nil, err
+	}
+	res, err := c.HTTP.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, &StatusError{StatusCode: res.StatusCode}
+	}
+	var payload Response
+	if err := json.NewDecoder(res.Body).Decode(&payload); err != nil {
+		return nil, err
+	}
+	return ExtractStates(&payload, bbox), nil
+}
+
+// ExtractStates converts the positioned, recently heard aircraft inside bbox.
+// Non-ICAO addresses (TIS-B and anonymous, marked with "~") are skipped.
+func ExtractStates(data *Response, bbox geo.BBox) []opensky.State {
+	var states []opensky.State
+	for _, a := range data.Aircraft {
+		if a.Hex == "" || strings.HasPrefix(a.Hex, "~") || a.Seen > staleAfter || a.Lat == nil || a.Lon == nil {
+			continue
+		}
+		if !bbox.Contains(geo.LatLon{Lat: *a.Lat, Lon: *a.Lon}) {
+			continue
+		}
+		st := opensky.State{
+			ICAO24:       strings.ToUpper(a.Hex),
+			Callsign:     strings.TrimSpace(a.Flight),
+			LastContact:  int64(data.Now - a.Seen),
+			Latitude:     a.Lat,
+			Longitude:    a.Lon,
+			Velocity:     scale(a.GS, knotsToMS),
+			TrueTrack:    a.Track,
+			VerticalRate: scale(firstNonNil(a.BaroRate, a.GeomRate), fpmToMS),
+			GeoAltitude:  scale(a.AltGeom, 1/geo.MetresToFeet),
+			Squawk:       a.Squawk,
+			Category:     actype.CategoryFromCode(a.Category),
+		}
+		if a.SeenPos != nil {
+			st.TimePosition = int64(data.Now - *a.SeenPos)
+		}
+		var alt float64
+		if err := json.Unmarshal(a.AltBaro, &alt); err == nil {
+			st.BaroAltitude = scale(&alt, 1/geo.MetresToFeet)
+		} else if strings.Contains(string(a.AltBaro), "ground") {
+			st.OnGround = true
+		}
+		states = append(states, st)
+	}
+	return states
+}
+
+func scale(v *float64, factor float64) *float64 {
+	if v == nil {
+		return nil
+	}
+	s := math.Round(*v*factor*100) / 100
+	return &s
+}
+
+func firstNonNil(values ...*float64) *float64 {
+	for _, v := range values {
+		if v != nil {
+			return v
+		}
+	}
+	return nil
+}
@@ -0,0 +1,366 @@
+// Package track smooths each aircraft's reported positions with a
+// constant-velocity Kalman filter and rejects reports that cannot be real:
+// CPR decode glitches, positions from another aircraft and the occasional wild
+// jump. A report is rejected when reaching it would need an impossible ground
+// speed, or when it lies far outside the filter's predicted uncertainty.
+// Altitude jumps beyond any plausible climb or descent are rejected the same way.
+//
+// The filter runs in a flat east/north frame in metres centred on each track's
+// first position, which is accurate enough over the few hundred kilometres an
+// aircraft crosses the monitored area.
+package track
+
+import (
+	"math"
+	"time"
+
+	"AirTraffic-Monitor/geo"
+)
+
+const (
+	// Reports implying a ground speed above maxSpeedMS are rejected, as are
+	// those needing more than speedSlack over the speeds reported either side.
+	maxSpeedMS   = 450
+	speedSlack   = 1.3
+	speedSlackMS = 30
+	// Altitude changes faster than maxClimbMS are rejected.
+	maxClimbMS = 100
+	// Position innovations beyond this squared Mahalanobis distance (99.9% for
+	// two degrees of freedom) are rejected.
+	gateChi2 = 13.8
+	// After maxRejects consecutive rejections the track restarts from the new
+	// report, on the basis that it was the track that was wrong.
+	maxRejects = 3
+	// Tracks not updated for forgetAfter are dropped; a gap longer than
+	// restartGap starts the filter afresh.
+	forgetAfter = 15 * time.Minute
+	restartGap  = 10 * time.Minute
+
+	// Measurement and process noise.
+	positionSigmaM = 50   // reported position
+	speedSigmaMS   = 3    // reported ground speed
+	trackSigmaRad  = 0.05 // reported track, about 3°
+	accelSigmaMS2  = 1.5  // unmodelled acceleration
+
+	// Track quality is judged over the last qualityWindow reports.
+	qualityWindow = 10
+)
+
+// Quality labels.
+const (
+	QualityNew  = "new"  // fewer than three reports
+	QualityGood = "good" //
+	QualityFair = "fair" //
+	QualityPoor = "poor" // frequent outliers or a large position uncertainty
+)
+
+// Report is one aircraft's state as received.
+type Report struct {
+	ICAO24   string
+	At       time.Time
+	Position geo.LatLon
+	AltM     *float64
+	SpeedMS  *float64
+	TrackDeg *float64
+}
+
+// Estimate is the smoothed state after a report.
+type Estimate struct {
+	Position geo.LatLon
+	AltM     *float64 // last plausible altitude
+	SpeedMS  float64
+	TrackDeg float64
+	SigmaM   float64 // one-sigma position uncertainty
+
+	Rejected bool   // the position or altitude was an outlier; the prediction or last plausible altitude is used instead
+	Reason   string // why it was rejected
+	Reports  int    // reports seen since the track started
+	Outliers int    // outliers among the last qualityWindow reports
+	Quality  string
+	Score    float64 // 0-1
+}
+
+// filter is one aircraft's Kalman filter. x is east, north, east velocity,
+// north velocity in metres and m/s; p is its covariance.
+type filter struct {
+	origin  geo.LatLon
+	x       [4]float64
+	p       [4][4]float64
+	at      time.Time
+	alt     *float64
+	altAt   time.Time
+	speed   *float64 // last reported speed
+	rejects int      // consecutive
+	reports int
+	history []bool // outlier flags, newest last
+}
+
+// Tracker keeps a filter per aircraft. It is not safe for concurrent use.
+type Tracker struct {
+	filters map[string]*filter
+}
+
+// NewTracker returns an empty tracker.
+func NewTracker() *Tracker {
+	return &Tracker{filters: make(map[string]*filter)}
+}
+
+// Len returns the number of aircraft tracked.
+func (t *Tracker) Len() int {
+	return len(t.filters)
+}
+
+// Update feeds one report and returns the smoothed estimate.
+func (t *Tracker) Update(r Report) Estimate {
+	f := t.filters[r.ICAO24]
+	if f == nil || r.At.Sub(f.at) > restartGap || r.At.Before(f.at) {
+		f = newFilter(r)
+		t.filters[r.ICAO24] = f
+		return f.estimate("")
+	}
+	if !r.At.After(f.at) {
+		// The same report again: nothing new to learn.
+		return f.estimate("")
+	}
+
+	reason := f.check(r)
+	if reason != "" && f.rejects+1 >= maxRejects {
+		f = newFilter(r)
+		t.filters[r.ICAO24] = f
+		return f.estimate("")
+	}
+	f.predict(r.At)
+	if reason == "" {
+		f.correct(r)
+		f.rejects = 0
+	} else {
+		f.rejects++
+	}
+	if r.AltM != nil {
+		if f.altitudeJump(r) {
+			reason = firstNonEmpty(reason, "implausible altitude change")
+		} else {
+			f.alt, f.altAt = r.AltM, r.At
+		}
+	}
+	f.reports++
+	f.history = append(f.history, reason != "")
+	if len(f.history) > qualityWindow {
+		f.history = f.history[1:]
+	}
+	return f.estimate(reason)
+}
+
+// Forget drops tracks not updated since before now minus forgetAfter.
+func (t *Tracker) Forget(now time.Time) {
+	for id, f := range t.filters {
+		if now.Sub(f.at) > forgetAfter {
+			delete(t.filters, id)
+		}
+	}
+}
+
+func newFilter(r Report) *filter {
+	f := &filter{origin: r.Position, at: r.At, altAt: r.At, alt: r.AltM, speed: r.SpeedMS, reports: 1, history: []bool{false}}
+	f.p[0][0], f.p[1][1] = positionSigmaM*positionSigmaM, positionSigmaM*positionSigmaM
+	if vx, vy, ok := velocity(r); ok {
+		f.x[2], f.x[3] = vx, vy
+		v := velocityVariance(r)
+		f.p[2][2], f.p[3][3] = v, v
+	} else {
+		// Unknown velocity: anything up to airliner speeds.
+		f.p[2][2], f.p[3][3] = 250*250, 250*250
+	}
+	return f
+}
+
+// check returns why r's position is implausible given the track, or "".
+func (f *filter) check(r Report) string {
+	dt := r.At.Sub(f.at).Seconds()
+	x, y := f.project(r.Position)
+	implied := math.Hypot(x-f.x[0], y-f.x[1]) / dt
+	limit := maxSpeedMS * 1.0
+	if f.speed != nil || r.SpeedMS != nil {
+		fastest := 0.0
+		for _, v := range []*float64{f.speed, r.SpeedMS} {
+			if v != nil {
+				fastest = math.Max(fastest, *v)
+			}
+		}
+		limit = math.Min(limit, fastest*speedSlack+speedSlackMS)
+	}
+	// Allow for the position error of both reports over short gaps.
+	if implied > limit+3*positionSigmaM/dt {
+		return "implausible speed"
+	}
+
+	// Mahalanobis distance against the predicted position.
+	px, py, pp := f.predicted(dt)
+	sxx, syy, sxy := pp[0][0]+positionSigmaM*positionSigmaM, pp[1][1]+positionSigmaM*positionSigmaM, pp[0][1]
+	det := sxx*syy - sxy*sxy
+	if det > 0 {
+		dx, dy := x-px, y-py
+		if d2 := (dx*dx*syy - 2*dx*dy*sxy + dy*dy*sxx) / det; d2 > gateChi2 {
+			return "outside predicted track"
+		}
+	}
+	return ""
+}
+
+// altitudeJump reports whether r's altitude is too far from the last plausible one.
+func (f *filter) altitudeJump(r Report) bool {
+	if f.alt == nil {
+		return false
+	}
+	return math.Abs(*r.AltM-*f.alt)/r.At.Sub(f.altAt).Seconds() > maxClimbMS
+}
+
+// predicted returns the predicted position and covariance dt seconds after the last update.
+func (f *filter) predicted(dt float64) (float64, float64, [4][4]float64) {
+	x := f.x[0] + f.x[2]*dt
+	y := f.x[1] + f.x[3]*dt
+	return x, y, propagate(f.p, dt)
+}
+
+// predict advances the state to at.
+func (f *filter) predict(at time.Time) {
+	dt := at.Sub(f.at).Seconds()
+	f.x[0] += f.x[2] * dt
+	f.x[1] += f.x[3] * dt
+	f.p = propagate(f.p, dt)
+	f.at = at
+}
+
+// propagate applies the constant-velocity transition and white-acceleration
+// process noise to p.
+func propagate(p [4][4]float64, dt float64) [4][4]float64 {
+	// F = [[1,0,dt,0],[0,1,0,dt],[0,0,1,0],[0,0,0,1]]; P' = F P Fᵀ + Q.
+	var fp [4][4]float64
+	for j := 0; j < 4; j++ {
+		fp[0][j] = p[0][j] + dt*p[2][j]
+		fp[1][j] = p[1][j] + dt*p[3][j]
+		fp[2][j] = p[2][j]
+		fp[3][j] = p[3][j]
+	}
+	var out [4][4]float64
+	for i := 0; i < 4; i++ {
+		out[i][0] = fp[i][0] + dt*fp[i][2]
+		out[i][1] = fp[i][1] + dt*fp[i][3]
+		out[i][2] = fp[i][2]
+		out[i][3] = fp[i][3]
+	}
+	q := accelSigmaMS2 * accelSigmaMS2
+	dt2, dt3, dt4 := dt*dt, dt*dt*dt, dt*dt*dt*dt
+	for _, k := range [2]int{0, 1} {
+		out[k][k] += q * dt4 / 4
+		out[k][k+2] += q * dt3 / 2
+		out[k+2][k] += q * dt3 / 2
+		out[k+2][k+2] += q * dt2
+	}
+	return out
+}
+
+// correct folds in the report's position and, when known, velocity. The
+// measurements are independent, so they are applied one at a time.
+func (f *filter) correct(r Report) {
+	x, y := f.project(r.Position)
+	f.scalarUpdate(0, x, positionSigmaM*positionSigmaM)
+	f.scalarUpdate(1, y, positionSigmaM*positionSigmaM)
+	if vx, vy, ok := velocity(r); ok {
+		v := velocityVariance(r)
+		f.scalarUpdate(2, vx, v)
+		f.scalarUpdate(3, vy, v)
+	}
+	if r.SpeedMS != nil {
+		f.speed = r.SpeedMS
+	}
+}
+
+// scalarUpdate applies a measurement z of state k with variance r.
+func (f *filter) scalarUpdate(k int, z, r float64) {
+	s := f.p[k][k] + r
+	var gain [4]float64
+	for i := range gain {
+		gain[i] = f.p[i][k] / s
+	}
+	innov := z - f.x[k]
+	row := f.p[k]
+	for i := 0; i < 4; i++ {
+		f.x[i] += gain[i] * innov
+		for j := 0; j < 4; j++ {
+			f.p[i][j] -= gain[i] * row[j]
+		}
+	}
+}
+
+// estimate converts the state back to a position and velocity.
+func (f *filter) estimate(reason string) Estimate {
+	e := Estimate{
+		Position: f.unproject(f.x[0], f.x[1]),
+		AltM:     f.alt,
+		SpeedMS:  math.Hypot(f.x[2], f.x[3]),
+		TrackDeg: math.Mod(math.Atan2(f.x[2], f.x[3])*180/math.Pi+360, 360),
+		SigmaM:   math.Sqrt((f.p[0][0] + f.p[1][1]) / 2),
+		Rejected: reason != "",
+		Reason:   reason,
+		Reports:  f.reports,
+	}
+	for _, bad := range f.history {
+		if bad {
+			e.Outliers++
+		}
+	}
+	e.Score, e.Quality = f.quality(e)
+	return e
+}
+
+// quality scores the track from its outlier rate and position uncertainty.
+func (f *filter) quality(e Estimate) (float64, string) {
+	clean := 1 - float64(e.Outliers)/float64(len(f.history))
+	// Full marks within 100 m, none beyond 2 km.
+	precision := 1 - math.Max(0, math.Min(1, (e.SigmaM-100)/1900))
+	score := math.Round(100*clean*(0.5+0.5*precision)) / 100
+	switch {
+	case e.Reports < 3:
+		return score, QualityNew
+	case score >= 0.8:
+		return score, QualityGood
+	case score >= 0.5:
+		return score, QualityFair
+	}
+	return score, QualityPoor
+}
+
+// project returns p's east and north offsets from the track origin in metres.
+func (f *filter) project(p geo.LatLon) (float64, float64) {
+	const mPerDeg = geo.EarthRadiusKm * 1000 * math.Pi / 180
+	return (p.Lon - f.origin.Lon) * mPerDeg * math.Cos(f.origin.Lat*math.Pi/180), (p.Lat - f.origin.Lat) * mPerDeg
+}
+
+func (f *filter) unproject(x, y float64) geo.LatLon {
+	const mPerDeg = geo.EarthRadiusKm * 1000 * math.Pi / 180
+	return geo.LatLon{Lat: f.origin.Lat + y/mPerDeg, Lon: f.origin.Lon + x/(mPerDeg*math.Cos(f.origin.Lat*math.Pi/180))}
+}
+
+// velocity returns the reported velocity as east and north components.
+func velocity(r Report) (float64, float64, bool) {
+	if r.SpeedMS == nil || r.TrackDeg == nil {
+		return 0, 0, false
+	}
+	rad := *r.TrackDeg * math.Pi / 180
+	return *r.SpeedMS * math.Sin(rad), *r.SpeedMS * math.Cos(rad), true
+}
+
+func velocityVariance(r Report) float64 {
+	cross := *r.SpeedMS * trackSigmaRad
+	return speedSigmaMS*speedSigmaMS + cross*cross
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,164 @@
+package track
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"AirTraffic-Monitor/geo"
+)
+
+var (
+	t0    = time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC)
+	start = geo.LatLon{Lat: 51.5, Lon: -0.3}
+)
+
+func ptr(v float64) *float64 { return &v }
+
+// eastbound returns the report of an aircraft flying east at 200 m/s, n steps
+// of 10 s after t0, with its position nudged by noiseM metres to the north.
+func eastbound(n int, noiseM float64) Report {
+	truth := geo.Destination(start, 90, float64(n)*2)
+	pos := geo.Destination(truth, 0, math.Abs(noiseM)/1000)
+	if noiseM < 0 {
+		pos = geo.Destination(truth, 180, -noiseM/1000)
+	}
+	return Report{ICAO24: "4CA123", At: t0.Add(time.Duration(n) * 10 * time.Second), Position: pos,
+		AltM: ptr(3000), SpeedMS: ptr(200), TrackDeg: ptr(90)}
+}
+
+// truthKm returns how far e is from where the eastbound aircraft really is.
+func truthKm(e Estimate, n int) float64 {
+	return geo.HaversineKm(e.Position, geo.Destination(start, 90, float64(n)*2))
+}
+
+func TestSmoothsNoisyTrack(t *testing.T) {
+	tr := NewTracker()
+	noise := []float64{0, 40, -40, 30, -30, 45, -45, 20, -20, 35, -35, 40}
+	var e Estimate
+	for n, dn := range noise {
+		e = tr.Update(eastbound(n, dn))
+		if e.Rejected {
+			t.Fatalf("report %d rejected: %s", n, e.Reason)
+		}
+	}
+	n := len(noise) - 1
+	if d := truthKm(e, n); d > 0.03 {
+		t.Errorf("estimate %.0f m from the truth, want under 30 m", d*1000)
+	}
+	if math.Abs(e.SpeedMS-200) > 5 || geo.AngleDiff(e.TrackDeg, 90) > 2 {
+		t.Errorf("speed %.1f m/s track %.1f°, want 200 m/s 90°", e.SpeedMS, e.TrackDeg)
+	}
+	if e.Quality != QualityGood || e.Reports != len(noise) || e.Outliers != 0 {
+		t.Errorf("quality %s, %d reports, %d outliers", e.Quality, e.Reports, e.Outliers)
+	}
+	if e.SigmaM >= positionSigmaM {
+		t.Errorf("sigma %.0f m, want below a single report's %d m", e.SigmaM, positionSigmaM)
+	}
+}
+
+func TestRejectsOutliers(t *testing.T) {
+	tests := []struct {
+		name       string
+		bad        func(Report) Report
+		wantReason string
+	}{
+		{
+			name: "position jump of 20 km",
+			bad: func(r Report) Report {
+				r.Position = geo.Destination(r.Position, 0, 20)
+				return r
+			},
+			wantReason: "implausible speed",
+		},
+		{
+			name: "position 600 m off track",
+			bad: func(r Report) Report {
+				r.Position = geo.Destination(r.Position, 0, 0.6)
+				return r
+			},
+			wantReason: "outside predicted track",
+		},
+		{
+			name: "altitude jump of 3000 m in 10 s",
+			bad: func(r Report) Report {
+				r.AltM = ptr(6000)
+				return r
+			},
+			wantReason: "implausible altitude change",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr := NewTracker()
+			for n := 0; n < 6; n++ {
+				tr.Update(eastbound(n, 0))
+			}
+			e := tr.Update(tt.bad(eastbound(6, 0)))
+			if !e.Rejected || e.Reason != tt.wantReason {
+				t.Fatalf("rejected %v (%q), want %q", e.Rejected, e.Reason, tt.wantReason)
+			}
+			if d := truthKm(e, 6); d > 0.1 {
+				t.Errorf("estimate %.0f m from the truth after an outlier", d*1000)
+			}
+			if e.AltM == nil || *e.AltM != 3000 {
+				t.Errorf("altitude %v, want the last plausible 3000 m", e.AltM)
+			}
+			if e.Outliers != 1 {
+				t.Errorf("outliers = %d, want 1", e.Outliers)
+			}
+
+			if e := tr.Update(eastbound(7, 0)); e.Rejected {
+				t.Errorf("next good report rejected: %s", e.Reason)
+			}
+		})
+	}
+}
+
+func TestRestartsAfterRepeatedRejects(t *testing.T) {
+	tr := NewTracker()
+	for n := 0; n < 5; n++ {
+		tr.Update(eastbound(n, 0))
+	}
+	// The track was wrong: the aircraft is really 20 km north.
+	moved := func(n int) Report {
+		r := eastbound(n, 0)
+		r.Position = geo.Destination(r.Position, 0, 20)
+		return r
+	}
+	for n := 5; n < 5+maxRejects-1; n++ {
+		if e := tr.Update(moved(n)); !e.Rejected {
+			t.Fatalf("report %d accepted", n)
+		}
+	}
+	e := tr.Update(moved(5 + maxRejects - 1))
+	if e.Rejected || e.Reports != 1 || e.Quality != QualityNew {
+		t.Fatalf("after %d rejects: rejected %v, %d reports, %s; want a fresh track", maxRejects, e.Rejected, e.Reports, e.Quality)
+	}
+	if d := geo.HaversineKm(e.Position, moved(5+maxRejects-1).Position); d > 0.001 {
+		t.Errorf("fresh track starts %.0f m from the report", d*1000)
+	}
+}
+
+func TestTrackLifetime(t *testing.T) {
+	tr := NewTracker()
+	tr.Update(eastbound(0, 0))
+	tr.Update(eastbound(1, 0))
+
+	if e := tr.Update(eastbound(1, 0)); e.Reports != 2 {
+		t.Errorf("repeated report counted: %d reports", e.Reports)
+	}
+	if e := tr.Update(eastbound(1+int(restartGap/(10*time.Second))+1, 0)); e.Reports != 1 {
+		t.Errorf("report after a %v gap continued the track (%d reports)", restartGap, e.Reports)
+	}
+
+	last := t0.Add(time.Duration(2+int(restartGap/(10*time.Second))) * 10 * time.Second)
+	tr.Forget(last.Add(forgetAfter - time.Second))
+	if tr.Len() != 1 {
+		t.Fatalf("track forgotten early")
+	}
+	tr.Forget(last.Add(forgetAfter + time.Second))
+	if tr.Len() != 0 {
+		t.Errorf("track kept after %v", forgetAfter)
+	}
+}
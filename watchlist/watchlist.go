@@ -0,0 +1,345 @@
+// Package watchlist keeps the persisted list of airframes, operators, types and
+// callsigns we want flagged, and the spotted log of when they were seen.
+package watchlist
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"AirTraffic-Monitor/internal/jsonfile"
+)
+
+// ErrNotFound is returned when a watchlist entry ID does not exist.
+var ErrNotFound = errors.New("watchlist entry not found")
+
+// ValidationError is returned by Add and Update when an entry is rejected
+// before anything is stored; other errors from them are persistence failures.
+type ValidationError struct {
+	Reason string
+}
+
+func (e *ValidationError) Error() string {
+	return e.Reason
+}
+
+// Entry describes something we want to be told about. Every non-empty
+// criterion must match for the entry to fire; at least one must be set.
+type Entry struct {
+	ID           string    `json:"id"`
+	Label        string    `json:"label"`
+	Registration string    `json:"registration,omitempty"`
+	ICAO24       string    `json:"icao24,omitempty"`
+	Owner        string    `json:"owner,omitempty"`     // case-insensitive substring
+	ICAOType     string    `json:"icao_type,omitempty"` // e.g. A388
+	Callsign     string    `json:"callsign,omitempty"`  // glob pattern, e.g. RRR*
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// Sighting is one line of the spotted log: a watched airframe and when we saw it.
+type Sighting struct {
+	ICAO24       string    `json:"icao24"`
+	Registration string    `json:"registration"`
+	Type         string    `json:"type"`
+	Callsign     string    `json:"callsign"`
+	EntryIDs     []string  `json:"entry_ids"`
+	Labels       []string  `json:"labels"`
+	FirstSeen    time.Time `json:"first_seen"`
+	LastSeen     time.Time `json:"last_seen"`
+	Cycles       int       `json:"cycles"`
+}
+
+// Watchlist holds the watch entries and spotted log, persisted as JSON files.
+type Watchlist struct {
+	mu          sync.RWMutex
+	path        string
+	spottedPath string
+	entries     []Entry
+	spotted     map[string]*Sighting
+	nextID      int
+	dirty       bool // spotted log changed since last save
+}
+
+// normalizeEntry upper-cases identifiers and validates that the entry can match something.
+func normalizeEntry(e Entry) (Entry, error) {
+	e.Registration = strings.ToUpper(strings.TrimSpace(e.Registration))
+	e.ICAO24 = strings.ToUpper(strings.TrimSpace(e.ICAO24))
+	e.Owner = strings.TrimSpace(e.Owner)
+	e.ICAOType = strings.ToUpper(strings.TrimSpace(e.ICAOType))
+	e.Callsign = strings.ToUpper(strings.TrimSpace(e.Callsign))
+	e.Label = strings.TrimSpace(e.Label)
+
+	if e.Registration == "" && e.ICAO24 == "" && e.Owner == "" && e.ICAOType == "" && e.Callsign == "" {
+		return e, &ValidationError{Reason: "watchlist entry needs at least one of registration, icao24, owner, icao_type or callsign"}
+	}
+	if e.Callsign != "" {
+		if _, err := path.Match(e.Callsign, ""); err != nil {
+			return e, &ValidationError{Reason: fmt.Sprintf("invalid callsign pattern %q: %v", e.Callsign, err)}
+		}
+	}
+	if e.Label == "" {
+		e.Label = firstNonEmpty(e.Registration, e.ICAO24, e.Callsign, e.ICAOType, e.Owner)
+	}
+	return e, nil
+}
+
+// normalizeRegistration makes "g-euuu" and "GEUUU" compare equal.
+func normalizeRegistration(reg string) string {
+	return strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(reg), "-", ""))
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// Target is the subset of an aircraft that entries can match on.
+type Target struct {
+	ICAO24       string
+	Registration string
+	Owner        string
+	ICAOType     string
+	Type         string // display type, recorded in the spotted log
+	Callsign     string
+}
+
+// Matches reports whether every criterion set on the entry holds for the aircraft.
+func (e Entry) Matches(a Target) bool {
+	if e.Registration != "" && normalizeRegistration(a.Registration) != normalizeRegistration(e.Registration) {
+		return false
+	}
+	if e.ICAO24 != "" && !strings.EqualFold(a.ICAO24, e.ICAO24) {
+		return false
+	}
+	if e.Owner != "" && !strings.Contains(strings.ToUpper(a.Owner), strings.ToUpper(e.Owner)) {
+		return false
+	}
+	if e.ICAOType != "" && !strings.EqualFold(a.ICAOType, e.ICAOType) {
+		return false
+	}
+	if e.Callsign != "" {
+		ok, _ := path.Match(e.Callsign, strings.ToUpper(a.Callsign))
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// Load reads the watchlist and spotted log, treating missing files as empty.
+// An empty spottedPath keeps the spotted log in memory only.
+func Load(path, spottedPath string) (*Watchlist, error) {
+	w := &Watchlist{
+		path:        path,
+		spottedPath: spottedPath,
+		spotted:     make(map[string]*Sighting),
+	}
+	if err := jsonfile.Read(path, &w.entries); err != nil {
+		return nil, fmt.Errorf("load watchlist: %w", err)
+	}
+	var sightings []*Sighting
+	if err := jsonfile.Read(spottedPath, &sightings); err != nil {
+		return nil, fmt.Errorf("load spotted log: %w", err)
+	}
+	for _, s := range sightings {
+		w.spotted[s.ICAO24] = s
+	}
+	for _, e := range w.entries {
+		if n, err := strconv.Atoi(e.ID); err == nil && n > w.nextID {
+			w.nextID = n
+		}
+	}
+	return w, nil
+}
+
+// Entries returns a copy of the watch entries.
+func (w *Watchlist) Entries() []Entry {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return append([]Entry(nil), w.entries...)
+}
+
+// Entry returns a single watch entry by ID.
+func (w *Watchlist) Entry(id string) (Entry, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	for _, e := range w.entries {
+		if e.ID == id {
+			return e, nil
+		}
+	}
+	return Entry{}, ErrNotFound
+}
+
+// Add validates and stores a new entry, assigning it an ID. If the watchlist
+// cannot be saved the entry is dropped again.
+func (w *Watchlist) Add(e Entry) (Entry, error) {
+	e, err := normalizeEntry(e)
+	if err != nil {
+		return e, err
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.nextID++
+	e.ID = strconv.Itoa(w.nextID)
+	e.CreatedAt = time.Now().UTC()
+	w.entries = append(w.entries, e)
+	if err := w.saveEntriesLocked(); err != nil {
+		w.entries = w.entries[:len(w.entries)-1]
+		w.nextID--
+		return Entry{}, err
+	}
+	return e, nil
+}
+
+// Update replaces the criteria of an existing entry, keeping its ID and
+// creation time. If the watchlist cannot be saved the old entry is restored.
+func (w *Watchlist) Update(id string, e Entry) (Entry, error) {
+	e, err := normalizeEntry(e)
+	if err != nil {
+		return e, err
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for i := range w.entries {
+		if w.entries[i].ID == id {
+			e.ID = id
+			e.CreatedAt = w.entries[i].CreatedAt
+			old := w.entries[i]
+			w.entries[i] = e
+			if err := w.saveEntriesLocked(); err != nil {
+				w.entries[i] = old
+				return Entry{}, err
+			}
+			return e, nil
+		}
+	}
+	return Entry{}, ErrNotFound
+}
+
+// Delete removes an entry, keeping it if the watchlist cannot be saved.
+// Past sightings stay in the spotted log.
+func (w *Watchlist) Delete(id string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for i := range w.entries {
+		if w.entries[i].ID == id {
+			old := w.entries
+			w.entries = append(append([]Entry(nil), w.entries[:i]...), w.entries[i+1:]...)
+			if err := w.saveEntriesLocked(); err != nil {
+				w.entries = old
+				return err
+			}
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+// Spotted returns the spotted log, most recently seen first.
+func (w *Watchlist) Spotted() []Sighting {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	out := make([]Sighting, 0, len(w.spotted))
+	for _, s := range w.spotted {
+		out = append(out, *s)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].LastSeen.After(out[j].LastSeen)
+	})
+	return out
+}
+
+// Observe returns the entries matching t and, if there are any, records the
+// sighting in the spotted log. Call SaveSpotted once per cycle to persist it.
+func (w *Watchlist) Observe(t Target, now time.Time) []Entry {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var matched []Entry
+	for _, e := range w.entries {
+		if e.Matches(t) {
+			matched = append(matched, e)
+		}
+	}
+	if len(matched) == 0 {
+		return nil
+	}
+
+	s, ok := w.spotted[t.ICAO24]
+	if !ok {
+		s = &Sighting{ICAO24: t.ICAO24, FirstSeen: now}
+		w.spotted[t.ICAO24] = s
+	}
+	s.Registration = t.Registration
+	s.Type = t.Type
+	s.Callsign = t.Callsign
+	s.EntryIDs, s.Labels = nil, nil
+	for _, e := range matched {
+		s.EntryIDs = append(s.EntryIDs, e.ID)
+		s.Labels = append(s.Labels, e.Label)
+	}
+	s.LastSeen = now
+	s.Cycles++
+	w.dirty = true
+	return matched
+}
+
+// SaveSpotted writes the spotted log if Observe recorded anything since the last save.
+func (w *Watchlist) SaveSpotted() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.dirty {
+		return nil
+	}
+	if err := w.saveSpottedLocked(); err != nil {
+		return err
+	}
+	w.dirty = false
+	return nil
+}
+
+// Flush writes the entries and spotted log to disk.
+func (w *Watchlist) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err := w.saveEntriesLocked(); err != nil {
+		return err
+	}
+	if err := w.saveSpottedLocked(); err != nil {
+		return err
+	}
+	w.dirty = false
+	return nil
+}
+
+func (w *Watchlist) saveEntriesLocked() error {
+	entries := w.entries
+	if entries == nil {
+		entries = []Entry{}
+	}
+	return jsonfile.Write(w.path, entries)
+}
+
+func (w *Watchlist) saveSpottedLocked() error {
+	if w.spottedPath == "" {
+		return nil
+	}
+	sightings := make([]*Sighting, 0, len(w.spotted))
+	for _, s := range w.spotted {
+		sightings = append(sightings, s)
+	}
+	sort.Slice(sightings, func(i, j int) bool {
+		return sightings[i].FirstSeen.Before(sightings[j].FirstSeen)
+	})
+	return jsonfile.Write(w.spottedPath, sightings)
+}
@@ -0,0 +1,74 @@
+package watchlist
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestAddRejectsInvalidEntries(t *testing.T) {
+	w, err := Load(filepath.Join(t.TempDir(), "watchlist.json"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name  string
+		entry Entry
+	}{
+		{"no criteria", Entry{Label: "nothing"}},
+		{"bad callsign glob", Entry{Callsign: "BAW["}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := w.Add(tt.entry)
+			var ve *ValidationError
+			if !errors.As(err, &ve) {
+				t.Fatalf("Add() error = %v, want *ValidationError", err)
+			}
+		})
+	}
+	if n := len(w.Entries()); n != 0 {
+		t.Errorf("stored %d entries after rejected adds, want 0", n)
+	}
+}
+
+func TestFailedSaveRollsBack(t *testing.T) {
+	dir := t.TempDir()
+	w, err := Load(filepath.Join(dir, "watchlist.json"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	kept, err := w.Add(Entry{Registration: "g-euuu"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Point the watchlist at a directory that does not exist so every save fails.
+	w.path = filepath.Join(dir, "missing", "watchlist.json")
+
+	_, err = w.Add(Entry{ICAOType: "A388"})
+	var ve *ValidationError
+	if err == nil || errors.As(err, &ve) {
+		t.Fatalf("Add() error = %v, want a persistence error", err)
+	}
+	if _, err := w.Update(kept.ID, Entry{Registration: "G-VIIA"}); err == nil {
+		t.Fatal("Update() succeeded with an unwritable path")
+	}
+	if err := w.Delete(kept.ID); err == nil {
+		t.Fatal("Delete() succeeded with an unwritable path")
+	}
+
+	entries := w.Entries()
+	if len(entries) != 1 || entries[0] != kept {
+		t.Fatalf("entries after failed saves = %+v, want only %+v", entries, kept)
+	}
+
+	w.path = filepath.Join(dir, "watchlist.json")
+	added, err := w.Add(Entry{ICAOType: "A388"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if added.ID != "2" {
+		t.Errorf("ID after rollback = %q, want %q", added.ID, "2")
+	}
+}
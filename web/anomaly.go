@@ -0,0 +1,96 @@
+package web
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"AirTraffic-Monitor/monitor"
+)
+
+// recentAnomalies is how many anomaly events /api/anomalies returns.
+const recentAnomalies = 100
+
+// suspect is one aircraft in /api/anomalies.
+type suspect struct {
+	ICAO24              string            `json:"icao24"`
+	Callsign            string            `json:"callsign,omitempty"`
+	Registration        string            `json:"registration,omitempty"`
+	RegistrationCountry string            `json:"registration_country,omitempty"`
+	Score               float64           `json:"score"`
+	Anomalies           []monitor.Anomaly `json:"anomalies"`
+	Latitude            *float64          `json:"lat,omitempty"`
+	Longitude           *float64          `json:"lon,omitempty"`
+	Altitude            *float64          `json:"altitude,omitempty"` // metres
+	Sources             []string          `json:"sources,omitempty"`
+}
+
+// anomaliesHandler lists the aircraft currently showing anomalies, most
+// suspicious first, and the most recent anomaly events, newest first. It takes
+// the /api filters plus min_score (0-1) and anomaly (comma-separated kinds).
+func (s *Server) anomaliesHandler(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	minScore := 0.0
+	if v := q.Get("min_score"); v != "" {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil || f < 0 || f > 1 {
+			http.Error(w, "min_score must be a number from 0 to 1", http.StatusBadRequest)
+			return
+		}
+		minScore = f
+	}
+	var kinds []string
+	if v := q.Get("anomaly"); v != "" {
+		kinds = strings.Split(v, ",")
+	}
+
+	aircraft, lastUpdate := s.store.Snapshot()
+	suspects := []suspect{}
+	for _, a := range filterAircraft(aircraft, q) {
+		if len(a.Anomalies) == 0 || a.AnomalyScore < minScore || !hasAnomalyKind(a.Anomalies, kinds) {
+			continue
+		}
+		suspects = append(suspects, suspect{
+			ICAO24:              a.ICAO24,
+			Callsign:            a.Callsign,
+			Registration:        a.Registration,
+			RegistrationCountry: a.RegistrationCountry,
+			Score:               a.AnomalyScore,
+			Anomalies:           a.Anomalies,
+			Latitude:            a.Latitude,
+			Longitude:           a.Longitude,
+			Altitude:            a.BaroAltitude,
+			Sources:             a.Sources,
+		})
+	}
+	sort.SliceStable(suspects, func(i, j int) bool { return suspects[i].Score > suspects[j].Score })
+
+	recent := []monitor.Event{}
+	for _, ev := range s.store.Events() {
+		if ev.Type == monitor.EventAnomaly {
+			recent = append(recent, ev)
+			if len(recent) == recentAnomalies {
+				break
+			}
+		}
+	}
+	writeJSON(w, http.StatusOK, struct {
+		LastUpdate string          `json:"last_update"`
+		Aircraft   []suspect       `json:"aircraft"`
+		Recent     []monitor.Event `json:"recent"`
+	}{LastUpdate: lastUpdate, Aircraft: suspects, Recent: recent})
+}
+
+// hasAnomalyKind reports whether any anomaly is one of kinds; no kinds matches all.
+func hasAnomalyKind(anomalies []monitor.Anomaly, kinds []string) bool {
+	if len(kinds) == 0 {
+		return true
+	}
+	for _, a := range anomalies {
+		if matchesAny(a.Kind, kinds) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,56 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"AirTraffic-Monitor/monitor"
+)
+
+func TestAnomaliesHandler(t *testing.T) {
+	h := trafficServer(t)
+	tests := []struct {
+		url        string
+		wantStatus int
+		want       []string // ICAO24s, most suspicious first
+	}{
+		{"/api/anomalies", http.StatusOK, []string{"3C6444", "A1B2C3"}},
+		{"/api/anomalies?min_score=0.5", http.StatusOK, []string{"3C6444"}},
+		{"/api/anomalies?anomaly=operator_mismatch,duplicate_address", http.StatusOK, []string{"A1B2C3"}},
+		{"/api/anomalies?anomaly=altitude_mismatch", http.StatusOK, nil},
+		{"/api/anomalies?min_score=2", http.StatusBadRequest, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			w := get(t, h, tt.url)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			var data struct {
+				Aircraft []suspect       `json:"aircraft"`
+				Recent   []monitor.Event `json:"recent"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &data); err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, s := range data.Aircraft {
+				got = append(got, s.ICAO24)
+				if len(s.Anomalies) == 0 || s.Score == 0 {
+					t.Errorf("%s listed without its reasons: %+v", s.ICAO24, s)
+				}
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("aircraft %v, want %v", got, tt.want)
+			}
+			if len(data.Recent) != 1 || data.Recent[0].Type != monitor.EventAnomaly {
+				t.Errorf("recent = %+v, want the one anomaly event", data.Recent)
+			}
+		})
+	}
+}
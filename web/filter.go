@@ -0,0 +1,127 @@
+package web
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"AirTraffic-Monitor/actype"
+	"AirTraffic-Monitor/monitor"
+)
+
+// groupKeys maps the group query parameter to the attribute aircraft are grouped by.
+var groupKeys = map[string]func(monitor.AircraftInfo) string{
+	"kind":        func(a monitor.AircraftInfo) string { return a.Kind },
+	"wake":        func(a monitor.AircraftInfo) string { return a.WakeCategory },
+	"engine_type": func(a monitor.AircraftInfo) string { return a.EngineType },
+	"engines": func(a monitor.AircraftInfo) string {
+		if a.Engines == 0 {
+			return ""
+		}
+		return strconv.Itoa(a.Engines)
+	},
+	"description":      func(a monitor.AircraftInfo) string { return a.TypeDescription },
+	"family":           func(a monitor.AircraftInfo) string { return a.TypeFamily },
+	"category":         func(a monitor.AircraftInfo) string { return actype.CategoryName(a.Category) },
+	"route_confidence": func(a monitor.AircraftInfo) string { return a.RouteConfidence },
+	"movement":         func(a monitor.AircraftInfo) string { return a.Movement },
+	"airport":          func(a monitor.AircraftInfo) string { return a.LocalAirport },
+	"status":           func(a monitor.AircraftInfo) string { return a.Status },
+	"track_quality":    func(a monitor.AircraftInfo) string { return a.TrackQuality },
+}
+
+// filterAircraft keeps the aircraft matching every filter in q. origin_country
+// and destination_country take an ISO code or country name; kind, wake,
+// engine_type, engines, description and family match the type attributes,
+// route_confidence the route check, and movement, airport and status the
+// arrivals and departures classification (case-insensitive, comma-separated
+// alternatives).
+func filterAircraft(aircraft []monitor.AircraftInfo, q url.Values) []monitor.AircraftInfo {
+	oc, dc := q.Get("origin_country"), q.Get("destination_country")
+	attrs := make(map[string][]string)
+	for name := range groupKeys {
+		if v := q.Get(name); v != "" {
+			attrs[name] = strings.Split(v, ",")
+		}
+	}
+	if oc == "" && dc == "" && len(attrs) == 0 {
+		return aircraft
+	}
+	filtered := []monitor.AircraftInfo{}
+	for _, a := range aircraft {
+		if !inCountry(a.OriginAirport, oc) || !inCountry(a.DestinationAirport, dc) {
+			continue
+		}
+		ok := true
+		for name, want := range attrs {
+			if !matchesAny(groupKeys[name](a), want) {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			filtered = append(filtered, a)
+		}
+	}
+	return filtered
+}
+
+func matchesAny(v string, want []string) bool {
+	for _, w := range want {
+		if strings.EqualFold(v, strings.TrimSpace(w)) {
+			return true
+		}
+	}
+	return false
+}
+
+// aircraftGroup is one group of the board or API response.
+type aircraftGroup struct {
+	Key      string                 `json:"key"`
+	Count    int                    `json:"count"`
+	Aircraft []monitor.AircraftInfo `json:"-"`
+	ICAO24   []string               `json:"icao24"`
+}
+
+// groupAircraft splits aircraft by the attribute named by, largest group first;
+// aircraft without the attribute go in an "unknown" group at the end.
+func groupAircraft(aircraft []monitor.AircraftInfo, by string) ([]aircraftGroup, error) {
+	key, ok := groupKeys[by]
+	if !ok {
+		names := make([]string, 0, len(groupKeys))
+		for name := range groupKeys {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("unknown group %q; use one of %s", by, strings.Join(names, ", "))
+	}
+	index := make(map[string]int)
+	var groups []aircraftGroup
+	for _, a := range aircraft {
+		k := key(a)
+		if k == "" {
+			k = actype.KindUnknown
+		}
+		i, ok := index[k]
+		if !ok {
+			i = len(groups)
+			index[k] = i
+			groups = append(groups, aircraftGroup{Key: k})
+		}
+		groups[i].Count++
+		groups[i].Aircraft = append(groups[i].Aircraft, a)
+		groups[i].ICAO24 = append(groups[i].ICAO24, a.ICAO24)
+	}
+	sort.SliceStable(groups, func(i, j int) bool {
+		if (groups[i].Key == actype.KindUnknown) != (groups[j].Key == actype.KindUnknown) {
+			return groups[j].Key == actype.KindUnknown
+		}
+		if groups[i].Count != groups[j].Count {
+			return groups[i].Count > groups[j].Count
+		}
+		return groups[i].Key < groups[j].Key
+	})
+	return groups, nil
+}
@@ -0,0 +1,142 @@
+package web
+
+import (
+	"encoding/csv"
+	"net/http"
+	"strconv"
+	"time"
+
+	"AirTraffic-Monitor/noise"
+)
+
+// observerView is one observer in /api/noise.
+type observerView struct {
+	noise.Observer
+	Days   []noise.Day        `json:"days"`   // oldest first
+	Recent []noise.Overflight `json:"recent"` // most recent first
+}
+
+// noiseQuery reads the observer, from and to parameters shared by the noise
+// routes. It writes the error response and returns false when they are invalid.
+func (s *Server) noiseQuery(w http.ResponseWriter, r *http.Request, defaultDays int) (observer, from, to string, ok bool) {
+	q := r.URL.Query()
+	observer, from, to = q.Get("observer"), q.Get("from"), q.Get("to")
+	if observer != "" && !s.hasObserver(observer) {
+		http.Error(w, "unknown observer", http.StatusNotFound)
+		return "", "", "", false
+	}
+	for _, d := range []string{from, to} {
+		if _, err := time.Parse(time.DateOnly, d); d != "" && err != nil {
+			http.Error(w, "from and to must be dates like 2024-06-30", http.StatusBadRequest)
+			return "", "", "", false
+		}
+	}
+	if from == "" && to == "" && defaultDays > 0 {
+		from = s.Clock().AddDate(0, 0, 1-defaultDays).Format(time.DateOnly)
+	}
+	return observer, from, to, true
+}
+
+func (s *Server) hasObserver(name string) bool {
+	for _, o := range s.Noise.Observers() {
+		if o.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// noiseHandler returns each observer's daily noise totals, the last week unless
+// from and to say otherwise, and its most recent overflights, e.g.
+// /api/noise?observer=Home&from=2024-06-01&to=2024-06-30&limit=50.
+func (s *Server) noiseHandler(w http.ResponseWriter, r *http.Request) {
+	observer, from, to, ok := s.noiseQuery(w, r, 7)
+	if !ok {
+		return
+	}
+	limit := 20
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			http.Error(w, "limit must be a non-negative integer", http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+	data := struct {
+		ThresholdsDB []float64      `json:"thresholds_db"`
+		Observers    []observerView `json:"observers"`
+	}{ThresholdsDB: s.Noise.Thresholds(), Observers: []observerView{}}
+	for _, o := range s.Noise.Observers() {
+		if observer != "" && o.Name != observer {
+			continue
+		}
+		recent := s.Noise.Overflights(o.Name, "")
+		if len(recent) > limit {
+			recent = recent[:limit]
+		}
+		data.Observers = append(data.Observers, observerView{Observer: o, Days: s.Noise.Days(o.Name, from, to), Recent: recent})
+	}
+	writeJSON(w, http.StatusOK, data)
+}
+
+// noiseReportHandler returns the daily totals as CSV, one row per observer and
+// day, for every day kept unless observer, from or to narrow it.
+func (s *Server) noiseReportHandler(w http.ResponseWriter, r *http.Request) {
+	observer, from, to, ok := s.noiseQuery(w, r, 0)
+	if !ok {
+		return
+	}
+	thresholds := s.Noise.Thresholds()
+	header := []string{"date", "observer", "events", "night_events"}
+	for _, th := range thresholds {
+		header = append(header, noise.ThresholdKey(th))
+	}
+	header = append(header, "max_db", "lden_db", "lnight_db", "leq16_db")
+
+	cw := csvResponse(w, "noise-report.csv")
+	cw.Write(header)
+	for _, d := range s.Noise.Days(observer, from, to) {
+		row := []string{d.Date, d.Observer, strconv.Itoa(d.Events), strconv.Itoa(d.NightEvents)}
+		for _, th := range thresholds {
+			row = append(row, strconv.Itoa(d.Above[noise.ThresholdKey(th)]))
+		}
+		row = append(row, formatDB(d.MaxDB), formatDB(d.LdenDB), formatDB(d.LnightDB), formatDB(d.Leq16DB))
+		cw.Write(row)
+	}
+	cw.Flush()
+}
+
+// noiseOverflightsHandler returns the recent overflights as CSV, most recent
+// first; observer and date (YYYY-MM-DD) narrow it.
+func (s *Server) noiseOverflightsHandler(w http.ResponseWriter, r *http.Request) {
+	observer, date := r.URL.Query().Get("observer"), r.URL.Query().Get("date")
+	if observer != "" && !s.hasObserver(observer) {
+		http.Error(w, "unknown observer", http.StatusNotFound)
+		return
+	}
+	if _, err := time.Parse(time.DateOnly, date); date != "" && err != nil {
+		http.Error(w, "date must be like 2024-06-30", http.StatusBadRequest)
+		return
+	}
+	cw := csvResponse(w, "noise-overflights.csv")
+	cw.Write([]string{"time", "observer", "icao24", "callsign", "type", "class", "movement",
+		"distance_km", "alt_ft", "lamax_db", "sel_db"})
+	for _, o := range s.Noise.Overflights(observer, date) {
+		cw.Write([]string{o.Time.Local().Format(time.DateTime), o.Observer, o.ICAO24, o.Callsign, o.Type, o.Class, o.Movement,
+			strconv.FormatFloat(o.DistanceKm, 'f', 2, 64), strconv.FormatFloat(o.AltFt, 'f', 0, 64),
+			formatDB(o.LAmaxDB), formatDB(o.SELDB)})
+	}
+	cw.Flush()
+}
+
+// csvResponse sets the headers for a CSV download named filename.
+func csvResponse(w http.ResponseWriter, filename string) *csv.Writer {
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+	return csv.NewWriter(w)
+}
+
+func formatDB(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
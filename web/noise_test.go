@@ -0,0 +1,115 @@
+package web
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"AirTraffic-Monitor/monitor"
+	"AirTraffic-Monitor/noise"
+)
+
+func ptr(v float64) *float64 { return &v }
+
+// noiseServer returns a server whose meter counted one 84 dB pass over Home
+// and one 70.9 dB pass over School on 18 October 2026.
+func noiseServer(t *testing.T) http.Handler {
+	t.Helper()
+	home := noise.Observer{Name: "Home", Lat: 51.5, Lon: -0.1}
+	school := noise.Observer{Name: "School", Lat: 51.6, Lon: -0.2}
+	m, err := noise.Load("", []noise.Observer{home, school}, noise.DefaultThresholds)
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := time.Date(2026, 10, 18, 12, 0, 0, 0, time.Local)
+	m.Update([]noise.Sample{
+		{ICAO24: "400A0B", Class: noise.ClassJet, Position: home.Position(), AltM: 305, SpeedMS: ptr(70)},
+		{ICAO24: "4CA123", Class: noise.ClassJet, Position: school.Position(), AltM: 1000, SpeedMS: ptr(70)},
+	}, at)
+	if err := m.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	s := New(monitor.NewStore(), nil, nil)
+	s.Noise = m
+	s.Clock = func() time.Time { return at }
+	return s.Handler()
+}
+
+func get(t *testing.T, h http.Handler, url string) *httptest.ResponseRecorder {
+	t.Helper()
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, url, nil))
+	return w
+}
+
+func TestNoiseReportCSV(t *testing.T) {
+	h := noiseServer(t)
+	tests := []struct {
+		url        string
+		wantStatus int
+		wantRows   []string // after the header
+	}{
+		{"/api/noise/report.csv", http.StatusOK, []string{
+			"2026-10-18,Home,1,0,1,1,1,84,46,0,47.8",
+			"2026-10-18,School,1,0,1,1,1,70.9,38,0,39.8",
+		}},
+		{"/api/noise/report.csv?observer=School", http.StatusOK, []string{"2026-10-18,School,1,0,1,1,1,70.9,38,0,39.8"}},
+		{"/api/noise/report.csv?from=2026-10-19", http.StatusOK, nil},
+		{"/api/noise/report.csv?observer=Nowhere", http.StatusNotFound, nil},
+		{"/api/noise/report.csv?to=18-10-2026", http.StatusBadRequest, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			w := get(t, h, tt.url)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			records, err := csv.NewReader(w.Body).ReadAll()
+			if err != nil {
+				t.Fatal(err)
+			}
+			header := "date,observer,events,night_events,N60,N65,N70,max_db,lden_db,lnight_db,leq16_db"
+			if got := strings.Join(records[0], ","); got != header {
+				t.Errorf("header %q, want %q", got, header)
+			}
+			var rows []string
+			for _, r := range records[1:] {
+				rows = append(rows, strings.Join(r, ","))
+			}
+			if strings.Join(rows, "\n") != strings.Join(tt.wantRows, "\n") {
+				t.Errorf("rows\n%s\nwant\n%s", strings.Join(rows, "\n"), strings.Join(tt.wantRows, "\n"))
+			}
+		})
+	}
+}
+
+func TestNoiseJSON(t *testing.T) {
+	w := get(t, noiseServer(t), "/api/noise?observer=Home")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", w.Code, w.Body)
+	}
+	if strings.Contains(w.Body.String(), "energy") {
+		t.Errorf("/api/noise exposes the energy sums: %s", w.Body)
+	}
+	var data struct {
+		Observers []struct {
+			Name   string             `json:"name"`
+			Days   []noise.Day        `json:"days"`
+			Recent []noise.Overflight `json:"recent"`
+		} `json:"observers"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &data); err != nil {
+		t.Fatal(err)
+	}
+	if len(data.Observers) != 1 || data.Observers[0].Name != "Home" || len(data.Observers[0].Days) != 1 ||
+		data.Observers[0].Days[0].Above["N70"] != 1 || len(data.Observers[0].Recent) != 1 {
+		t.Errorf("observers = %+v", data.Observers)
+	}
+}
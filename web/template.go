@@ -0,0 +1,452 @@
+package web
+
+// HTML template for the web page
+const htmlTemplate = `
+<!DOCTYPE html>
+<html>
+<head>
+    <title>Aircraft Over North London</title>
+    <meta http-equiv="refresh" content="60">
+    {{template "style"}}
+</head>
+<body>
+    <h1>✈ AIRCRAFT - NORTH LONDON ✈</h1>
+    {{template "nav"}}
+    
+    <div class="header">
+        <p><strong>Coverage Area:</strong> North London (Lat: 51.50-51.80, Lon: -0.50 to 0.20)</p>
+        <p class="update-time"><strong>Last Updated:</strong> {{.LastUpdate}}</p>
+        <p class="update-time"><strong>Total Aircraft:</strong> {{len .Aircraft}}</p>
+        {{template "runways" .RunwayOps}}
+        <p class="filters"><a href="/">All</a><a href="/?kind=airliner">Airliners</a><a href="/?kind=business">Business</a><a href="/?kind=light">Light</a><a href="/?kind=helicopter">Helicopters</a><a href="/?kind=military">Military</a>| <a href="/?group=kind">By kind</a><a href="/?group=wake">By wake</a><a href="/?group=engine_type">By engine</a></p>
+        <p><em>Page auto-refreshes every 60 seconds</em></p>
+    </div>
+    {{template "holds" .Holds}}
+
+    {{if .Aircraft}}
+    <table>
+        <thead>
+            <tr>
+                <th>Registration</th>
+                <th>Airline</th>
+                <th>Owner</th>
+                <th>Manufacturer</th>
+                <th>Aircraft Type</th>
+                <th>Origin</th>
+                <th>Destination</th>
+            </tr>
+        </thead>
+        <tbody>
+            {{range .Groups}}
+            {{if .Key}}<tr class="group-header"><td colspan="7">{{.Key}} ({{.Count}})</td></tr>{{end}}
+            {{range .Aircraft}}
+            <tr{{if .Watched}} class="watched"{{end}}>
+                <td{{if .RegistrationCountry}} title="Registered in {{.RegistrationCountry}}"{{end}}>{{if .Watched}}&#9733; {{end}}{{if .LikelyMilitary}}<span class="military" title="Likely military (ICAO24 in a military block)">&#9876;</span> {{end}}{{if .Registration}}{{.Registration}}{{else}}{{.ICAO24}}{{end}}{{if .Watched}}<br><span class="watch-label">{{range $i, $l := .WatchLabels}}{{if $i}}, {{end}}{{$l}}{{end}}</span>{{end}}</td>
+                <td>{{if .Airline}}{{.Airline}}{{if .AirlineIATA}} ({{.AirlineIATA}}){{end}}{{if .AirlineCallsign}}<br><span class="airline-callsign">{{.AirlineCallsign}}</span>{{end}}{{else}}-{{end}}</td>
+                <td>{{.Owner}}</td>
+                <td>{{.Manufacturer}}</td>
+                <td>{{.Type}}{{if .ICAOType}}<br><span class="type-info" title="{{.TypeFamily}}">{{.ICAOType}}{{with .TypeDescription}} · {{.}}{{end}}{{with .WakeCategory}} · {{.}}{{end}}</span>{{end}}{{if and .Kind (ne .Kind "unknown")}}<br><span class="type-info">{{.Kind}}</span>{{end}}</td>
+                <td{{if .RouteDoubtful}} class="route-doubtful"{{end}}>{{.Origin}}</td>
+                <td{{if .RouteDoubtful}} class="route-doubtful" title="{{.RouteNote}}"{{end}}>{{.Destination}}{{if eq .RouteConfidence "low"}}<br><span class="route-flag">ROUTE UNLIKELY</span>{{else if .RouteDoubtful}}<br><span class="route-flag">ROUTE UNCONFIRMED</span>{{else}}{{with .RouteSummary}}<br><span class="airline-callsign">{{.}}</span>{{end}}{{end}}</td>
+            </tr>
+            {{end}}
+            {{end}}
+        </tbody>
+    </table>
+    {{else}}
+    <div class="no-aircraft">
+        <p>No aircraft currently detected over North London area.</p>
+        <p>Data will refresh automatically every 5 minutes.</p>
+    </div>
+    {{end}}
+
+    <div class="footer">
+        <p><strong>DATA SOURCES</strong></p>
+        <p>LIVE POSITIONS: OPENSKY NETWORK | AIRCRAFT DATA: ADSBDB.COM</p>
+        <p>MONITORING AREA: 51.50°N-51.80°N, 0.50°W-0.20°E</p>
+    </div>
+
+    <script>
+        function wrapTextInFlipChars(element) {
+            const text = element.textContent;
+            element.innerHTML = '';
+            
+            for (let i = 0; i < text.length; i++) {
+                const char = text[i];
+                const span = document.createElement('span');
+                span.className = 'flip-char';
+                span.style.setProperty('--char-index', i);
+                span.textContent = char === ' ' ? '\u00A0' : char; // Non-breaking space
+                element.appendChild(span);
+            }
+        }
+        
+        function animateTableUpdate() {
+            const rows = document.querySelectorAll('tbody tr');
+            rows.forEach((row, index) => {
+                row.style.animation = 'none';
+                row.offsetHeight; // Trigger reflow
+                row.style.animation = 'flipIn 1.2s ease-in-out ' + (index * 0.2) + 's';
+            });
+        }
+        
+        // Initialize flip animations when page loads
+        window.addEventListener('load', function() {
+            // Animate the main title
+            const title = document.querySelector('h1');
+            if (title) {
+                wrapTextInFlipChars(title);
+            }
+            
+            // Animate table headers
+            const headers = document.querySelectorAll('th');
+            headers.forEach(header => {
+                wrapTextInFlipChars(header);
+            });
+            
+            // Set up periodic flip animation for visual effect
+            setInterval(() => {
+                const randomRowNum = Math.floor(Math.random() * 5) + 1;
+                const randomRow = document.querySelector('tbody tr:nth-child(' + randomRowNum + ')');
+                if (randomRow) {
+                    const cells = randomRow.querySelectorAll('td');
+                    cells.forEach(cell => {
+                        cell.style.animation = 'flipUpdate 0.8s ease-in-out';
+                        setTimeout(() => {
+                            cell.style.animation = '';
+                        }, 800);
+                    });
+                }
+            }, 8000); // Random flip every 8 seconds
+            
+            // Add subtle continuous flip to title
+            setInterval(() => {
+                const titleChars = document.querySelectorAll('h1 .flip-char');
+                titleChars.forEach((char, index) => {
+                    setTimeout(() => {
+                        char.style.animation = 'flipIn 0.6s ease-in-out';
+                        setTimeout(() => {
+                            char.style.animation = '';
+                        }, 600);
+                    }, index * 100);
+                });
+            }, 15000); // Title flip every 15 seconds
+        });
+        
+        // Re-animate when page refreshes with new data
+        let lastUpdateTime = '{{.LastUpdate}}';
+        setInterval(() => {
+            // This would normally check for updates via AJAX, 
+            // but since we're using meta refresh, we'll just add visual flair
+            const updateTimeElement = document.querySelector('.update-time');
+            if (updateTimeElement) {
+                updateTimeElement.style.animation = 'flipUpdate 0.8s ease-in-out';
+                setTimeout(() => {
+                    updateTimeElement.style.animation = '';
+                }, 800);
+            }
+        }, 60000); // Visual update every minute
+    </script>
+</body>
+</html>
+`
+
+// Shared head styles and the navigation between the boards.
+const sharedTemplate = `
+{{define "style"}}
+    <style>
+        body { 
+            font-family: 'Courier New', monospace; 
+            margin: 0; 
+            padding: 20px;
+            background-color: #000000;
+            color: #FFFF00;
+        }
+        h1 { 
+            color: #FFFF00; 
+            text-align: center;
+            font-size: 2.5em;
+            margin-bottom: 10px;
+            text-transform: uppercase;
+            letter-spacing: 2px;
+        }
+        .header { 
+            background-color: #1a1a1a; 
+            padding: 15px; 
+            border: 2px solid #FFFF00;
+            margin-bottom: 20px;
+            text-align: center;
+        }
+        table { 
+            border-collapse: collapse; 
+            width: 100%; 
+            margin-top: 20px;
+            background-color: #000000;
+            border: 2px solid #FFFF00;
+        }
+        th, td { 
+            border: 1px solid #FFFF00; 
+            padding: 15px; 
+            text-align: left;
+            font-family: 'Courier New', monospace;
+            font-size: 0.9em;
+        }
+        th { 
+            background-color: #FFFF00; 
+            color: #000000;
+            font-weight: bold;
+            text-transform: uppercase;
+            letter-spacing: 1px;
+        }
+        td {
+            background-color: #000000;
+            color: #FFFFFF;
+        }
+        tr:nth-child(even) td { 
+            background-color: #1a1a1a;
+        }
+        tr:hover td {
+            background-color: #333333;
+        }
+        tr.watched td {
+            color: #FFFF00;
+            font-weight: bold;
+        }
+        .watch-label {
+            font-size: 0.8em;
+            color: #FF9900;
+        }
+        .military {
+            color: #FF4444;
+        }
+        .type-info {
+            font-size: 0.8em;
+            color: #AAAAAA;
+        }
+        .nav {
+            text-align: center;
+            margin-bottom: 20px;
+        }
+        .nav a {
+            color: #FFFF00;
+            margin: 0 12px;
+            font-weight: bold;
+            text-transform: uppercase;
+        }
+        .status-APPROACH, .status-DESCENDING { color: #FF9900; }
+        .status-LANDED, .status-DEPARTED { color: #00FF00; }
+        .status-CLIMBING, .status-TAXI { color: #00CCFF; }
+        .status-HOLDING { color: #FF4444; }
+        .runways { color: #00CCFF; }
+        .filters a {
+            color: #FFFF00;
+            margin: 0 6px;
+        }
+        tr.group-header td {
+            background-color: #FFFF00;
+            color: #000000;
+            font-weight: bold;
+            text-transform: uppercase;
+        }
+        .route-doubtful {
+            color: #888888;
+            font-style: italic;
+        }
+        .route-flag {
+            font-size: 0.8em;
+            color: #FF9900;
+        }
+        .airline-callsign {
+            font-size: 0.8em;
+            color: #AAAAAA;
+        }
+        .no-aircraft { 
+            color: #FFFF00; 
+            font-style: italic; 
+            text-align: center; 
+            padding: 40px;
+            font-size: 1.2em;
+            background-color: #1a1a1a;
+            border: 2px solid #FFFF00;
+            margin: 20px 0;
+        }
+        .update-time { 
+            color: #FFFF00; 
+            font-size: 1em;
+            margin: 5px 0;
+        }
+        .footer {
+            margin-top: 30px; 
+            padding-top: 20px; 
+            border-top: 2px solid #FFFF00; 
+            color: #FFFF00; 
+            font-size: 0.9em;
+            text-align: center;
+        }
+        
+        /* Flip animation for airport board effect */
+        @keyframes flipIn {
+            0% {
+                transform: rotateX(-90deg);
+                opacity: 0;
+            }
+            50% {
+                transform: rotateX(-45deg);
+                opacity: 0.5;
+            }
+            100% {
+                transform: rotateX(0deg);
+                opacity: 1;
+            }
+        }
+        
+        @keyframes flipOut {
+            0% {
+                transform: rotateX(0deg);
+                opacity: 1;
+            }
+            50% {
+                transform: rotateX(45deg);
+                opacity: 0.5;
+            }
+            100% {
+                transform: rotateX(90deg);
+                opacity: 0;
+            }
+        }
+        
+        .flip-char {
+            display: inline-block;
+            animation: flipIn 0.8s ease-in-out;
+            transform-origin: center;
+        }
+        
+        .flip-update {
+            animation: flipOut 0.4s ease-in-out, flipIn 0.4s ease-in-out 0.4s;
+        }
+        
+        /* Stagger animation delays for wave effect */
+        .flip-char:nth-child(1) { animation-delay: 0.1s; }
+        .flip-char:nth-child(2) { animation-delay: 0.2s; }
+        .flip-char:nth-child(3) { animation-delay: 0.3s; }
+        .flip-char:nth-child(4) { animation-delay: 0.4s; }
+        .flip-char:nth-child(5) { animation-delay: 0.5s; }
+        .flip-char:nth-child(6) { animation-delay: 0.6s; }
+        .flip-char:nth-child(7) { animation-delay: 0.7s; }
+        .flip-char:nth-child(8) { animation-delay: 0.8s; }
+        .flip-char:nth-child(9) { animation-delay: 0.9s; }
+        .flip-char:nth-child(10) { animation-delay: 1.0s; }
+        .flip-char:nth-child(11) { animation-delay: 1.1s; }
+        .flip-char:nth-child(12) { animation-delay: 1.2s; }
+        .flip-char:nth-child(13) { animation-delay: 1.3s; }
+        .flip-char:nth-child(14) { animation-delay: 1.4s; }
+        .flip-char:nth-child(15) { animation-delay: 1.5s; }
+        
+        /* Table rows animate in sequence */
+        tbody tr {
+            animation: flipIn 1.2s ease-in-out;
+        }
+        
+        tbody tr:nth-child(1) { animation-delay: 0.2s; }
+        tbody tr:nth-child(2) { animation-delay: 0.4s; }
+        tbody tr:nth-child(3) { animation-delay: 0.6s; }
+        tbody tr:nth-child(4) { animation-delay: 0.8s; }
+        tbody tr:nth-child(5) { animation-delay: 1.0s; }
+        tbody tr:nth-child(6) { animation-delay: 1.2s; }
+        tbody tr:nth-child(7) { animation-delay: 1.4s; }
+        tbody tr:nth-child(8) { animation-delay: 1.6s; }
+        
+        /* Header flip animation */
+        h1 .flip-char {
+            animation-duration: 1.5s;
+            animation-delay: calc(0.1s * var(--char-index));
+        }
+    </style>
+{{end}}
+{{define "holds"}}
+    {{if .Holding}}
+    <div class="header holds">
+        <p><strong>HOLDING:</strong> {{.Holding}} aircraft</p>
+        <table>
+            <thead><tr><th>Stack</th><th>For</th><th>Aircraft</th><th>Levels</th><th>Longest hold</th></tr></thead>
+            <tbody>
+            {{range .Stacks}}{{if .Count}}
+            <tr>
+                <td>{{.Name}} ({{.Ident}})</td>
+                <td>{{.Feeds}}</td>
+                <td>{{.Count}}{{if .Unsure}} ({{.Unsure}} unconfirmed){{end}}</td>
+                <td>{{range $i, $l := .Levels}}{{if $i}}, {{end}}{{$l.Level}}: {{range $j, $a := $l.Aircraft}}{{if $j}} {{end}}{{$a}}{{end}}{{end}}</td>
+                <td>{{printf "%.0f" .MaxMinutes}} min</td>
+            </tr>
+            {{end}}{{end}}
+            </tbody>
+        </table>
+    </div>
+    {{end}}
+{{end}}
+{{define "runways"}}
+    {{range .}}<p class="runways"><strong>RUNWAYS:</strong> {{.Summary}} since {{.Since.Format "15:04"}}</p>{{end}}
+{{end}}
+{{define "nav"}}
+    <div class="nav"><a href="/">All aircraft</a><a href="/arrivals">Arrivals</a><a href="/departures">Departures</a><a href="/overflights">Overflights</a></div>
+{{end}}
+`
+
+// Arrivals, departures and overflights boards
+const boardHTML = `
+<!DOCTYPE html>
+<html>
+<head>
+    <title>{{.Title}} - North London</title>
+    <meta http-equiv="refresh" content="60">
+    {{template "style"}}
+</head>
+<body>
+    <h1>✈ {{.Title}} ✈</h1>
+    {{template "nav"}}
+
+    <div class="header">
+        <p class="update-time"><strong>Last Updated:</strong> {{.LastUpdate}}</p>
+        {{if ne .Movement "overflight"}}<p class="filters">{{$path := .Path}}<a href="{{$path}}">All airports</a>{{range .Airports}}<a href="{{$path}}?airport={{.}}">{{.}}</a>{{end}}</p>{{end}}
+        {{template "runways" .RunwayOps}}
+    </div>
+    {{template "holds" .Holds}}
+
+    {{if .Aircraft}}
+    <table>
+        <thead>
+            <tr>
+                <th>Flight</th>
+                <th>Airline</th>
+                {{if ne .Movement "departure"}}<th>From</th>{{end}}
+                {{if ne .Movement "arrival"}}<th>To</th>{{end}}
+                {{if ne .Movement "overflight"}}<th>Airport</th>{{end}}
+                <th>Aircraft</th>
+                <th>Altitude</th>
+                <th>Status</th>
+            </tr>
+        </thead>
+        <tbody>
+            {{$movement := .Movement}}
+            {{range .Aircraft}}
+            <tr{{if .Watched}} class="watched"{{end}}>
+                <td>{{if .Watched}}&#9733; {{end}}{{with .FlightNumber}}{{.}}{{else}}{{if .Registration}}{{.Registration}}{{else}}{{.ICAO24}}{{end}}{{end}}{{if and .Callsign (ne .FlightNumber .Callsign)}}<br><span class="airline-callsign">{{.Callsign}}</span>{{end}}</td>
+                <td>{{if .Airline}}{{.Airline}}{{else}}-{{end}}</td>
+                {{if ne $movement "departure"}}<td{{if .RouteDoubtful}} class="route-doubtful" title="{{.RouteNote}}"{{end}}>{{with .OriginAirport}}{{if .City}}{{.City}}{{else}}{{.Name}}{{end}} ({{.ICAO}}){{else}}-{{end}}</td>{{end}}
+                {{if ne $movement "arrival"}}<td{{if .RouteDoubtful}} class="route-doubtful" title="{{.RouteNote}}"{{end}}>{{with .DestinationAirport}}{{if .City}}{{.City}}{{else}}{{.Name}}{{end}} ({{.ICAO}}){{else}}-{{end}}</td>{{end}}
+                {{if ne $movement "overflight"}}<td>{{.LocalAirport}}</td>{{end}}
+                <td>{{if .ICAOType}}{{.ICAOType}}{{else}}{{.Type}}{{end}}{{with .Registration}}<br><span class="type-info">{{.}}</span>{{end}}</td>
+                <td>{{.Altitude}}</td>
+                <td class="status-{{.Status}}">{{.Status}}</td>
+            </tr>
+            {{end}}
+        </tbody>
+    </table>
+    {{else}}
+    <div class="no-aircraft">
+        <p>No {{.Movement}}s at the moment.</p>
+    </div>
+    {{end}}
+</body>
+</html>
+`
@@ -0,0 +1,455 @@
+// Package web serves the departure-board page and the JSON API.
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"html/template"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"AirTraffic-Monitor/airports"
+	"AirTraffic-Monitor/fusion"
+	"AirTraffic-Monitor/holding"
+	"AirTraffic-Monitor/logging"
+	"AirTraffic-Monitor/monitor"
+	"AirTraffic-Monitor/noise"
+	"AirTraffic-Monitor/watchlist"
+)
+
+var (
+	pageTemplate  = template.Must(template.Must(template.New("aircraft").Parse(htmlTemplate)).Parse(sharedTemplate))
+	boardTemplate = template.Must(template.Must(template.New("board").Parse(boardHTML)).Parse(sharedTemplate))
+)
+
+// Server renders the store and manages the watchlist over HTTP. Airports
+// defaults to the bundled table and may be replaced after New; LocalAirports
+// lists the airports the arrivals and departures boards offer as filters.
+// Noise, if set before Handler, enables the noise routes. PredictMaxAge caps
+// the dead reckoning in /api and /api/positions, which estimates for Clock()
+// (time.Now unless replaying).
+type Server struct {
+	store     *monitor.Store
+	watchlist *watchlist.Watchlist
+	logs      *logging.Loggers
+
+	Airports      *airports.DB
+	LocalAirports []string
+	Noise         *noise.Meter
+	PredictMaxAge time.Duration
+	Clock         func() time.Time
+}
+
+// New returns a server reading from store. wl may be nil, which disables the watchlist routes.
+func New(store *monitor.Store, wl *watchlist.Watchlist, logs *logging.Loggers) *Server {
+	if logs == nil {
+		logs = logging.Discard()
+	}
+	return &Server{store: store, watchlist: wl, logs: logs, Airports: airports.Bundled(),
+		PredictMaxAge: monitor.DefaultPredictMaxAge, Clock: time.Now}
+}
+
+// Handler returns the routes wrapped in the access-log middleware.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", s.aircraftHandler)
+	mux.HandleFunc("/arrivals", s.boardHandler(monitor.MovementArrival, "Arrivals"))
+	mux.HandleFunc("/departures", s.boardHandler(monitor.MovementDeparture, "Departures"))
+	mux.HandleFunc("/overflights", s.boardHandler(monitor.MovementOverflight, "Overflights"))
+	mux.HandleFunc("/api", s.apiHandler)
+	mux.HandleFunc("/api/positions", s.positionsHandler)
+	mux.HandleFunc("/api/airports/{icao}", s.airportHandler)
+	mux.HandleFunc("/api/events", s.eventsHandler)
+	mux.HandleFunc("/api/holds", s.holdsHandler)
+	mux.HandleFunc("/api/runways", s.runwaysHandler)
+	mux.HandleFunc("/api/runways/{icao}", s.runwaysHandler)
+	mux.HandleFunc("/api/sources", s.sourcesHandler)
+	mux.HandleFunc("/api/anomalies", s.anomaliesHandler)
+	if s.Noise != nil {
+		mux.HandleFunc("/api/noise", s.noiseHandler)
+		mux.HandleFunc("/api/noise/report.csv", s.noiseReportHandler)
+		mux.HandleFunc("/api/noise/overflights.csv", s.noiseOverflightsHandler)
+	}
+	if s.watchlist != nil {
+		mux.HandleFunc("/api/watchlist", s.watchlistHandler)
+		mux.HandleFunc("/api/watchlist/{id}", s.watchlistEntryHandler)
+		mux.HandleFunc("/api/spotted", s.spottedHandler)
+	}
+	return logging.Middleware(s.logs.For(logging.ComponentWeb), mux)
+}
+
+// Web handler for the main page. It takes the same filters as /api, and group
+// splits the board by a type attribute, e.g. /?group=kind or /?kind=helicopter.
+func (s *Server) aircraftHandler(w http.ResponseWriter, r *http.Request) {
+	aircraft, lastUpdate := s.store.Snapshot()
+	q := r.URL.Query()
+	aircraft = filterAircraft(aircraft, q)
+	groups := []aircraftGroup{{Aircraft: aircraft}}
+	if by := q.Get("group"); by != "" {
+		var err error
+		if groups, err = groupAircraft(aircraft, by); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	data := struct {
+		Aircraft   []monitor.AircraftInfo
+		Groups     []aircraftGroup
+		Holds      holding.Report
+		RunwayOps  []monitor.RunwayOps
+		LastUpdate string
+	}{
+		Aircraft:   aircraft,
+		Groups:     groups,
+		Holds:      s.store.Holds(),
+		RunwayOps:  s.store.RunwayOps(),
+		LastUpdate: lastUpdate,
+	}
+
+	w.Header().Set("Content-Type", "text/html")
+	if err := pageTemplate.Execute(w, data); err != nil {
+		http.Error(w, "Template execution error", http.StatusInternalServerError)
+	}
+}
+
+// boardHandler renders the airport-style board for one movement, lowest first
+// with aircraft on the ground at the top. It takes the same filters as /api,
+// e.g. /arrivals?airport=EGLL.
+func (s *Server) boardHandler(movement, title string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		all, lastUpdate := s.store.Snapshot()
+		q := r.URL.Query()
+		q.Set("movement", movement)
+		aircraft := append([]monitor.AircraftInfo(nil), filterAircraft(all, q)...)
+		sort.SliceStable(aircraft, func(i, j int) bool {
+			return altitudeKey(aircraft[i]) < altitudeKey(aircraft[j])
+		})
+		data := struct {
+			Title      string
+			Path       string
+			Movement   string
+			Airports   []string
+			Aircraft   []monitor.AircraftInfo
+			Holds      holding.Report
+			RunwayOps  []monitor.RunwayOps
+			LastUpdate string
+		}{
+			Title:      strings.ToUpper(title),
+			Path:       r.URL.Path,
+			Movement:   movement,
+			Airports:   s.LocalAirports,
+			Aircraft:   aircraft,
+			LastUpdate: lastUpdate,
+		}
+		if movement == monitor.MovementArrival {
+			data.Holds = s.store.Holds()
+		}
+		if movement != monitor.MovementOverflight {
+			data.RunwayOps = s.store.RunwayOps()
+		}
+
+		w.Header().Set("Content-Type", "text/html")
+		if err := boardTemplate.Execute(w, data); err != nil {
+			http.Error(w, "Template execution error", http.StatusInternalServerError)
+		}
+	}
+}
+
+// altitudeKey orders aircraft on the ground first, then by altitude, unknown last.
+func altitudeKey(a monitor.AircraftInfo) float64 {
+	switch {
+	case a.OnGround:
+		return -1
+	case a.BaroAltitude == nil:
+		return math.MaxFloat64
+	}
+	return *a.BaroAltitude
+}
+
+// JSON API endpoint. Each aircraft with a position carries an Estimate of
+// where it is now, dead reckoned from its last report. origin_country and destination_country filter by ISO code
+// or country name, e.g. /api?destination_country=ES; kind, wake, engine_type,
+// engines, description, family and category filter by aircraft type, e.g.
+// /api?kind=helicopter or /api?wake=H,J. group adds per-group counts, e.g.
+// /api?group=wake.
+func (s *Server) apiHandler(w http.ResponseWriter, r *http.Request) {
+	aircraft, lastUpdate := s.store.Snapshot()
+	q := r.URL.Query()
+	aircraft = withEstimates(filterAircraft(aircraft, q), s.Clock(), s.PredictMaxAge)
+	var groups []aircraftGroup
+	if by := q.Get("group"); by != "" {
+		var err error
+		if groups, err = groupAircraft(aircraft, by); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	data := struct {
+		Aircraft   []monitor.AircraftInfo `json:"aircraft"`
+		LastUpdate string                 `json:"last_update"`
+		Count      int                    `json:"count"`
+		Groups     []aircraftGroup        `json:"groups,omitempty"`
+	}{
+		Aircraft:   aircraft,
+		LastUpdate: lastUpdate,
+		Count:      len(aircraft),
+		Groups:     groups,
+	}
+	writeJSON(w, http.StatusOK, data)
+}
+
+// withEstimates returns a copy of list with each aircraft's Estimate for now.
+func withEstimates(list []monitor.AircraftInfo, now time.Time, maxAge time.Duration) []monitor.AircraftInfo {
+	out := make([]monitor.AircraftInfo, len(list))
+	for i, a := range list {
+		a.Estimate = a.Predict(now, maxAge)
+		out[i] = a
+	}
+	return out
+}
+
+// position is one aircraft in /api/positions.
+type position struct {
+	ICAO24   string              `json:"icao24"`
+	Callsign string              `json:"callsign,omitempty"`
+	OnGround bool                `json:"on_ground"`
+	Track    *float64            `json:"track,omitempty"`    // degrees
+	Velocity *float64            `json:"velocity,omitempty"` // m/s
+	Measured measuredPosition    `json:"measured"`
+	Estimate *monitor.Prediction `json:"estimate"`
+}
+
+type measuredPosition struct {
+	Latitude  float64    `json:"lat"`
+	Longitude float64    `json:"lon"`
+	Altitude  *float64   `json:"altitude,omitempty"` // metres
+	At        *time.Time `json:"at,omitempty"`
+}
+
+// positionsHandler returns just what a map needs: each aircraft's last measured
+// position and its estimate for now, or for at (RFC 3339) to animate between
+// polls. It takes the same filters as /api, e.g. /api/positions?kind=helicopter.
+func (s *Server) positionsHandler(w http.ResponseWriter, r *http.Request) {
+	at := s.Clock()
+	if v := r.URL.Query().Get("at"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			http.Error(w, "at must be an RFC 3339 time", http.StatusBadRequest)
+			return
+		}
+		at = t
+	}
+	aircraft, lastUpdate := s.store.Snapshot()
+	positions := []position{}
+	for _, a := range filterAircraft(aircraft, r.URL.Query()) {
+		est := a.Predict(at, s.PredictMaxAge)
+		if est == nil {
+			continue
+		}
+		positions = append(positions, position{
+			ICAO24:   a.ICAO24,
+			Callsign: a.Callsign,
+			OnGround: a.OnGround,
+			Track:    a.Track,
+			Velocity: a.Velocity,
+			Measured: measuredPosition{Latitude: *a.Latitude, Longitude: *a.Longitude, Altitude: a.BaroAltitude, At: a.PositionTime},
+			Estimate: est,
+		})
+	}
+	writeJSON(w, http.StatusOK, struct {
+		Time       time.Time  `json:"time"`
+		LastUpdate string     `json:"last_update"`
+		Positions  []position `json:"positions"`
+	}{Time: at, LastUpdate: lastUpdate, Positions: positions})
+}
+
+// inCountry reports whether the airport is in country (ISO code or name); an
+// empty country matches everything, an unknown airport nothing.
+func inCountry(a *monitor.AirportInfo, country string) bool {
+	if country == "" {
+		return true
+	}
+	return a != nil && (strings.EqualFold(a.CountryISO, country) || strings.EqualFold(a.Country, country))
+}
+
+// airportHandler returns one airport with its runways from the airport table
+func (s *Server) airportHandler(w http.ResponseWriter, r *http.Request) {
+	airport, ok := s.Airports.Lookup(r.PathValue("icao"))
+	if !ok {
+		http.Error(w, "unknown airport", http.StatusNotFound)
+		return
+	}
+	writeJSON(w, http.StatusOK, airport)
+}
+
+// holdsHandler returns each stack's occupancy by level and the aircraft holding.
+func (s *Server) holdsHandler(w http.ResponseWriter, r *http.Request) {
+	report := s.store.Holds()
+	if report.Stacks == nil {
+		report.Stacks = []holding.StackStatus{}
+	}
+	if report.Holds == nil {
+		report.Holds = []holding.Hold{}
+	}
+	writeJSON(w, http.StatusOK, report)
+}
+
+// sourcesHandler returns each position source's health and contribution to the
+// last cycle, preferred source first.
+func (s *Server) sourcesHandler(w http.ResponseWriter, r *http.Request) {
+	stats := s.store.Sources()
+	if stats == nil {
+		stats = []fusion.SourceStats{}
+	}
+	writeJSON(w, http.StatusOK, stats)
+}
+
+// runwaysHandler returns the inferred direction of operation and runways in use
+// at each local airport, or at one with /api/runways/{icao}. Airports without
+// enough recent traffic to tell are left out.
+func (s *Server) runwaysHandler(w http.ResponseWriter, r *http.Request) {
+	ops := []monitor.RunwayOps{}
+	code := r.PathValue("icao")
+	for _, o := range s.store.RunwayOps() {
+		if code == "" || strings.EqualFold(o.Airport, code) {
+			ops = append(ops, o)
+		}
+	}
+	if code != "" {
+		if len(ops) == 0 {
+			http.Error(w, "no runway operations known for "+code, http.StatusNotFound)
+			return
+		}
+		writeJSON(w, http.StatusOK, ops[0])
+		return
+	}
+	writeJSON(w, http.StatusOK, ops)
+}
+
+// eventsHandler returns the event history, most recent first. type, airport,
+// runway and icao24 filter it (comma-separated alternatives) and limit caps
+// the count, e.g. /api/events?type=takeoff,landing&airport=EGLL&limit=20.
+func (s *Server) eventsHandler(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	limit := 0
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			http.Error(w, "limit must be a positive integer", http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+	filters := map[string]func(monitor.Event) string{
+		"type":    func(e monitor.Event) string { return e.Type },
+		"airport": func(e monitor.Event) string { return e.Airport },
+		"runway":  func(e monitor.Event) string { return e.Runway },
+		"icao24":  func(e monitor.Event) string { return e.ICAO24 },
+	}
+	events := []monitor.Event{}
+	for _, ev := range s.store.Events() {
+		ok := true
+		for name, field := range filters {
+			if v := q.Get(name); v != "" && !matchesAny(field(ev), strings.Split(v, ",")) {
+				ok = false
+				break
+			}
+		}
+		if !ok {
+			continue
+		}
+		events = append(events, ev)
+		if limit > 0 && len(events) == limit {
+			break
+		}
+	}
+	writeJSON(w, http.StatusOK, events)
+}
+
+// watchlistHandler lists (GET) or creates (POST) watchlist entries
+func (s *Server) watchlistHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, s.watchlist.Entries())
+	case http.MethodPost:
+		var entry watchlist.Entry
+		if err := json.NewDecoder(r.Body).Decode(&entry); err != nil {
+			http.Error(w, "invalid JSON: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		created, err := s.watchlist.Add(entry)
+		if err != nil {
+			http.Error(w, err.Error(), watchlistErrorStatus(err))
+			return
+		}
+		writeJSON(w, http.StatusCreated, created)
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// watchlistEntryHandler reads (GET), replaces (PUT) or removes (DELETE) one watchlist entry
+func (s *Server) watchlistEntryHandler(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	switch r.Method {
+	case http.MethodGet:
+		entry, err := s.watchlist.Entry(id)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		writeJSON(w, http.StatusOK, entry)
+	case http.MethodPut:
+		var entry watchlist.Entry
+		if err := json.NewDecoder(r.Body).Decode(&entry); err != nil {
+			http.Error(w, "invalid JSON: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		updated, err := s.watchlist.Update(id, entry)
+		if err != nil {
+			http.Error(w, err.Error(), watchlistErrorStatus(err))
+			return
+		}
+		writeJSON(w, http.StatusOK, updated)
+	case http.MethodDelete:
+		if err := s.watchlist.Delete(id); err != nil {
+			http.Error(w, err.Error(), watchlistErrorStatus(err))
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.Header().Set("Allow", "GET, PUT, DELETE")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// watchlistErrorStatus maps a watchlist error to its HTTP status: rejected
+// entries are the client's fault, anything else is a failure to persist
+func watchlistErrorStatus(err error) int {
+	var ve *watchlist.ValidationError
+	switch {
+	case errors.As(err, &ve):
+		return http.StatusBadRequest
+	case errors.Is(err, watchlist.ErrNotFound):
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// spottedHandler returns the spotted log of watched aircraft
+func (s *Server) spottedHandler(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.watchlist.Spotted())
+}
+
+// writeJSON encodes v as the response body with the given status code
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,107 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"AirTraffic-Monitor/monitor"
+)
+
+var measured = time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+
+// trafficServer returns a server holding an eastbound airliner measured at
+// noon, a parked aircraft and two suspects without positions, with Clock a
+// minute after noon.
+func trafficServer(t *testing.T) http.Handler {
+	t.Helper()
+	store := monitor.NewStore()
+	store.Update([]monitor.AircraftInfo{
+		{ICAO24: "4CA123", Callsign: "RYR1AB", Latitude: ptr(51.5), Longitude: ptr(-0.1), BaroAltitude: ptr(3000),
+			Velocity: ptr(200), Track: ptr(90), PositionTime: &measured},
+		{ICAO24: "400A0B", Latitude: ptr(51.47), Longitude: ptr(-0.45), OnGround: true, PositionTime: &measured},
+		{ICAO24: "3C6444", Callsign: "DLH9X", PositionTime: &measured, AnomalyScore: 0.72,
+			Anomalies: []monitor.Anomaly{{Kind: monitor.AnomalySpeed, Detail: "ground speed 778 kt", Weight: 0.5}}},
+		{ICAO24: "A1B2C3", Callsign: "N123AB", AnomalyScore: 0.2,
+			Anomalies: []monitor.Anomaly{{Kind: monitor.AnomalyOperator, Detail: "callsign N123AB", Weight: 0.2}}},
+	}, "12:00:00")
+	store.AddEvents([]monitor.Event{{Time: measured, Type: monitor.EventAnomaly, ICAO24: "3C6444", Detail: "impossible_speed"},
+		{Time: measured, Type: monitor.EventEnter, ICAO24: "4CA123"}})
+	s := New(store, nil, nil)
+	s.Clock = func() time.Time { return measured.Add(time.Minute) }
+	return s.Handler()
+}
+
+func TestPositions(t *testing.T) {
+	h := trafficServer(t)
+	tests := []struct {
+		url           string
+		wantStatus    int
+		wantAt        time.Time
+		wantPredicted map[string]bool // by ICAO24; aircraft without a position are left out
+		wantCapped    bool
+	}{
+		{"/api/positions", http.StatusOK, measured.Add(time.Minute),
+			map[string]bool{"4CA123": true, "400A0B": false}, false},
+		{"/api/positions?at=2026-10-18T12:00:00Z", http.StatusOK, measured,
+			map[string]bool{"4CA123": false, "400A0B": false}, false},
+		{"/api/positions?at=2026-10-18T13:00:00Z", http.StatusOK, measured.Add(monitor.DefaultPredictMaxAge),
+			map[string]bool{"4CA123": true, "400A0B": false}, true},
+		{"/api/positions?at=noon", http.StatusBadRequest, time.Time{}, nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			w := get(t, h, tt.url)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			var data struct {
+				Positions []struct {
+					ICAO24   string             `json:"icao24"`
+					Estimate monitor.Prediction `json:"estimate"`
+				} `json:"positions"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &data); err != nil {
+				t.Fatal(err)
+			}
+			if len(data.Positions) != len(tt.wantPredicted) {
+				t.Fatalf("%d positions, want %d", len(data.Positions), len(tt.wantPredicted))
+			}
+			for _, p := range data.Positions {
+				want, ok := tt.wantPredicted[p.ICAO24]
+				if !ok || p.Estimate.Predicted != want {
+					t.Errorf("%s predicted %v, want %v", p.ICAO24, p.Estimate.Predicted, want)
+				}
+				if !want {
+					continue
+				}
+				if !p.Estimate.At.Equal(tt.wantAt) || p.Estimate.Capped != tt.wantCapped || p.Estimate.UncertaintyKm <= 0.1 {
+					t.Errorf("%s estimate at %v capped %v ±%v km, want %v and %v", p.ICAO24,
+						p.Estimate.At, p.Estimate.Capped, p.Estimate.UncertaintyKm, tt.wantAt, tt.wantCapped)
+				}
+			}
+		})
+	}
+}
+
+func TestAPIEstimates(t *testing.T) {
+	w := get(t, trafficServer(t), "/api")
+	var data struct {
+		Aircraft []monitor.AircraftInfo `json:"aircraft"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &data); err != nil {
+		t.Fatal(err)
+	}
+	for _, a := range data.Aircraft {
+		if hasPosition := a.Latitude != nil; (a.Estimate != nil) != hasPosition {
+			t.Errorf("%s: estimate %+v, position %v", a.ICAO24, a.Estimate, hasPosition)
+		}
+		if a.ICAO24 == "4CA123" && (a.Estimate == nil || !a.Estimate.Predicted || a.Estimate.AgeSeconds != 60) {
+			t.Errorf("4CA123 estimate %+v, want predicted a minute on", a.Estimate)
+		}
+	}
+}